@@ -0,0 +1,8 @@
+package agents
+
+import "embed"
+
+// AgentsFS holds the curated agent definitions bundled into the binary.
+//
+//go:embed *.agent.md
+var AgentsFS embed.FS
@@ -0,0 +1,8 @@
+package instructions
+
+import "embed"
+
+// InstructionsFS holds the curated instruction files bundled into the binary.
+//
+//go:embed *.instructions.md
+var InstructionsFS embed.FS
@@ -14,12 +14,52 @@ import (
 )
 
 var (
-	applyForce   bool
-	applyLink    bool
-	applyRefresh bool
-	applyGlobal  bool
+	applyForce    bool
+	applyLink     bool
+	applyRefresh  bool
+	applyGlobal   bool
+	applyOnly     []string
+	applyKinds    []string
+	applyTargets  []string
+	applyAll      bool
+	applyProfiles []string
 )
 
+// selectedProfiles returns the profiles chosen with --profile, falling back
+// to the comma-separated $PV_PROFILE.
+func selectedProfiles(flag []string) []string {
+	if len(flag) > 0 {
+		return flag
+	}
+	return manifest.ParseProfileList(os.Getenv(manifest.ProfileEnvVar))
+}
+
+// buildApplyFilter validates the --only, --kind and --target selectors and
+// converts them to an engine.ApplyFilter. --only entries have the form
+// <resource-type>:<name>, e.g. "agents:reviewer".
+func buildApplyFilter(only, kinds, targets []string) (engine.ApplyFilter, error) {
+	var f engine.ApplyFilter
+	for _, sel := range only {
+		typ, name, ok := strings.Cut(sel, ":")
+		if !ok || name == "" {
+			return f, fmt.Errorf("invalid --only selector %q (want <resource-type>:<name>)", sel)
+		}
+		rt, err := ParseResourceType(typ)
+		if err != nil {
+			return f, err
+		}
+		f.Only = append(f.Only, engine.ResourceSelector{Kind: opKindForResourceType(rt), Name: name})
+	}
+	var err error
+	if f.Kinds, err = parseKindFlags(kinds); err != nil {
+		return f, err
+	}
+	if f.Targets, err = parseTargetFlags(targets); err != nil {
+		return f, err
+	}
+	return f, nil
+}
+
 func globalApplyNoOpMessage(m *manifest.Manifest) (string, bool) {
 	if m == nil {
 		return "", false
@@ -41,7 +81,12 @@ func resolveManifestForApply(project, globalPath string, globalOnly bool) (*mani
 			return nil, fmt.Errorf("error loading global manifest: %w", err)
 		}
 		manifest.ResolveManifestPaths(m, filepath.Dir(globalPath))
-		return m, nil
+		// The system config's baseline and policy apply here too.
+		sys, err := manifest.LoadSystemManifest()
+		if err != nil {
+			return nil, err
+		}
+		return manifest.MergeManifests(sys, m), nil
 	}
 
 	if _, _, err := manifest.LoadManifestFromProject(project); err != nil {
@@ -73,17 +118,72 @@ func formatOverrideWarnings(d manifest.RiskyOverrideDiagnostics) string {
 	return "Warning: local config overrides change resource source type:\n" + strings.Join(lines, "\n") + "\n"
 }
 
+// syncGeneratedIgnores updates the managed .gitignore and .git/info/exclude
+// blocks for project according to the manifest's generated mode. Resources
+// that no committed manifest (workspace root or project) declares are
+// personal and go to the exclude file.
+func syncGeneratedIgnores(project, globalPath string) error {
+	var mode string
+	if m, err := manifest.LoadMergedManifest(project, globalPath); err == nil {
+		mode = m.Generated
+	}
+	layers, _ := manifest.LoadManifestLayers(project, globalPath)
+	return engine.SyncGitIgnore(project, mode, func(rec engine.InstallRecord) bool {
+		for _, l := range layers {
+			if (l.Kind == manifest.LayerWorkspace || l.Kind == manifest.LayerLocal) && manifestHasResource(l.Manifest, rec.Kind, rec.Name) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
 var applyCmd = &cobra.Command{
 	Use:   "apply",
 	Short: "Apply manifest to all targets",
+	Long: `Install every resource in the manifest into every configured target.
+
+Use --only, --kind and --target to apply part of the manifest. Registries
+that the selection does not need are neither fetched nor refreshed.
+
+In a workspace (a root vibes.yaml with "workspace: [services/*]"), --all
+applies every package into its own directory, each inheriting the root
+manifest.
+
+Examples:
+  positive-vibes apply
+  positive-vibes apply --only skills:code-review,agents:reviewer
+  positive-vibes apply --kind instructions --target cursor
+  positive-vibes apply --profile frontend,ci
+  positive-vibes apply --all`,
 	Run: func(cmd *cobra.Command, args []string) {
 		project := ProjectDir()
 		globalPath := defaultGlobalManifestPath()
+
+		filter, err := buildApplyFilter(applyOnly, applyKinds, applyTargets)
+		if err != nil {
+			fmt.Printf("error: %v\n", err)
+			return
+		}
+		if applyAll {
+			if applyGlobal {
+				fmt.Println("error: --all and --global cannot be combined")
+				return
+			}
+			applyWorkspace(project, globalPath, filter)
+			return
+		}
+
 		merged, err := resolveManifestForApply(project, globalPath, applyGlobal)
 		if err != nil {
 			fmt.Printf("%v\n", err)
 			return
 		}
+		profiles := selectedProfiles(applyProfiles)
+		if merged, err = merged.WithProfiles(profiles); err != nil {
+			fmt.Printf("error: %v\n", err)
+			return
+		}
 
 		if !applyGlobal {
 			var globalM, localM *manifest.Manifest
@@ -103,67 +203,223 @@ var applyCmd = &cobra.Command{
 			}
 		}
 
-		// registries
-		regs := []registry.SkillSource{registry.NewEmbeddedRegistry()}
-		regs = append(regs, gitRegistriesFromManifest(merged)...)
-
-		// Refresh git registries if requested
-		if applyRefresh {
-			for _, r := range regs {
-				if gr, ok := r.(*registry.GitRegistry); ok {
-					debugf("refreshing registry %s ...", gr.Name())
-					if err := gr.Refresh(); err != nil {
-						fmt.Printf("warning: refresh %s failed: %v\n", gr.Name(), err)
-					}
-				}
-			}
+		if len(profiles) > 0 {
+			fmt.Printf("Profiles: %s\n", strings.Join(profiles, ", "))
 		}
-
-		applier := engine.NewApplier(regs)
-		opts := target.InstallOpts{Force: applyForce, Link: applyLink}
-
 		fmt.Println("Aligning your AI tools...")
 		fmt.Println()
-		res, err := applier.ApplyManifest(merged, project, opts)
+		res, err := applyProject(project, globalPath, merged, filter, map[string]bool{})
 		if err != nil {
 			fmt.Printf("error: %v\n", err)
 			return
 		}
 
-		// Print per-operation lines
-		for _, op := range res.Ops {
-			kind := string(op.Kind)
-			if kind == "" {
-				kind = "skill"
+		// Summary line
+		fmt.Println()
+		fmt.Println(formatApplySummary(res, filter))
+	},
+}
+
+// applyProject applies merged into project and prints one line per
+// operation. Git registries are refreshed at most once per cache across
+// calls sharing refreshed.
+func applyProject(project, globalPath string, merged *manifest.Manifest, filter engine.ApplyFilter, refreshed map[string]bool) (*engine.ApplyResult, error) {
+	// A manifest the policy rejects is never applied; check before --refresh
+	// fetches anything.
+	if err := merged.EnforcePolicy(); err != nil {
+		return nil, err
+	}
+
+	// registries
+	regs := []registry.SkillSource{registry.NewEmbeddedRegistry()}
+	regs = append(regs, registriesFromManifest(merged)...)
+
+	applier := engine.NewApplier(regs)
+	applier.Filter = filter
+	applier.CacheRoot = defaultCacheRoot()
+
+	// Refresh remote registries if requested, skipping ones the selection
+	// never reads from
+	if applyRefresh {
+		needed, all, err := applier.NeededRegistries(merged)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range regs {
+			if !all && !contains(needed, r.Name()) {
+				continue
 			}
-			switch op.Status {
-			case engine.OpInstalled:
-				fmt.Printf("  installed %s: %s -> %s\n", kind, op.SkillName, op.TargetName)
-			case engine.OpSkipped:
-				fmt.Printf("  skipped %s:   %s -> %s (already exists)\n", kind, op.SkillName, op.TargetName)
-			case engine.OpNotFound:
-				fmt.Printf("  not found %s: %s\n", kind, op.SkillName)
-			case engine.OpError:
-				fmt.Printf("  error %s:     %s -> %s: %s\n", kind, op.SkillName, op.TargetName, op.Error)
+			if gr, ok := r.(registry.Refresher); ok {
+				if refreshed[gr.CacheDir()] {
+					continue
+				}
+				refreshed[gr.CacheDir()] = true
+				debugf("refreshing registry %s ...", gr.Name())
+				if err := gr.Refresh(); err != nil {
+					fmt.Printf("warning: refresh %s failed: %v\n", gr.Name(), err)
+				}
 			}
 		}
+	}
 
-		// Summary line
-		fmt.Println()
-		if res.Installed > 0 {
-			fmt.Printf("Done. Installed %d, skipped %d, errors %d.\n", res.Installed, res.Skipped, len(res.Errors))
-		} else if res.Skipped > 0 {
-			fmt.Printf("Already in sync. %d items up to date. Use --force to reinstall.\n", res.Skipped)
-		} else {
-			fmt.Println("Nothing to install. Check your manifest.")
+	opts := target.InstallOpts{Force: applyForce, Link: applyLink}
+
+	res, err := applier.ApplyManifest(merged, project, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Print(formatApplyOps(res))
+	if err := syncGeneratedIgnores(project, globalPath); err != nil {
+		fmt.Printf("  warning: update git ignore entries: %v\n", err)
+	}
+	return res, nil
+}
+
+// formatApplyOps renders one line per apply operation, followed by warnings.
+func formatApplyOps(res *engine.ApplyResult) string {
+	var b strings.Builder
+	for _, op := range res.Ops {
+		kind := string(op.Kind)
+		if kind == "" {
+			kind = "skill"
 		}
-	},
+		switch op.Status {
+		case engine.OpInstalled:
+			fmt.Fprintf(&b, "  installed %s: %s -> %s\n", kind, op.SkillName, op.TargetName)
+		case engine.OpSkipped:
+			fmt.Fprintf(&b, "  skipped %s:   %s -> %s (already exists)\n", kind, op.SkillName, op.TargetName)
+		case engine.OpNotFound:
+			fmt.Fprintf(&b, "  not found %s: %s\n", kind, op.SkillName)
+		case engine.OpError:
+			fmt.Fprintf(&b, "  error %s:     %s -> %s: %s\n", kind, op.SkillName, op.TargetName, op.Error)
+		}
+	}
+	for _, w := range res.Warnings {
+		fmt.Fprintf(&b, "  warning: %s\n", w)
+	}
+	return b.String()
+}
+
+// formatApplySummary returns the one-line outcome of an apply.
+func formatApplySummary(res *engine.ApplyResult, filter engine.ApplyFilter) string {
+	switch {
+	case res.Installed > 0:
+		return fmt.Sprintf("Done. Installed %d, skipped %d, errors %d.", res.Installed, res.Skipped, len(res.Errors))
+	case res.Skipped > 0:
+		return fmt.Sprintf("Already in sync. %d items up to date. Use --force to reinstall.", res.Skipped)
+	case len(res.Errors) > 0:
+		return fmt.Sprintf("Failed. Errors %d.", len(res.Errors))
+	case !filter.IsZero():
+		return "Nothing to install. No resources match the selection."
+	default:
+		return "Nothing to install. Check your manifest."
+	}
+}
+
+// workspacePackageDirs returns the directories apply --all installs into:
+// the workspace root (when its own manifest declares resources) followed by
+// every package. project may be the root or any package; the returned root
+// and directories are absolute.
+func workspacePackageDirs(project string) (string, []string, error) {
+	root, err := filepath.Abs(project)
+	if err != nil {
+		return "", nil, err
+	}
+	m, _, err := manifest.LoadManifestFromProject(root)
+	if err != nil || len(m.Workspace) == 0 {
+		r, ok := manifest.FindWorkspaceRoot(root)
+		if !ok {
+			return "", nil, fmt.Errorf("no workspace found: no manifest at or above %s declares workspace patterns", project)
+		}
+		root = r
+		if m, _, err = manifest.LoadManifestFromProject(root); err != nil {
+			return "", nil, err
+		}
+	}
+
+	pkgs, err := manifest.WorkspacePackages(root, m)
+	if err != nil {
+		return "", nil, err
+	}
+	// The root is a package of its own only when it declares resources;
+	// targets alone are just defaults for the packages.
+	var dirs []string
+	if len(m.Skills)+len(m.Instructions)+len(m.Agents) > 0 {
+		dirs = append(dirs, root)
+	}
+	return root, append(dirs, pkgs...), nil
+}
+
+// applyWorkspace applies every workspace package into its own directory,
+// grouping output per package.
+func applyWorkspace(project, globalPath string, filter engine.ApplyFilter) {
+	root, dirs, err := workspacePackageDirs(project)
+	if err != nil {
+		fmt.Printf("error: %v\n", err)
+		return
+	}
+	if len(dirs) == 0 {
+		fmt.Println("Nothing to install. The workspace has no packages.")
+		return
+	}
+
+	// A selection only has to match somewhere in the workspace.
+	filter.Lenient = true
+	profiles := selectedProfiles(applyProfiles)
+	refreshed := map[string]bool{}
+	total := &engine.ApplyResult{}
+	failed := 0
+
+	if len(profiles) > 0 {
+		fmt.Printf("Profiles: %s\n", strings.Join(profiles, ", "))
+	}
+	fmt.Println("Aligning your AI tools...")
+	for _, dir := range dirs {
+		label, err := filepath.Rel(root, dir)
+		if err != nil {
+			label = dir
+		}
+		if label == "." {
+			label = "(workspace root)"
+		}
+		fmt.Printf("\n%s\n", label)
+
+		merged, err := manifest.LoadMergedManifest(dir, globalPath)
+		if err == nil {
+			merged, err = merged.WithProfiles(profiles)
+		}
+		if err != nil {
+			fmt.Printf("  error: %v\n", err)
+			failed++
+			continue
+		}
+		res, err := applyProject(dir, globalPath, merged, filter, refreshed)
+		if err != nil {
+			fmt.Printf("  error: %v\n", err)
+			failed++
+			continue
+		}
+		fmt.Printf("  %s\n", formatApplySummary(res, filter))
+		total.Installed += res.Installed
+		total.Skipped += res.Skipped
+		total.Errors = append(total.Errors, res.Errors...)
+	}
+
+	fmt.Println()
+	fmt.Printf("Workspace done. %d packages (%d failed). Installed %d, skipped %d, errors %d.\n",
+		len(dirs), failed, total.Installed, total.Skipped, len(total.Errors))
 }
 
 func init() {
 	applyCmd.Flags().BoolVarP(&applyForce, "force", "f", false, "overwrite existing skills")
-	applyCmd.Flags().BoolVarP(&applyLink, "link", "l", false, "symlink skills instead of copying")
-	applyCmd.Flags().BoolVar(&applyRefresh, "refresh", false, "pull latest from git registries before applying")
+	applyCmd.Flags().BoolVarP(&applyLink, "link", "l", false, "symlink resources instead of copying")
+	applyCmd.Flags().BoolVar(&applyRefresh, "refresh", false, "pull latest from git and archive registries before applying")
 	applyCmd.Flags().BoolVar(&applyGlobal, "global", false, "apply only global config to current project targets")
+	applyCmd.Flags().StringSliceVar(&applyProfiles, "profile", nil, "apply these manifest profiles, in order (default from $PV_PROFILE)")
+	applyCmd.Flags().BoolVar(&applyAll, "all", false, "apply every package of the workspace into its own directory")
+	applyCmd.Flags().StringSliceVar(&applyOnly, "only", nil, "apply only these resources, e.g. skills:code-review,agents:reviewer")
+	applyCmd.Flags().StringSliceVar(&applyKinds, "kind", nil, "apply only these resource types (skills, instructions, agents)")
+	applyCmd.Flags().StringSliceVar(&applyTargets, "target", nil, "apply only to these targets (vscode-copilot, opencode, cursor)")
 	rootCmd.AddCommand(applyCmd)
 }
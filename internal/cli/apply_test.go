@@ -4,8 +4,12 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/chaz8081/positive-vibes/internal/engine"
 	"github.com/chaz8081/positive-vibes/internal/manifest"
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -105,3 +109,180 @@ func TestGlobalApplyNoOpMessage_WhenNoInstallableResources(t *testing.T) {
 	assert.Contains(t, msg, "No-op")
 	assert.Contains(t, msg, "global config has no installable resources")
 }
+
+func TestSyncGeneratedIgnores_SplitsSharedAndPersonal(t *testing.T) {
+	projectDir := t.TempDir()
+	globalPath := filepath.Join(t.TempDir(), "vibes.yaml")
+	require.NoError(t, os.MkdirAll(filepath.Join(projectDir, ".git", "info"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "vibes.yaml"), []byte("generated: ignore\nskills:\n  - name: tdd\ntargets:\n  - cursor\n"), 0o644))
+	require.NoError(t, os.WriteFile(globalPath, []byte("skills:\n  - name: my-notes\n"), 0o644))
+
+	state := &engine.InstallState{Records: []engine.InstallRecord{
+		{Target: "cursor", Kind: engine.KindSkill, Name: "tdd", Path: ".cursor/skills/tdd"},
+		{Target: "cursor", Kind: engine.KindSkill, Name: "my-notes", Path: ".cursor/skills/my-notes"},
+	}}
+	require.NoError(t, engine.SaveInstallState(projectDir, state))
+
+	require.NoError(t, syncGeneratedIgnores(projectDir, globalPath))
+
+	ignore, err := os.ReadFile(filepath.Join(projectDir, ".gitignore"))
+	require.NoError(t, err)
+	assert.Contains(t, string(ignore), "/.cursor/skills/tdd/")
+	assert.NotContains(t, string(ignore), "my-notes")
+
+	exclude, err := os.ReadFile(filepath.Join(projectDir, ".git", "info", "exclude"))
+	require.NoError(t, err)
+	assert.Contains(t, string(exclude), "/.cursor/skills/my-notes/")
+}
+
+func TestBuildApplyFilter_ParsesSelectors(t *testing.T) {
+	f, err := buildApplyFilter([]string{"skills:code-review", "agents:reviewer"}, []string{"agents"}, []string{"cursor"})
+	require.NoError(t, err)
+	assert.Equal(t, []engine.ResourceSelector{
+		{Kind: engine.KindSkill, Name: "code-review"},
+		{Kind: engine.KindAgent, Name: "reviewer"},
+	}, f.Only)
+	assert.Equal(t, []engine.ApplyOpKind{engine.KindAgent}, f.Kinds)
+	assert.Equal(t, []string{"cursor"}, f.Targets)
+}
+
+func TestBuildApplyFilter_RejectsInvalidSelectors(t *testing.T) {
+	for _, only := range []string{"code-review", "skills:", "widgets:x"} {
+		_, err := buildApplyFilter([]string{only}, nil, nil)
+		assert.Error(t, err, only)
+	}
+	_, err := buildApplyFilter(nil, nil, []string{"emacs"})
+	assert.Error(t, err)
+}
+
+func TestApplyCommand_HasSelectorFlags(t *testing.T) {
+	for _, name := range []string{"only", "kind", "target"} {
+		assert.NotNil(t, applyCmd.Flags().Lookup(name), name)
+	}
+}
+
+func TestWorkspacePackageDirs_FromRootAndPackage(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "vibes.yaml"), []byte("workspace:\n  - services/*\ntargets:\n  - cursor\n"), 0o644))
+	for _, svc := range []string{"api", "web"} {
+		dir := filepath.Join(root, "services", svc)
+		require.NoError(t, os.MkdirAll(dir, 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "vibes.yaml"), []byte("targets:\n  - cursor\n"), 0o644))
+	}
+	want := []string{filepath.Join(root, "services", "api"), filepath.Join(root, "services", "web")}
+
+	gotRoot, dirs, err := workspacePackageDirs(root)
+	require.NoError(t, err)
+	assert.Equal(t, root, gotRoot)
+	assert.Equal(t, want, dirs, "a root declaring only targets is not applied itself")
+
+	gotRoot, dirs, err = workspacePackageDirs(filepath.Join(root, "services", "web"))
+	require.NoError(t, err)
+	assert.Equal(t, root, gotRoot)
+	assert.Equal(t, want, dirs)
+
+	require.NoError(t, os.WriteFile(filepath.Join(root, "vibes.yaml"), []byte("workspace:\n  - services/*\nskills:\n  - name: shared\n"), 0o644))
+	t.Chdir(root)
+	gotRoot, dirs, err = workspacePackageDirs(".")
+	require.NoError(t, err)
+	assert.Equal(t, root, gotRoot, "a relative project yields an absolute root")
+	assert.Equal(t, append([]string{root}, want...), dirs, "a root declaring resources is applied itself")
+
+	_, _, err = workspacePackageDirs(t.TempDir())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no workspace found")
+}
+
+func TestFormatApplySummary(t *testing.T) {
+	assert.Equal(t, "Done. Installed 2, skipped 1, errors 0.", formatApplySummary(&engine.ApplyResult{Installed: 2, Skipped: 1}, engine.ApplyFilter{}))
+	assert.Equal(t, "Nothing to install. No resources match the selection.",
+		formatApplySummary(&engine.ApplyResult{}, engine.ApplyFilter{Kinds: []engine.ApplyOpKind{engine.KindAgent}}))
+	assert.Equal(t, "Nothing to install. Check your manifest.", formatApplySummary(&engine.ApplyResult{}, engine.ApplyFilter{}))
+}
+
+func TestSelectedProfiles_FlagOverridesEnv(t *testing.T) {
+	t.Setenv(manifest.ProfileEnvVar, "backend, ci")
+	assert.Equal(t, []string{"backend", "ci"}, selectedProfiles(nil))
+	assert.Equal(t, []string{"frontend"}, selectedProfiles([]string{"frontend"}))
+
+	t.Setenv(manifest.ProfileEnvVar, "")
+	assert.Empty(t, selectedProfiles(nil))
+}
+
+// newSkillRepo commits a registry holding one skill to a new git repository
+// and returns its path.
+func newSkillRepo(t *testing.T, skill string) string {
+	t.Helper()
+	repo := t.TempDir()
+	r, err := gogit.PlainInit(repo, false)
+	require.NoError(t, err)
+	dir := filepath.Join(repo, "skills", skill)
+	require.NoError(t, os.MkdirAll(dir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "SKILL.md"), []byte("---\nname: "+skill+"\ndescription: test skill\n---\n# "+skill+"\n"), 0o644))
+	wt, err := r.Worktree()
+	require.NoError(t, err)
+	_, err = wt.Add("skills")
+	require.NoError(t, err)
+	_, err = wt.Commit("add "+skill, &gogit.CommitOptions{Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()}})
+	require.NoError(t, err)
+	return repo
+}
+
+var rogueRegistryPolicy = &manifest.Policy{AllowedRegistries: []string{"https://github.com/myorg/*"}}
+
+func TestRegistriesFromManifest_SkipsDisallowedRegistries(t *testing.T) {
+	m := &manifest.Manifest{
+		Policy: rogueRegistryPolicy,
+		Registries: []manifest.RegistryRef{
+			{Name: "approved", URL: "https://github.com/myorg/skills", Ref: "v1"},
+			{Name: "rogue", URL: "https://example.com/skills", Ref: "v1"},
+		},
+	}
+	sources := registriesFromManifest(m)
+	require.Len(t, sources, 1)
+	assert.Equal(t, "approved", sources[0].Name())
+}
+
+func TestApplyProject_RefreshNeverFetchesDisallowedRegistries(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	old := applyRefresh
+	applyRefresh = true
+	t.Cleanup(func() { applyRefresh = old })
+
+	merged := &manifest.Manifest{
+		Policy:     rogueRegistryPolicy,
+		Registries: []manifest.RegistryRef{{Name: "rogue", URL: newSkillRepo(t, "secret"), Ref: "latest"}},
+		Skills:     []manifest.SkillRef{{Name: "secret"}},
+		Targets:    []string{"cursor"},
+	}
+	project := t.TempDir()
+	_, err := applyProject(project, filepath.Join(t.TempDir(), "vibes.yaml"), merged, engine.ApplyFilter{}, map[string]bool{})
+	var pe *manifest.PolicyError
+	require.ErrorAs(t, err, &pe)
+	assert.NoDirExists(t, filepath.Join(home, ".positive-vibes", "cache"), "a disallowed registry must not be fetched")
+	assert.NoDirExists(t, filepath.Join(project, ".cursor", "skills", "secret"))
+}
+
+func TestInstallSkillsRun_NeverSearchesDisallowedRegistries(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	global := defaultGlobalManifestPath()
+	require.NoError(t, os.MkdirAll(filepath.Dir(global), 0o755))
+	require.NoError(t, os.WriteFile(global, []byte("policy:\n  allowed_registries:\n    - https://github.com/myorg/*\n"), 0o644))
+
+	project := t.TempDir()
+	manifestPath := filepath.Join(project, "vibes.yaml")
+	require.NoError(t, os.WriteFile(manifestPath, []byte("registries:\n  - name: rogue\n    url: "+newSkillRepo(t, "secret")+"\n    ref: latest\n"), 0o644))
+	old := projectDir
+	projectDir = project
+	t.Cleanup(func() { projectDir = old })
+
+	installSkillsRun([]string{"secret"})
+
+	data, err := os.ReadFile(manifestPath)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "name: secret", "a skill only a disallowed registry provides must not be added")
+	assert.NoDirExists(t, filepath.Join(home, ".positive-vibes", "cache"), "a disallowed registry must not be cloned")
+}
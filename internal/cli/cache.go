@@ -0,0 +1,427 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/chaz8081/positive-vibes/internal/manifest"
+	"github.com/chaz8081/positive-vibes/internal/registry"
+	"github.com/spf13/cobra"
+)
+
+var (
+	cacheListJSON bool
+	cachePruneYes bool
+)
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Inspect and clean the registry cache",
+	Long: `Git and archive registries are cloned or downloaded into
+~/.positive-vibes/cache/<name>-<key> on first use and reused afterwards. The
+key is a hash of the registry's URL and ref, so registries that share a name
+but not a source get separate caches. Every ref of a git repository borrows
+its objects from one store, ~/.positive-vibes/cache/objects/<key>.`,
+}
+
+var cacheListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Show cached registries",
+	Long: `Shows each cached registry's size, the ref your manifests ask for (or
+the checked-out branch), the checked-out commit, the last clone or refresh,
+and its URL. Caches marked with * are not used by the project or global
+manifest; remove them with 'positive-vibes cache prune'.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		caches, err := registry.ListCaches(defaultCacheRoot())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		known, err := knownCacheRefs(ProjectDir(), defaultGlobalManifestPath())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %v; caches it uses are marked unused\n", err)
+		}
+		if cacheListJSON {
+			fmt.Println(formatCacheListJSON(caches, known))
+			return
+		}
+		fmt.Print(formatCacheList(caches, known))
+	},
+}
+
+var cacheVerifyCmd = &cobra.Command{
+	Use:   "verify [name...]",
+	Short: "Check cached registries for corrupt or partial clones",
+	Long: `Checks that every cached registry (or just the named caches or
+registries) is complete.
+A git cache needs a readable HEAD commit with all of its files present. An
+archive cache needs its extracted tree. A damaged cache is still used as a
+fallback when the registry can't be reached, so remove it with
+'positive-vibes cache clean <name>' to fetch it again on next use.
+
+Exits with status 1 if any cache has problems.`,
+	ValidArgsFunction: completeCacheNames,
+	Run: func(cmd *cobra.Command, args []string) {
+		caches, err := selectCaches(defaultCacheRoot(), args)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		out, failed := formatCacheVerify(caches)
+		fmt.Print(out)
+		if failed > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+var cacheCleanCmd = &cobra.Command{
+	Use:   "clean [name...]",
+	Short: "Delete cached registries",
+	Long: `Deletes the named caches, or every cache when no name is given. A
+registry name deletes every cache of that registry (one per URL and ref).
+Object stores no remaining cache uses are deleted with them. Registries are
+fetched again the next time they are used.`,
+	ValidArgsFunction: completeCacheNames,
+	Run: func(cmd *cobra.Command, args []string) {
+		caches, err := selectCaches(defaultCacheRoot(), args)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		if len(caches) == 0 {
+			fmt.Println("The cache is empty.")
+			return
+		}
+		fmt.Print(removeCaches(caches, false))
+	},
+}
+
+var cachePruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Delete caches no manifest uses",
+	Long: `Lists caches of registries that neither the global manifest nor the
+current project's manifests (including every package of its workspace)
+declare, and leftovers of interrupted downloads. Pass --yes to delete them.
+Caches used only by other projects on this machine are deleted too; they are
+fetched again when next used.
+
+Nothing is deleted when any of those manifests fails to load, since the
+caches it uses can't be told apart from unused ones.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		caches, err := registry.ListCaches(defaultCacheRoot())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		known, err := knownCacheRefs(ProjectDir(), defaultGlobalManifestPath())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\nNothing was pruned. Fix the manifest, or delete specific caches with 'positive-vibes cache clean <name>'.\n", err)
+			os.Exit(1)
+		}
+		unused := unusedCaches(caches, known)
+		if len(unused) == 0 {
+			fmt.Println("Nothing to prune.")
+			return
+		}
+		fmt.Print(removeCaches(unused, !cachePruneYes))
+		if !cachePruneYes {
+			fmt.Println("Run 'positive-vibes cache prune --yes' to delete them.")
+		}
+	},
+}
+
+// knownCacheRefs returns the registries declared by the global manifest
+// and the project's merged manifest (every workspace package's, when the
+// project is part of a workspace), keyed by cache name: the cache directory
+// name and, for git registries, "objects/<key>" of their object store.
+// Directory registries have no cache and are left out. Missing manifests
+// are skipped, but one that fails to load is an error.
+func knownCacheRefs(projectDir, globalPath string) (map[string]manifest.RegistryRef, error) {
+	known := make(map[string]manifest.RegistryRef)
+	add := func(m *manifest.Manifest) {
+		if m == nil {
+			return
+		}
+		for _, r := range m.Registries {
+			if r.SourceType() == manifest.RegistryTypeDir {
+				continue
+			}
+			known[filepath.Base(registryCachePath(r))] = r
+			if r.SourceType() == manifest.RegistryTypeGit {
+				known[registry.ObjectStoreDir+"/"+registry.RepoKey(r.URL)] = r
+			}
+		}
+	}
+
+	g, err := manifest.LoadManifest(globalPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("global manifest %s: %w", globalPath, err)
+	}
+	add(g)
+	dirs := []string{projectDir}
+	if _, pkgs, err := workspacePackageDirs(projectDir); err == nil {
+		dirs = append(dirs, pkgs...)
+	}
+	for _, dir := range dirs {
+		layers, err := manifest.LoadManifestLayers(dir, globalPath)
+		if err != nil {
+			return nil, fmt.Errorf("manifests of %s: %w", dir, err)
+		}
+		if len(layers) > 0 {
+			add(manifest.MergeLayers(layers))
+		}
+	}
+	return known, nil
+}
+
+// selectCaches returns the caches under root named in names, or all of
+// them when names is empty. A registry name selects every cache of that
+// registry. Object stores that only selected caches borrow from are selected
+// with them.
+func selectCaches(root string, names []string) ([]registry.CacheInfo, error) {
+	caches, err := registry.ListCaches(root)
+	if err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		return caches, nil
+	}
+	selected := make(map[string]bool)
+	var out []registry.CacheInfo
+	pick := func(c registry.CacheInfo) {
+		if !selected[c.Path] {
+			selected[c.Path] = true
+			out = append(out, c)
+		}
+	}
+	for _, name := range names {
+		found := false
+		for _, c := range caches {
+			if c.Name == name || c.Kind != registry.CacheKindStore && registry.CacheOf(c.Name, name) {
+				pick(c)
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("no cache or registry named %q (see 'positive-vibes cache list')", name)
+		}
+	}
+
+	// Stores are matched by path, as the clones record them.
+	borrowed := make(map[string]bool)
+	for _, c := range caches {
+		if c.Store != "" && !selected[c.Path] {
+			borrowed[filepath.Clean(c.Store)] = true
+		}
+	}
+	for _, c := range out {
+		if c.Store == "" || borrowed[filepath.Clean(c.Store)] {
+			continue
+		}
+		for _, s := range caches {
+			if s.Kind == registry.CacheKindStore && filepath.Clean(s.Path) == filepath.Clean(c.Store) {
+				pick(s)
+			}
+		}
+	}
+	return out, nil
+}
+
+// unusedCaches returns the caches not in known, including leftover
+// download directories.
+func unusedCaches(caches []registry.CacheInfo, known map[string]manifest.RegistryRef) []registry.CacheInfo {
+	var out []registry.CacheInfo
+	for _, c := range caches {
+		if _, ok := known[c.Name]; !ok || c.Kind == registry.CacheKindDownload {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// removeCaches deletes caches (or only reports them when dryRun) and
+// returns one line per cache and a total.
+func removeCaches(caches []registry.CacheInfo, dryRun bool) string {
+	verb := "Removed"
+	if dryRun {
+		verb = "Would remove"
+	}
+	var b strings.Builder
+	var freed int64
+	removed := 0
+	for _, c := range caches {
+		if !dryRun {
+			if err := os.RemoveAll(c.Path); err != nil {
+				fmt.Fprintf(&b, "  error: %s: %v\n", c.Name, err)
+				continue
+			}
+		}
+		fmt.Fprintf(&b, "  %s %s (%s)\n", strings.ToLower(verb), c.Name, formatBytes(c.Size))
+		freed += c.Size
+		removed++
+	}
+	fmt.Fprintf(&b, "%s %d cache(s), %s.\n", verb, removed, formatBytes(freed))
+	return b.String()
+}
+
+// cacheRef is the ref shown for a cache: the manifest's, else the
+// checked-out branch. Object stores hold every ref and show none.
+func cacheRef(c registry.CacheInfo, known map[string]manifest.RegistryRef) string {
+	if c.Kind == registry.CacheKindStore {
+		return ""
+	}
+	if r, ok := known[c.Name]; ok && r.Ref != "" {
+		return r.Ref
+	}
+	return c.Branch
+}
+
+func formatCacheList(caches []registry.CacheInfo, known map[string]manifest.RegistryRef) string {
+	if len(caches) == 0 {
+		return "The cache is empty.\n"
+	}
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSIZE\tREF\tCOMMIT\tLAST REFRESH\tURL")
+	var total int64
+	unused := 0
+	for _, c := range caches {
+		name := c.Name
+		if _, ok := known[c.Name]; !ok {
+			name += " *"
+			unused++
+		}
+		commit := c.Commit
+		if len(commit) > 12 {
+			commit = commit[:12]
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", name, formatBytes(c.Size), orDash(cacheRef(c, known)), orDash(commit), formatRefreshTime(c.LastRefresh), orDash(registry.RedactURL(c.URL)))
+		total += c.Size
+	}
+	w.Flush()
+	fmt.Fprintf(&b, "\n%d cache(s), %s", len(caches), formatBytes(total))
+	if unused > 0 {
+		fmt.Fprintf(&b, "; %d not used by any known manifest (*)", unused)
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+type cacheJSON struct {
+	Name        string `json:"name"`
+	Path        string `json:"path"`
+	Kind        string `json:"kind"`
+	Size        int64  `json:"size"`
+	URL         string `json:"url,omitempty"`
+	Ref         string `json:"ref,omitempty"`
+	Commit      string `json:"commit,omitempty"`
+	Shallow     bool   `json:"shallow,omitempty"`
+	LastRefresh string `json:"last_refresh,omitempty"`
+	Used        bool   `json:"used"`
+}
+
+func formatCacheListJSON(caches []registry.CacheInfo, known map[string]manifest.RegistryRef) string {
+	out := make([]cacheJSON, 0, len(caches))
+	for _, c := range caches {
+		_, used := known[c.Name]
+		entry := cacheJSON{
+			Name: c.Name, Path: c.Path, Kind: c.Kind, Size: c.Size, URL: registry.RedactURL(c.URL),
+			Ref: cacheRef(c, known), Commit: c.Commit, Shallow: c.Shallow, Used: used,
+		}
+		if !c.LastRefresh.IsZero() {
+			entry.LastRefresh = c.LastRefresh.UTC().Format(time.RFC3339)
+		}
+		out = append(out, entry)
+	}
+	data, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return fmt.Sprintf(`{"error": %q}`, err.Error())
+	}
+	return string(data)
+}
+
+// formatCacheVerify verifies each cache and reports the problems found,
+// returning how many caches failed.
+func formatCacheVerify(caches []registry.CacheInfo) (string, int) {
+	if len(caches) == 0 {
+		return "The cache is empty.\n", 0
+	}
+	var b strings.Builder
+	failed := 0
+	for _, c := range caches {
+		problems := registry.VerifyCache(c.Path)
+		if len(problems) == 0 {
+			fmt.Fprintf(&b, "  ok      %s\n", c.Name)
+			continue
+		}
+		failed++
+		fmt.Fprintf(&b, "  broken  %s\n", c.Name)
+		for _, p := range problems {
+			fmt.Fprintf(&b, "          %v\n", p)
+		}
+	}
+	if failed > 0 {
+		fmt.Fprintf(&b, "%d of %d cache(s) have problems. Run 'positive-vibes cache clean <name>' to fetch them again on next use.\n", failed, len(caches))
+	} else {
+		fmt.Fprintf(&b, "All %d cache(s) are healthy.\n", len(caches))
+	}
+	return b.String(), failed
+}
+
+func formatRefreshTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// formatBytes renders n in binary units, e.g. "1.5 MiB".
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// completeCacheNames suggests the registry names caches belong to, then
+// every cache directory name.
+func completeCacheNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	caches, _ := registry.ListCaches(defaultCacheRoot())
+	var registries, names []string
+	for _, c := range caches {
+		if r := registry.CacheRegistryName(c.Name); r != "" {
+			registries = append(registries, r)
+		}
+		names = append(names, c.Name)
+	}
+	return dedup(append(registries, names...)), cobra.ShellCompDirectiveNoFileComp
+}
+
+func init() {
+	cacheListCmd.Flags().BoolVar(&cacheListJSON, "json", false, "output as JSON")
+	cachePruneCmd.Flags().BoolVarP(&cachePruneYes, "yes", "y", false, "delete the unused caches instead of only listing them")
+	cacheCmd.AddCommand(cacheListCmd, cacheVerifyCmd, cacheCleanCmd, cachePruneCmd)
+	rootCmd.AddCommand(cacheCmd)
+}
@@ -0,0 +1,158 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/chaz8081/positive-vibes/internal/engine"
+	"github.com/chaz8081/positive-vibes/internal/manifest"
+	"github.com/spf13/cobra"
+)
+
+var (
+	cleanTargets []string
+	cleanKinds   []string
+	cleanDryRun  bool
+)
+
+// opKindForResourceType maps a CLI resource type to the engine's op kind.
+func opKindForResourceType(rt ResourceType) engine.ApplyOpKind {
+	switch rt {
+	case ResourceInstructions:
+		return engine.KindInstruction
+	case ResourceAgents:
+		return engine.KindAgent
+	default:
+		return engine.KindSkill
+	}
+}
+
+// parseTargetFlags validates --target values against the supported targets.
+func parseTargetFlags(targets []string) ([]string, error) {
+	var out []string
+	for _, t := range targets {
+		if !contains(manifest.ValidTargets, t) {
+			return nil, fmt.Errorf("unknown target %q", t)
+		}
+		out = append(out, t)
+	}
+	return out, nil
+}
+
+// parseKindFlags converts --kind values (skills, instructions, agents) to op kinds.
+func parseKindFlags(kinds []string) ([]engine.ApplyOpKind, error) {
+	var out []engine.ApplyOpKind
+	for _, k := range kinds {
+		rt, err := ParseResourceType(k)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, opKindForResourceType(rt))
+	}
+	return out, nil
+}
+
+// buildCleanOpts validates --target and --kind values and converts them to
+// engine.CleanOpts.
+func buildCleanOpts(targets, kinds []string, dryRun bool) (engine.CleanOpts, error) {
+	opts := engine.CleanOpts{DryRun: dryRun}
+	var err error
+	if opts.Targets, err = parseTargetFlags(targets); err != nil {
+		return opts, err
+	}
+	if opts.Kinds, err = parseKindFlags(kinds); err != nil {
+		return opts, err
+	}
+	return opts, nil
+}
+
+// formatRemovalOps renders one line per uninstall op and any warnings.
+func formatRemovalOps(res *engine.CleanResult, verb string) string {
+	var b strings.Builder
+	for _, op := range res.Ops {
+		switch op.Status {
+		case engine.OpRemoved:
+			fmt.Fprintf(&b, "  %s %s: %s -> %s\n", verb, op.Kind, op.SkillName, op.TargetName)
+		case engine.OpSkipped:
+			fmt.Fprintf(&b, "  skipped %s:   %s -> %s (%s)\n", op.Kind, op.SkillName, op.TargetName, op.Error)
+		case engine.OpNotFound:
+			fmt.Fprintf(&b, "  not found %s: %s -> %s\n", op.Kind, op.SkillName, op.TargetName)
+		case engine.OpError:
+			fmt.Fprintf(&b, "  error %s:     %s -> %s: %s\n", op.Kind, op.SkillName, op.TargetName, op.Error)
+		}
+	}
+	for _, w := range res.Warnings {
+		fmt.Fprintf(&b, "  warning: %s\n", w)
+	}
+	return b.String()
+}
+
+// formatCleanResult renders per-resource clean results and a summary line.
+func formatCleanResult(res *engine.CleanResult, dryRun bool) string {
+	verb := "removed"
+	if dryRun {
+		verb = "would remove"
+	}
+
+	var b strings.Builder
+	b.WriteString(formatRemovalOps(res, verb))
+
+	b.WriteString("\n")
+	switch {
+	case len(res.Ops) == 0:
+		b.WriteString("Nothing to clean. No recorded installs match.\n")
+	case dryRun:
+		fmt.Fprintf(&b, "Dry run. Would remove %d, skip %d. Files:\n", res.Removed, res.Skipped)
+		for _, p := range res.Paths {
+			fmt.Fprintf(&b, "  %s\n", p)
+		}
+	default:
+		fmt.Fprintf(&b, "Done. Removed %d, skipped %d, errors %d.\n", res.Removed, res.Skipped, len(res.Errors))
+	}
+	return b.String()
+}
+
+var cleanCmd = &cobra.Command{
+	Use:   "clean",
+	Short: "Uninstall everything positive-vibes generated",
+	Long: `Remove every file and symlink positive-vibes installed into this project,
+using the install state recorded by apply. Files you authored, and installed
+files you have since modified, are never removed. Directories are removed
+only when empty and only if positive-vibes created them.
+
+Examples:
+  positive-vibes clean --dry-run             # preview what would be removed
+  positive-vibes clean                       # remove everything
+  positive-vibes clean --target cursor       # only Cursor files
+  positive-vibes clean --kind agents         # only agents, all targets`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		opts, err := buildCleanOpts(cleanTargets, cleanKinds, cleanDryRun)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return
+		}
+
+		res, err := engine.Clean(ProjectDir(), opts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			if res == nil {
+				return
+			}
+		}
+		if !cleanDryRun {
+			if err := syncGeneratedIgnores(ProjectDir(), defaultGlobalManifestPath()); err != nil {
+				res.Warnings = append(res.Warnings, fmt.Sprintf("update git ignore entries: %v", err))
+			}
+		}
+		fmt.Print(formatCleanResult(res, cleanDryRun))
+	},
+}
+
+func init() {
+	cleanCmd.Flags().StringSliceVar(&cleanTargets, "target", nil, "only clean these targets (vscode-copilot, opencode, cursor)")
+	cleanCmd.Flags().StringSliceVar(&cleanKinds, "kind", nil, "only clean these resource types (skills, instructions, agents)")
+	cleanCmd.Flags().BoolVar(&cleanDryRun, "dry-run", false, "show what would be removed without deleting anything")
+	rootCmd.AddCommand(cleanCmd)
+}
@@ -0,0 +1,43 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/chaz8081/positive-vibes/internal/engine"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildCleanOpts_MapsKindsAndTargets(t *testing.T) {
+	opts, err := buildCleanOpts([]string{"cursor"}, []string{"agents", "skills"}, true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cursor"}, opts.Targets)
+	assert.Equal(t, []engine.ApplyOpKind{engine.KindAgent, engine.KindSkill}, opts.Kinds)
+	assert.True(t, opts.DryRun)
+}
+
+func TestBuildCleanOpts_RejectsUnknownValues(t *testing.T) {
+	_, err := buildCleanOpts([]string{"notepad"}, nil, false)
+	require.Error(t, err)
+
+	_, err = buildCleanOpts(nil, []string{"widgets"}, false)
+	require.Error(t, err)
+}
+
+func TestFormatCleanResult_DryRunListsPaths(t *testing.T) {
+	res := &engine.CleanResult{
+		Removed: 1,
+		Ops: []engine.ApplyOp{
+			{SkillName: "reviewer", TargetName: "cursor", Kind: engine.KindAgent, Status: engine.OpRemoved},
+		},
+		Paths: []string{".cursor/agents/reviewer.md"},
+	}
+	out := formatCleanResult(res, true)
+	assert.Contains(t, out, "would remove agent: reviewer -> cursor")
+	assert.Contains(t, out, ".cursor/agents/reviewer.md")
+}
+
+func TestFormatCleanResult_NothingToClean(t *testing.T) {
+	out := formatCleanResult(&engine.CleanResult{}, false)
+	assert.Contains(t, out, "Nothing to clean")
+}
@@ -97,12 +97,20 @@ func relativePathsNoEffectNote(m *manifest.Manifest) string {
 func formatPaths(globalPath, projectDir, cacheDir string) string {
 	var b strings.Builder
 
+	// System config status (organization-wide, merged first)
+	systemPath := manifest.SystemManifestPath()
+	systemStatus := "[not found]"
+	if _, err := os.Stat(systemPath); err == nil {
+		systemStatus = "[found]"
+	}
+	fmt.Fprintf(&b, "System config:   %s  %s\n", systemPath, systemStatus)
+
 	// Global config status
 	globalStatus := "[not found]"
 	if _, err := os.Stat(globalPath); err == nil {
 		globalStatus = "[found]"
 	}
-	fmt.Fprintf(&b, "Global config:  %s  %s\n", globalPath, globalStatus)
+	fmt.Fprintf(&b, "Global config:   %s  %s\n", globalPath, globalStatus)
 
 	// Local config status -- check vibes.yaml then vibes.yml
 	localStatus := "[not found]"
@@ -122,15 +130,23 @@ func formatPaths(globalPath, projectDir, cacheDir string) string {
 	if localStatus == "[not found]" {
 		localPath = filepath.Join(projectDir, "vibes.yaml")
 	}
-	fmt.Fprintf(&b, "Local config:   %s  %s\n", localPath, localStatus)
+	fmt.Fprintf(&b, "Local config:    %s  %s\n", localPath, localStatus)
+
+	// Personal config status (vibes.local.yaml, merged last)
+	personalPath := manifest.PersonalManifestPath(projectDir)
+	personalStatus := "[not found]"
+	if _, err := os.Stat(personalPath); err == nil {
+		personalStatus = "[found]"
+	}
+	fmt.Fprintf(&b, "Personal config: %s  %s\n", personalPath, personalStatus)
 
 	// Project dir and cache
 	absProject, err := filepath.Abs(projectDir)
 	if err != nil {
 		absProject = projectDir
 	}
-	fmt.Fprintf(&b, "Project dir:    %s\n", absProject)
-	fmt.Fprintf(&b, "Cache dir:      %s\n", cacheDir)
+	fmt.Fprintf(&b, "Project dir:     %s\n", absProject)
+	fmt.Fprintf(&b, "Cache dir:       %s\n", cacheDir)
 
 	return b.String()
 }
@@ -154,6 +170,46 @@ type annotateRenderOptions struct {
 	RelativePaths bool
 	ProjectDir    string
 	GlobalPath    string
+	// Sources attributes values to the layer they came from (see
+	// manifest.ValueSources). Values from extended, system, workspace or
+	// personal manifests are tagged with that file instead of
+	// [global]/[local].
+	Sources map[string]manifest.Layer
+}
+
+// layerTag returns the annotation for a value whose winning layer is not the
+// global or project manifest itself, or "" to fall back to [global]/[local].
+func (o annotateRenderOptions) layerTag(section, name string) string {
+	l, ok := o.Sources[manifest.SourceKey(section, name)]
+	if !ok {
+		return ""
+	}
+	src := l.Source
+	if filepath.IsAbs(src) {
+		src = pathForDisplay(src, o.ProjectDir, true)
+	}
+	switch {
+	case l.ExtendedBy != "":
+		return fmt.Sprintf("# [extends %s]", src)
+	case l.Kind == manifest.LayerSystem:
+		return fmt.Sprintf("# [system %s]", src)
+	case l.Kind == manifest.LayerWorkspace:
+		return fmt.Sprintf("# [workspace %s]", src)
+	case l.Kind == manifest.LayerPersonal:
+		return fmt.Sprintf("# [personal %s]", src)
+	case l.Kind == manifest.LayerProfile:
+		return fmt.Sprintf("# [profile %s]", src)
+	default:
+		return ""
+	}
+}
+
+// tagOr returns the layer tag for a value, or fallback when it has none.
+func (o annotateRenderOptions) tagOr(section, name, fallback string) string {
+	if tag := o.layerTag(section, name); tag != "" {
+		return tag
+	}
+	return fallback
 }
 
 func pathForDisplay(path string, root string, relative bool) string {
@@ -238,9 +294,9 @@ func annotateManifestWithOptions(global, local, merged *manifest.Manifest, opts
 	if len(merged.Registries) > 0 {
 		b.WriteString("registries:\n")
 		for _, r := range merged.Registries {
-			tag := sourceTag(globalRegs[r.Name], localRegs[r.Name])
+			tag := opts.tagOr("registries", r.Name, sourceTag(globalRegs[r.Name], localRegs[r.Name]))
 			b.WriteString(fmt.Sprintf("  - name: %s  %s\n", r.Name, tag))
-			b.WriteString(fmt.Sprintf("    url: %s\n", r.URL))
+			b.WriteString(fmt.Sprintf("    url: %s\n", registry.RedactURL(r.URL)))
 		}
 	}
 
@@ -248,7 +304,7 @@ func annotateManifestWithOptions(global, local, merged *manifest.Manifest, opts
 	if len(merged.Skills) > 0 {
 		b.WriteString("skills:\n")
 		for _, s := range merged.Skills {
-			tag := sourceTag(globalSkills[s.Name], localSkills[s.Name])
+			tag := opts.tagOr("skills", s.Name, sourceTag(globalSkills[s.Name], localSkills[s.Name]))
 			b.WriteString(fmt.Sprintf("  - name: %s  %s\n", s.Name, tag))
 			if s.Registry != "" {
 				b.WriteString(fmt.Sprintf("    registry: %s\n", s.Registry))
@@ -275,17 +331,26 @@ func annotateManifestWithOptions(global, local, merged *manifest.Manifest, opts
 		if targetsFromLocal {
 			targetsSource = "# [local]"
 		}
-		b.WriteString(fmt.Sprintf("targets: %s\n", targetsSource))
+		b.WriteString(fmt.Sprintf("targets: %s\n", opts.tagOr("targets", "", targetsSource)))
 		for _, t := range merged.Targets {
 			b.WriteString(fmt.Sprintf("  - %s\n", t))
 		}
 	}
 
+	// Generated
+	if merged.Generated != "" {
+		generatedSource := "# [global]"
+		if local != nil && local.Generated != "" {
+			generatedSource = "# [local]"
+		}
+		b.WriteString(fmt.Sprintf("generated: %s  %s\n", merged.Generated, opts.tagOr("generated", "", generatedSource)))
+	}
+
 	// Instructions
 	if len(merged.Instructions) > 0 {
 		b.WriteString("instructions:\n")
 		for _, inst := range merged.Instructions {
-			tag := sourceTag(globalInstructions[inst.Name], localInstructions[inst.Name])
+			tag := opts.tagOr("instructions", inst.Name, sourceTag(globalInstructions[inst.Name], localInstructions[inst.Name]))
 			b.WriteString(fmt.Sprintf("  - name: %s  %s\n", inst.Name, tag))
 			if inst.Registry != "" {
 				b.WriteString(fmt.Sprintf("    registry: %s\n", inst.Registry))
@@ -313,7 +378,7 @@ func annotateManifestWithOptions(global, local, merged *manifest.Manifest, opts
 	if len(merged.Agents) > 0 {
 		b.WriteString("agents:\n")
 		for _, a := range merged.Agents {
-			tag := sourceTag(globalAgents[a.Name], localAgents[a.Name])
+			tag := opts.tagOr("agents", a.Name, sourceTag(globalAgents[a.Name], localAgents[a.Name]))
 			b.WriteString(fmt.Sprintf("  - name: %s  %s\n", a.Name, tag))
 			if a.Registry != "" {
 				b.WriteString(fmt.Sprintf("    registry: %s\n", a.Registry))
@@ -444,7 +509,7 @@ func validateConfigWithContext(m *manifest.Manifest, embeddedSkills []string, ha
 	// Check each instruction with a path
 	for _, inst := range m.Instructions {
 		if inst.Registry != "" {
-			if !registryNameExists(inst.Registry, m.Registries) {
+			if !registryNameExists(inst.Registry, m.Registries) && inst.Registry != "embedded" {
 				result.add(inst.Name, "registry not found: "+inst.Registry)
 			}
 		} else if inst.Path != "" {
@@ -457,7 +522,7 @@ func validateConfigWithContext(m *manifest.Manifest, embeddedSkills []string, ha
 	// Check each agent with a path
 	for _, a := range m.Agents {
 		if a.Registry != "" {
-			if !registryNameExists(a.Registry, m.Registries) {
+			if !registryNameExists(a.Registry, m.Registries) && a.Registry != "embedded" {
 				result.add(a.Name, "registry not found: "+a.Registry)
 			}
 		} else if a.Path != "" {
@@ -467,6 +532,10 @@ func validateConfigWithContext(m *manifest.Manifest, embeddedSkills []string, ha
 		}
 	}
 
+	for _, v := range m.CheckPolicy() {
+		result.add(v.Field, v.Message)
+	}
+
 	for _, p := range localGlobalRegistryDependencyProblems(global, local) {
 		result.add(p.field, p.message)
 	}
@@ -487,6 +556,68 @@ func validateConfigWithContext(m *manifest.Manifest, embeddedSkills []string, ha
 	return result
 }
 
+// lockProblems reports attempts to override, disable or remove entries a
+// lower layer marks locked: true. Merging ignores them, so they never take
+// effect.
+func lockProblems(layers []manifest.Layer) []configProblem {
+	var out []configProblem
+	for _, v := range manifest.LockViolations(layers) {
+		out = append(out, configProblem{
+			field:   v.Name,
+			message: fmt.Sprintf("%s in %s is ignored: %s is locked by %s", v.Action, v.Source, v.Kind(), v.LockedBy),
+		})
+	}
+	return out
+}
+
+// schemaProblems checks each manifest file against the vibes.yaml JSON
+// Schema. Problems are keyed by "file:line:column"; missing files are
+// skipped.
+func schemaProblems(paths ...string) []configProblem {
+	var out []configProblem
+	for _, p := range paths {
+		data, err := os.ReadFile(p)
+		if err != nil {
+			continue
+		}
+		for _, e := range manifest.ValidateSchema(data) {
+			field := fmt.Sprintf("%s:%d", p, e.Line)
+			if e.Column > 0 {
+				field += fmt.Sprintf(":%d", e.Column)
+			}
+			msg := e.Message
+			if e.Path != "" {
+				msg = e.Path + ": " + msg
+			}
+			out = append(out, configProblem{field: field, message: msg})
+		}
+	}
+	return out
+}
+
+// variableProblems reports ${VAR} references without a default to unset
+// variables in each manifest file, keyed like schemaProblems.
+func variableProblems(paths ...string) []configProblem {
+	var out []configProblem
+	for _, p := range paths {
+		data, err := os.ReadFile(p)
+		if err != nil {
+			continue
+		}
+		unset, err := manifest.UnsetVariables(data)
+		if err != nil {
+			continue // reported by schemaProblems
+		}
+		for _, u := range unset {
+			out = append(out, configProblem{
+				field:   fmt.Sprintf("%s:%d:%d", p, u.Line, u.Column),
+				message: fmt.Sprintf("%s: environment variable %s is not set (set it or use ${%s:-default})", u.Path, u.Name, u.Name),
+			})
+		}
+	}
+	return out
+}
+
 func registryNameExists(name string, regs []manifest.RegistryRef) bool {
 	for _, r := range regs {
 		if r.Name == name {
@@ -558,7 +689,9 @@ func collectAvailableSkillsFromSources(sources []registry.SkillSource) (map[stri
 func namesFromSkills(items []manifest.SkillRef) map[string]bool {
 	m := make(map[string]bool, len(items))
 	for _, it := range items {
-		m[it.Name] = true
+		if !it.Disabled() {
+			m[it.Name] = true
+		}
 	}
 	return m
 }
@@ -566,7 +699,9 @@ func namesFromSkills(items []manifest.SkillRef) map[string]bool {
 func namesFromInstructions(items []manifest.InstructionRef) map[string]bool {
 	m := make(map[string]bool, len(items))
 	for _, it := range items {
-		m[it.Name] = true
+		if !it.Disabled() {
+			m[it.Name] = true
+		}
 	}
 	return m
 }
@@ -574,11 +709,24 @@ func namesFromInstructions(items []manifest.InstructionRef) map[string]bool {
 func namesFromAgents(items []manifest.AgentRef) map[string]bool {
 	m := make(map[string]bool, len(items))
 	for _, it := range items {
-		m[it.Name] = true
+		if !it.Disabled() {
+			m[it.Name] = true
+		}
 	}
 	return m
 }
 
+// without returns items minus the names in drop.
+func without(items, drop []string) []string {
+	var out []string
+	for _, it := range items {
+		if !contains(drop, it) {
+			out = append(out, it)
+		}
+	}
+	return out
+}
+
 func setDiff(a, b map[string]bool) []string {
 	var out []string
 	for name := range a {
@@ -612,13 +760,13 @@ func formatConfigDiff(global, local, merged *manifest.Manifest) string {
 	var b strings.Builder
 
 	b.WriteString("Global-only:\n")
-	if items := setDiff(globalSkills, localSkills); len(items) > 0 {
+	if items := without(setDiff(globalSkills, localSkills), d.Suppressed.Skills); len(items) > 0 {
 		b.WriteString("  skills: " + strings.Join(items, ", ") + "\n")
 	}
-	if items := setDiff(globalInst, localInst); len(items) > 0 {
+	if items := without(setDiff(globalInst, localInst), d.Suppressed.Instructions); len(items) > 0 {
 		b.WriteString("  instructions: " + strings.Join(items, ", ") + "\n")
 	}
-	if items := setDiff(globalAgents, localAgents); len(items) > 0 {
+	if items := without(setDiff(globalAgents, localAgents), d.Suppressed.Agents); len(items) > 0 {
 		b.WriteString("  agents: " + strings.Join(items, ", ") + "\n")
 	}
 
@@ -647,6 +795,20 @@ func formatConfigDiff(global, local, merged *manifest.Manifest) string {
 		b.WriteString("  registries: " + strings.Join(d.Registries, ", ") + "\n")
 	}
 
+	b.WriteString("\nSuppressed (enabled: false):\n")
+	if len(d.Suppressed.Skills) > 0 {
+		b.WriteString("  skills: " + strings.Join(d.Suppressed.Skills, ", ") + "\n")
+	}
+	if len(d.Suppressed.Instructions) > 0 {
+		b.WriteString("  instructions: " + strings.Join(d.Suppressed.Instructions, ", ") + "\n")
+	}
+	if len(d.Suppressed.Agents) > 0 {
+		b.WriteString("  agents: " + strings.Join(d.Suppressed.Agents, ", ") + "\n")
+	}
+	if len(d.Suppressed.Registries) > 0 {
+		b.WriteString("  registries: " + strings.Join(d.Suppressed.Registries, ", ") + "\n")
+	}
+
 	b.WriteString("\nEffective config summary:\n")
 	b.WriteString(fmt.Sprintf("  registries: %d\n", len(merged.Registries)))
 	b.WriteString(fmt.Sprintf("  skills: %d\n", len(merged.Skills)))
@@ -675,11 +837,12 @@ func formatConfigDiffJSON(global, local, merged *manifest.Manifest) (string, err
 	globalAgents := namesFromAgents(global.Agents)
 	localAgents := namesFromAgents(local.Agents)
 
+	d := manifest.ComputeOverrideDiagnostics(global, local)
 	payload := map[string]any{
 		"global_only": map[string]any{
-			"skills":       setDiff(globalSkills, localSkills),
-			"instructions": setDiff(globalInst, localInst),
-			"agents":       setDiff(globalAgents, localAgents),
+			"skills":       without(setDiff(globalSkills, localSkills), d.Suppressed.Skills),
+			"instructions": without(setDiff(globalInst, localInst), d.Suppressed.Instructions),
+			"agents":       without(setDiff(globalAgents, localAgents), d.Suppressed.Agents),
 		},
 		"local_only": map[string]any{
 			"skills":       setDiff(localSkills, globalSkills),
@@ -687,9 +850,15 @@ func formatConfigDiffJSON(global, local, merged *manifest.Manifest) (string, err
 			"agents":       setDiff(localAgents, globalAgents),
 		},
 		"overrides": map[string]any{
-			"all":   manifest.ComputeOverrideDiagnostics(global, local),
+			"all":   d,
 			"risky": manifest.ComputeRiskyOverrideDiagnostics(global, local),
 		},
+		"suppressed": map[string]any{
+			"registries":   d.Suppressed.Registries,
+			"skills":       d.Suppressed.Skills,
+			"instructions": d.Suppressed.Instructions,
+			"agents":       d.Suppressed.Agents,
+		},
 		"effective_summary": map[string]any{
 			"registries":   len(merged.Registries),
 			"skills":       len(merged.Skills),
@@ -727,11 +896,30 @@ func buildConfigDiffOutput(project, globalPath string, asJSON bool) (string, err
 	return formatConfigDiff(global, local, merged), nil
 }
 
+// maskedValue replaces environment values in config show --mask output.
+const maskedValue = "****"
+
+// maskedLookupEnv wraps lookup so that set, non-empty variables expand to
+// maskedValue. Defaults written in the manifest are shown as-is.
+func maskedLookupEnv(lookup func(string) (string, bool)) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		v, ok := lookup(name)
+		if ok && v != "" {
+			return maskedValue, true
+		}
+		return v, ok
+	}
+}
+
 // --- Cobra commands ---
 
 var configShowSources bool
 var configShowRelativePaths bool
+var configShowProfiles []string
+var configShowMask bool
 var configDiffJSON bool
+var configMigrateGlobal bool
+var configMigrateDryRun bool
 var configColor string
 
 var configCmd = &cobra.Command{
@@ -744,7 +932,9 @@ Subcommands:
   show       Print the effective merged config as YAML
   paths      Show resolved config file locations
   diff       Show differences between global/local/effective config
-  validate   Check config for problems (offline)`,
+  validate   Check config for problems (offline)
+  schema     Print the JSON Schema for vibes.yaml
+  migrate    Upgrade manifests to the current format version`,
 	Run: func(cmd *cobra.Command, args []string) {
 		_ = cmd.Help()
 	},
@@ -757,11 +947,21 @@ var configShowCmd = &cobra.Command{
 effective configuration as YAML.
 
 Use --sources to annotate each value with [global], [local], or
-[local, overrides global] to show where each value comes from.`,
+[local, overrides global] to show where each value comes from. Values
+inherited through extends, the system config or a workspace root, or set in
+vibes.local.yaml, are annotated with the file they came from.
+
+Use --profile (or PV_PROFILE) to show the result of applying profiles.
+
+${VAR} references are shown expanded; use --mask to hide values taken from
+the environment.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		project := ProjectDir()
 		globalPath := defaultGlobalManifestPath()
 		colorEnabled := shouldUseColor(configColor)
+		if configShowMask {
+			manifest.LookupEnv = maskedLookupEnv(manifest.LookupEnv)
+		}
 
 		if configShowSources {
 			// Load global and local separately for annotation
@@ -774,16 +974,26 @@ Use --sources to annotate each value with [global], [local], or
 			if p, _, err := manifest.LoadManifestFromProject(project); err == nil {
 				local = p
 			}
-			if global == nil && local == nil {
+			_, personalErr := os.Stat(manifest.PersonalManifestPath(project))
+			_, systemErr := os.Stat(manifest.SystemManifestPath())
+			if global == nil && local == nil && personalErr != nil && systemErr != nil {
 				fmt.Fprintf(os.Stderr, "No config found (checked %s and %s)\n", globalPath, project)
 				os.Exit(1)
 			}
 
-			merged, err := manifest.LoadMergedManifest(project, globalPath)
+			layers, err := manifest.LoadManifestLayers(project, globalPath)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
 				os.Exit(1)
 			}
+			base := manifest.MergeLayers(layers)
+			profiles := selectedProfiles(configShowProfiles)
+			merged, err := base.WithProfiles(profiles)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			layers = append(layers, base.ProfileLayers(profiles)...)
 			if configShowRelativePaths {
 				if note := relativePathsNoEffectNote(merged); note != "" {
 					fmt.Println(note)
@@ -793,12 +1003,17 @@ Use --sources to annotate each value with [global], [local], or
 				RelativePaths: configShowRelativePaths,
 				ProjectDir:    project,
 				GlobalPath:    globalPath,
+				Sources:       manifest.ValueSources(layers),
 			})
 			fmt.Print(colorizeSourceAnnotations(out, colorEnabled))
 		} else {
 			merged, err := manifest.LoadMergedManifest(project, globalPath)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "No config found (checked %s and %s)\n", globalPath, project)
+				fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+				os.Exit(1)
+			}
+			if merged, err = merged.WithProfiles(selectedProfiles(configShowProfiles)); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 				os.Exit(1)
 			}
 			fmt.Print(renderMergedYAML(merged))
@@ -837,7 +1052,9 @@ var configValidateCmd = &cobra.Command{
 	Use:   "validate",
 	Short: "Check configuration for problems",
 	Long: `Loads the merged configuration and runs offline checks:
-- Config files exist and parse correctly
+- Config files exist and match the vibes.yaml schema (see 'config schema'),
+  with problems reported as file:line:column
+- Every ${VAR} reference without a default names a set variable
 - All targets are valid
 - All skills are resolvable (embedded or local path)
 
@@ -848,6 +1065,10 @@ Exits with code 1 if any problems are found.`,
 		colorEnabled := shouldUseColor(configColor)
 
 		// Report config file status
+		systemPath := manifest.SystemManifestPath()
+		if _, err := os.Stat(systemPath); err == nil {
+			fmt.Fprintf(os.Stdout, "Loading system config:  %s  %s\n", systemPath, colorizeStatus("ok", statusOK, colorEnabled))
+		}
 		globalStatus := "ok"
 		if _, err := os.Stat(globalPath); err != nil {
 			globalStatus = "not found"
@@ -875,12 +1096,50 @@ Exits with code 1 if any problems are found.`,
 		if localStatus != "ok" {
 			localStatusLabel = colorizeStatus(localStatus, statusWarn, colorEnabled)
 		}
-		fmt.Fprintf(os.Stdout, "Loading local config:   %s  %s\n\n", localPath, localStatusLabel)
+		fmt.Fprintf(os.Stdout, "Loading local config:   %s  %s\n", localPath, localStatusLabel)
+		personalPath := manifest.PersonalManifestPath(project)
+		if _, err := os.Stat(personalPath); err == nil {
+			fmt.Fprintf(os.Stdout, "Loading personal config: %s  %s\n", personalPath, colorizeStatus("ok", statusOK, colorEnabled))
+		}
+		fmt.Fprintln(os.Stdout)
+
+		// Check each manifest file against the schema and for unset
+		// variables before decoding it, so mistakes are reported with their
+		// position.
+		files := []string{systemPath, globalPath}
+		if rootDir, ok := manifest.FindWorkspaceRoot(project); ok {
+			if _, rootPath, err := manifest.LoadManifestFromProject(rootDir); err == nil {
+				files = append(files, rootPath)
+			}
+		}
+		files = append(files, localPath, personalPath)
+		var fileErrs []configProblem
+		for _, group := range []struct {
+			title    string
+			problems []configProblem
+		}{
+			{"Schema", schemaProblems(files...)},
+			{"Variables", variableProblems(files...)},
+		} {
+			if len(group.problems) == 0 {
+				continue
+			}
+			fmt.Fprintf(os.Stdout, "%s (%d):\n", group.title, len(group.problems))
+			for _, p := range group.problems {
+				fmt.Fprintf(os.Stdout, "  %s  %s  %s\n", colorizeStatus("FAIL", statusFail, colorEnabled), p.field, p.message)
+			}
+			fmt.Println()
+			fileErrs = append(fileErrs, group.problems...)
+		}
 
 		// Load merged manifest
 		merged, err := manifest.LoadMergedManifest(project, globalPath)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			if len(fileErrs) > 0 {
+				fmt.Fprintf(os.Stdout, "%d problem(s) found.\n", len(fileErrs))
+			} else {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			}
 			os.Exit(1)
 		}
 
@@ -894,7 +1153,7 @@ Exits with code 1 if any problems are found.`,
 
 		// Get available skill names from embedded + configured registries
 		sources := []registry.SkillSource{registry.NewEmbeddedRegistry()}
-		sources = append(sources, gitRegistriesFromManifest(merged)...)
+		sources = append(sources, registriesFromManifest(merged)...)
 		availableSkills, sourceWarnings := collectAvailableSkillsFromSources(sources)
 		var skillNames []string
 		for name := range availableSkills {
@@ -910,12 +1169,16 @@ Exits with code 1 if any problems are found.`,
 		// Run validation -- pass whether local config was found
 		hasLocal := localStatus == "ok"
 		result := validateConfigWithContext(merged, skillNames, hasLocal, globalM, localM, unresolved...)
+		if layers, err := manifest.LoadManifestLayers(project, globalPath); err == nil {
+			result.problems = append(result.problems, lockProblems(layers)...)
+		}
+		result.problems = append(fileErrs, result.problems...)
 		result.warnings = append(sourceWarnings, result.warnings...)
 
 		// Print registries
 		fmt.Fprintf(os.Stdout, "Registries (%d):\n", len(merged.Registries))
 		for _, r := range merged.Registries {
-			fmt.Fprintf(os.Stdout, "  %s  %s  %s\n", colorizeStatus("ok", statusOK, colorEnabled), r.Name, r.URL)
+			fmt.Fprintf(os.Stdout, "  %s  %s  %s\n", colorizeStatus("ok", statusOK, colorEnabled), r.Name, registry.RedactURL(r.URL))
 		}
 		fmt.Println()
 
@@ -970,14 +1233,87 @@ Exits with code 1 if any problems are found.`,
 	},
 }
 
+// formatMigration describes the outcome of migrating the manifest at path.
+func formatMigration(path string, res *manifest.MigrationResult, dryRun bool) string {
+	if len(res.Applied) == 0 {
+		return fmt.Sprintf("%s is up to date (version %d)\n", path, res.From)
+	}
+	var b strings.Builder
+	verb := "migrated"
+	if dryRun {
+		verb = "would migrate"
+	}
+	fmt.Fprintf(&b, "%s %s from version %d to %d:\n", verb, path, res.From, res.To)
+	for _, m := range res.Applied {
+		fmt.Fprintf(&b, "  %d -> %d: %s\n", m.From, m.From+1, m.Description)
+	}
+	return b.String()
+}
+
+var configMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Upgrade manifests to the current format version",
+	Long: `Rewrites the project manifest (or the global one with --global) in
+place, upgrading it to the current format version. Comments and key order
+are kept. Use --dry-run to print the upgraded manifest without writing it.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		path := defaultGlobalManifestPath()
+		if !configMigrateGlobal {
+			p, ok := manifest.FindProjectManifest(ProjectDir())
+			if !ok {
+				fmt.Fprintf(os.Stderr, "error: no manifest found in %s (looked for %v)\n", ProjectDir(), manifest.ManifestFilenames)
+				os.Exit(1)
+			}
+			path = p
+		}
+
+		res, err := manifest.MigrateFile(path, configMigrateDryRun)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Print(formatMigration(path, res, configMigrateDryRun))
+		if configMigrateDryRun && len(res.Applied) > 0 {
+			fmt.Println()
+			os.Stdout.Write(res.Data)
+		}
+	},
+}
+
+var configSchemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Print the JSON Schema for vibes.yaml",
+	Long: `Prints a JSON Schema (draft-07) describing vibes.yaml, for editor
+completion and validation.
+
+Example:
+  positive-vibes config schema > vibes.schema.json
+  # then add to the top of vibes.yaml:
+  # yaml-language-server: $schema=./vibes.schema.json`,
+	Run: func(cmd *cobra.Command, args []string) {
+		data, err := manifest.SchemaJSON()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		os.Stdout.Write(data)
+	},
+}
+
 func init() {
 	configShowCmd.Flags().BoolVar(&configShowSources, "sources", false, "annotate values with their source (global/local)")
 	configShowCmd.Flags().BoolVar(&configShowRelativePaths, "relative-paths", false, "show source-annotated paths relative to their config root")
+	configShowCmd.Flags().BoolVar(&configShowMask, "mask", false, "mask values expanded from environment variables")
+	configShowCmd.Flags().StringSliceVar(&configShowProfiles, "profile", nil, "apply these profiles, in order (default from $PV_PROFILE)")
 	configDiffCmd.Flags().BoolVar(&configDiffJSON, "json", false, "emit config diff as JSON")
+	configMigrateCmd.Flags().BoolVar(&configMigrateGlobal, "global", false, "migrate the global manifest instead of the project one")
+	configMigrateCmd.Flags().BoolVar(&configMigrateDryRun, "dry-run", false, "print the migrated manifest without writing it")
 	configCmd.PersistentFlags().StringVar(&configColor, "color", "auto", "color output: auto, always, never")
 	configCmd.AddCommand(configShowCmd)
 	configCmd.AddCommand(configPathsCmd)
 	configCmd.AddCommand(configDiffCmd)
 	configCmd.AddCommand(configValidateCmd)
+	configCmd.AddCommand(configSchemaCmd)
+	configCmd.AddCommand(configMigrateCmd)
 	rootCmd.AddCommand(configCmd)
 }
@@ -52,6 +52,43 @@ func TestFormatPaths_BothExist(t *testing.T) {
 	assert.Contains(t, out, filepath.Join(dir, "cache"))
 }
 
+func TestFormatPaths_PersonalConfig(t *testing.T) {
+	dir := t.TempDir()
+	globalPath := filepath.Join(dir, "nope", "vibes.yaml")
+
+	out := formatPaths(globalPath, dir, filepath.Join(dir, "cache"))
+	assert.Contains(t, out, "Personal config: "+filepath.Join(dir, "vibes.local.yaml")+"  [not found]")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "vibes.local.yaml"), []byte("skills: []"), 0o644))
+	out = formatPaths(globalPath, dir, filepath.Join(dir, "cache"))
+	assert.Contains(t, out, "Personal config: "+filepath.Join(dir, "vibes.local.yaml")+"  [found]")
+}
+
+func TestFormatPaths_SystemConfig(t *testing.T) {
+	dir := t.TempDir()
+	sysPath := filepath.Join(dir, "system.yaml")
+	t.Setenv(manifest.SystemConfigEnvVar, sysPath)
+	require.NoError(t, os.WriteFile(sysPath, []byte("skills: []"), 0o644))
+
+	out := formatPaths(filepath.Join(dir, "nope", "vibes.yaml"), dir, filepath.Join(dir, "cache"))
+	assert.Contains(t, out, "System config:   "+sysPath+"  [found]")
+}
+
+func TestLockProblems(t *testing.T) {
+	layers := []manifest.Layer{
+		{Source: "/etc/positive-vibes/vibes.yaml", Kind: manifest.LayerSystem, Manifest: &manifest.Manifest{
+			Skills: []manifest.SkillRef{{Name: "tdd", Locked: true}},
+		}},
+		{Source: "/repo/vibes.yaml", Kind: manifest.LayerLocal, Manifest: &manifest.Manifest{
+			Skills: []manifest.SkillRef{{Name: "tdd", Path: "./skills/tdd"}},
+		}},
+	}
+	problems := lockProblems(layers)
+	require.Len(t, problems, 1)
+	assert.Equal(t, "tdd", problems[0].field)
+	assert.Equal(t, "override in /repo/vibes.yaml is ignored: skill is locked by /etc/positive-vibes/vibes.yaml", problems[0].message)
+}
+
 func TestFormatPaths_NeitherExists(t *testing.T) {
 	dir := t.TempDir()
 	globalPath := filepath.Join(dir, "nope", "vibes.yml")
@@ -241,6 +278,65 @@ func TestAnnotateManifestWithOptions_RelativePathsForGlobal(t *testing.T) {
 	assert.Contains(t, out, "path: ./skills/global-skill")
 }
 
+func TestAnnotateManifestWithOptions_AttributesExtendedValues(t *testing.T) {
+	projectDir := t.TempDir()
+	basePath := filepath.Join(projectDir, "shared", "base.yaml")
+	require.NoError(t, os.MkdirAll(filepath.Dir(basePath), 0o755))
+	require.NoError(t, os.WriteFile(basePath, []byte("skills:\n  - name: base-skill\ntargets:\n  - cursor\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "vibes.yaml"), []byte("extends:\n  - ./shared/base.yaml\nskills:\n  - name: project-skill\n"), 0o644))
+
+	layers, err := manifest.LoadManifestLayers(projectDir, filepath.Join(t.TempDir(), "vibes.yaml"))
+	require.NoError(t, err)
+	local, _, err := manifest.LoadManifestFromProject(projectDir)
+	require.NoError(t, err)
+
+	out := annotateManifestWithOptions(nil, local, manifest.MergeLayers(layers), annotateRenderOptions{
+		ProjectDir: projectDir,
+		Sources:    manifest.ValueSources(layers),
+	})
+
+	assert.Contains(t, out, "- name: base-skill  # [extends ./shared/base.yaml]")
+	assert.Contains(t, out, "- name: project-skill  # [local]")
+	assert.Contains(t, out, "targets: # [extends ./shared/base.yaml]")
+}
+
+func TestAnnotateManifestWithOptions_AttributesPersonalValues(t *testing.T) {
+	projectDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "vibes.yaml"), []byte("skills:\n  - name: project-skill\ntargets:\n  - cursor\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "vibes.local.yaml"), []byte("skills:\n  - name: my-skill\n"), 0o644))
+
+	layers, err := manifest.LoadManifestLayers(projectDir, filepath.Join(t.TempDir(), "vibes.yaml"))
+	require.NoError(t, err)
+	local, _, err := manifest.LoadManifestFromProject(projectDir)
+	require.NoError(t, err)
+
+	out := annotateManifestWithOptions(nil, local, manifest.MergeLayers(layers), annotateRenderOptions{
+		ProjectDir: projectDir,
+		Sources:    manifest.ValueSources(layers),
+	})
+
+	assert.Contains(t, out, "- name: my-skill  # [personal ./vibes.local.yaml]")
+	assert.Contains(t, out, "- name: project-skill  # [local]")
+}
+
+func TestAnnotateManifestWithOptions_AttributesProfileValues(t *testing.T) {
+	base := &manifest.Manifest{
+		Skills:  []manifest.SkillRef{{Name: "base-skill"}},
+		Targets: []string{"opencode"},
+		Profiles: map[string]manifest.Profile{
+			"frontend": {Agents: []manifest.AgentRef{{Name: "ui", Path: "/agents/ui.md"}}},
+		},
+	}
+	merged, err := base.WithProfiles([]string{"frontend"})
+	require.NoError(t, err)
+
+	out := annotateManifestWithOptions(nil, base, merged, annotateRenderOptions{
+		Sources: manifest.ValueSources(base.ProfileLayers([]string{"frontend"})),
+	})
+	assert.Contains(t, out, "- name: ui  # [profile frontend]")
+	assert.Contains(t, out, "- name: base-skill  # [local]")
+}
+
 func TestAnnotateManifest_InstructionSources(t *testing.T) {
 	global := &manifest.Manifest{
 		Skills:  []manifest.SkillRef{{Name: "s"}},
@@ -707,6 +803,33 @@ func TestFormatConfigDiff_IncludesGlobalLocalAndOverrides(t *testing.T) {
 	assert.Contains(t, out, "Effective config summary:")
 }
 
+func TestFormatConfigDiff_ReportsSuppressedEntries(t *testing.T) {
+	off := false
+	global := &manifest.Manifest{
+		Skills:  []manifest.SkillRef{{Name: "tdd"}, {Name: "kept"}},
+		Targets: []string{"opencode"},
+	}
+	local := &manifest.Manifest{
+		Skills: []manifest.SkillRef{{Name: "tdd", Enabled: &off}},
+	}
+	merged := manifest.MergeManifests(global, local)
+
+	out := formatConfigDiff(global, local, merged)
+	assert.Contains(t, out, "Global-only:\n  skills: kept\n")
+	assert.Contains(t, out, "Local-only:\n\nOverrides:\n\nSuppressed (enabled: false):\n  skills: tdd\n")
+	assert.Contains(t, out, "  skills: 1\n")
+
+	jsonOut, err := formatConfigDiffJSON(global, local, merged)
+	require.NoError(t, err)
+	var decoded struct {
+		Suppressed struct {
+			Skills []string `json:"skills"`
+		} `json:"suppressed"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(jsonOut), &decoded))
+	assert.Equal(t, []string{"tdd"}, decoded.Suppressed.Skills)
+}
+
 func TestFormatConfigDiffJSON_ParsesAndContainsKeys(t *testing.T) {
 	global := &manifest.Manifest{Skills: []manifest.SkillRef{{Name: "global-only"}}}
 	local := &manifest.Manifest{Skills: []manifest.SkillRef{{Name: "local-only"}}}
@@ -809,3 +932,116 @@ func TestValidateConfigWithContext_LocalRegistryDependencyDefinedOnlyGlobally_Is
 	require.NotEmpty(t, result.problems)
 	assert.Contains(t, result.problems[0].message, "defined only in global config")
 }
+
+func TestSchemaProblems_PositionsByFile(t *testing.T) {
+	dir := t.TempDir()
+	good := filepath.Join(dir, "global.yaml")
+	bad := filepath.Join(dir, "vibes.yaml")
+	require.NoError(t, os.WriteFile(good, []byte("skills:\n  - name: tdd\ntargets: [cursor]\n"), 0o644))
+	require.NoError(t, os.WriteFile(bad, []byte("skills:\n  - name: tdd\n    regsitry: x\ntargets: [cursor]\n"), 0o644))
+
+	problems := schemaProblems(good, bad, filepath.Join(dir, "missing.yaml"))
+	require.Len(t, problems, 1)
+	assert.Equal(t, bad+":3:5", problems[0].field)
+	assert.Equal(t, `skills[0].regsitry: unknown property "regsitry"`, problems[0].message)
+}
+
+func TestSchemaProblems_DirRegistryNeedsNoRef(t *testing.T) {
+	dir := t.TempDir()
+	p := filepath.Join(dir, "vibes.yaml")
+	require.NoError(t, os.WriteFile(p, []byte("registries:\n  - name: shared\n    type: dir\n    url: ../shared\nskills:\n  - name: tdd\n    registry: shared\n    path: tdd\ntargets: [cursor]\n"), 0o644))
+
+	assert.Empty(t, schemaProblems(p))
+	m, err := manifest.LoadManifest(p)
+	require.NoError(t, err)
+	assert.NoError(t, m.Validate())
+}
+
+func TestConfigSchemaCommand_Registered(t *testing.T) {
+	cmd, _, err := configCmd.Find([]string{"schema"})
+	require.NoError(t, err)
+	assert.Equal(t, "schema", cmd.Name())
+}
+
+func TestFormatMigration(t *testing.T) {
+	res := &manifest.MigrationResult{From: 1, To: 2, Applied: manifest.Migrations[:1]}
+	assert.Equal(t, "migrated vibes.yaml from version 1 to 2:\n  1 -> 2: "+manifest.Migrations[0].Description+"\n",
+		formatMigration("vibes.yaml", res, false))
+	assert.Contains(t, formatMigration("vibes.yaml", res, true), "would migrate vibes.yaml")
+	assert.Equal(t, "vibes.yaml is up to date (version 2)\n",
+		formatMigration("vibes.yaml", &manifest.MigrationResult{From: 2, To: 2}, false))
+}
+
+func TestVariableProblems_PositionsByFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vibes.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("registries:\n  - name: team\n    url: ${PV_TEST_UNSET_MIRROR}\n    ref: ${PV_TEST_UNSET_REF:-main}\n"), 0o644))
+
+	problems := variableProblems(path)
+	require.Len(t, problems, 1)
+	assert.Equal(t, path+":3:10", problems[0].field)
+	assert.Contains(t, problems[0].message, "registries[0].url: environment variable PV_TEST_UNSET_MIRROR is not set")
+}
+
+func TestMaskedLookupEnv(t *testing.T) {
+	lookup := maskedLookupEnv(func(name string) (string, bool) {
+		switch name {
+		case "SET":
+			return "secret-host", true
+		case "EMPTY":
+			return "", true
+		}
+		return "", false
+	})
+
+	v, ok := lookup("SET")
+	assert.True(t, ok)
+	assert.Equal(t, maskedValue, v)
+	v, ok = lookup("EMPTY")
+	assert.True(t, ok)
+	assert.Empty(t, v)
+	_, ok = lookup("UNSET")
+	assert.False(t, ok)
+}
+
+func TestValidateConfigWithContext_PolicyViolationsAreProblems(t *testing.T) {
+	m := &manifest.Manifest{
+		Policy:     &manifest.Policy{DeniedSkills: []string{"yolo"}, RequirePinnedRefs: true},
+		Registries: []manifest.RegistryRef{{Name: "team", URL: "https://example.com/team", Ref: "latest"}},
+		Skills:     []manifest.SkillRef{{Name: "yolo", Path: "./skills/yolo"}},
+		Targets:    []string{"opencode"},
+	}
+
+	result := validateConfigWithContext(m, []string{"yolo"}, true, nil, nil)
+	assert.False(t, result.ok())
+	fields := make([]string, len(result.problems))
+	for i, p := range result.problems {
+		fields[i] = p.field
+	}
+	assert.Contains(t, fields, "registries/team")
+	assert.Contains(t, fields, "skills/yolo")
+}
+
+func TestCheckInstallPolicy(t *testing.T) {
+	merged := &manifest.Manifest{
+		Policy: &manifest.Policy{
+			AllowedRegistries: []string{"https://github.com/myorg/*"},
+			DeniedSkills:      []string{"yolo"},
+		},
+		Registries: []manifest.RegistryRef{
+			{Name: "approved", URL: "https://github.com/myorg/skills", Ref: "v1"},
+			{Name: "random", URL: "https://example.com/skills", Ref: "v1"},
+		},
+	}
+
+	require.NoError(t, checkInstallPolicy(merged, manifest.SkillRef{Name: "tdd", Registry: "approved"}))
+	require.NoError(t, checkInstallPolicy(&manifest.Manifest{}, manifest.SkillRef{Name: "yolo"}))
+
+	err := checkInstallPolicy(merged, manifest.SkillRef{Name: "yolo"})
+	var pe *manifest.PolicyError
+	require.ErrorAs(t, err, &pe)
+	assert.Equal(t, "skills/yolo", pe.Violations[0].Field)
+
+	err = checkInstallPolicy(merged, manifest.AgentRef{Name: "reviewer", Registry: "random", Path: "agents/reviewer.md"})
+	require.ErrorAs(t, err, &pe)
+	assert.Contains(t, err.Error(), "not an approved registry")
+}
@@ -22,6 +22,8 @@ func renderBootstrapManifest(m *manifest.Manifest) string {
 	b.WriteString("# Run 'positive-vibes apply' to sync skills and instructions to all targets.\n")
 	b.WriteString("# Global (~/.config/positive-vibes/vibes.yaml) and project configs are merged automatically; project values take priority.\n")
 	b.WriteString("\n")
+	b.WriteString(fmt.Sprintf("version: %d\n", manifest.CurrentVersion))
+	b.WriteString("\n")
 
 	// Registries
 	b.WriteString("# Remote skill registries (git repos). Project entries override global by name.\n")
@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
@@ -149,6 +150,11 @@ func TestRenderBootstrapManifest_Header_IsGeneric(t *testing.T) {
 	assert.NotContains(t, content, "project configuration")
 }
 
+func TestRenderBootstrapManifest_WritesCurrentVersion(t *testing.T) {
+	content := renderBootstrapManifest(&manifest.Manifest{})
+	assert.Contains(t, content, fmt.Sprintf("\nversion: %d\n", manifest.CurrentVersion))
+}
+
 func TestRenderBootstrapManifest_EmptySkills_ShowsCommentedExamples(t *testing.T) {
 	content := renderBootstrapManifest(&manifest.Manifest{})
 	// When no skills are provided, should show commented-out examples
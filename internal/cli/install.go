@@ -12,6 +12,8 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var installLocal bool
+
 var installCmd = &cobra.Command{
 	Use:   "install <resource-type> [name...]",
 	Short: "Install resources into the manifest",
@@ -19,6 +21,9 @@ var installCmd = &cobra.Command{
 
 If no names are given, an interactive picker is shown.
 
+Use --local to add resources to vibes.local.yaml, a personal layer merged on
+top of vibes.yaml that is kept out of git.
+
 Resource types: skills, agents, instructions
 
 Examples:
@@ -26,7 +31,8 @@ Examples:
   positive-vibes install skills code-review          # install by name
   positive-vibes install skills code-review tdd      # install multiple
   positive-vibes install agents reviewer             # add agent by name
-  positive-vibes install instructions standards      # add instruction by name`,
+  positive-vibes install instructions standards      # add instruction by name
+  positive-vibes install agents my-helper --local    # personal, not committed`,
 	Args:              cobra.MinimumNArgs(1),
 	ValidArgsFunction: makeValidArgsFunction("available"),
 	Run: func(cmd *cobra.Command, args []string) {
@@ -54,20 +60,17 @@ func installSkillsRun(names []string) {
 	globalPath := defaultGlobalManifestPath()
 
 	// Find existing manifest
-	_, manifestPath, findErr := manifest.LoadManifestFromProject(project)
-	if findErr != nil {
-		manifestPath = filepath.Join(project, "vibes.yaml")
-	}
+	_, manifestPath, _ := manifestToEdit(project, installLocal)
 
 	// Build registries
 	regs := []registry.SkillSource{registry.NewEmbeddedRegistry()}
-	if merged, err := manifest.LoadMergedManifest(project, globalPath); err == nil {
-		regs = append(regs, gitRegistriesFromManifest(merged)...)
+	merged, err := manifest.LoadMergedManifest(project, globalPath)
+	if err == nil {
+		regs = append(regs, registriesFromManifest(merged)...)
 	}
 
 	// If no names provided, show interactive picker
 	if len(names) == 0 {
-		merged, _ := manifest.LoadMergedManifest(project, globalPath)
 		available := collectAvailableSkills(merged)
 
 		// Filter out already-installed skills
@@ -112,6 +115,10 @@ func installSkillsRun(names []string) {
 	inst := engine.NewInstaller(regs)
 	for _, name := range names {
 		fmt.Printf("Installing '%s'...\n", name)
+		if err := checkInstallPolicy(merged, manifest.SkillRef{Name: name}); err != nil {
+			fmt.Fprintf(os.Stderr, "  error: %v\n", err)
+			continue
+		}
 		if err := inst.Install(name, manifestPath); err != nil {
 			fmt.Fprintf(os.Stderr, "  error: %v\n", err)
 			continue
@@ -131,6 +138,7 @@ func installSkillsRun(names []string) {
 		}
 		fmt.Printf("  Added '%s' to %s\n", name, filepath.Base(manifestPath))
 	}
+	excludePersonalManifest(project)
 
 	fmt.Println("\nRun 'positive-vibes apply' to install everywhere!")
 }
@@ -139,9 +147,8 @@ func installAgentsRun(names []string) {
 	project := ProjectDir()
 	globalPath := defaultGlobalManifestPath()
 
-	m, manifestPath, findErr := manifest.LoadManifestFromProject(project)
+	m, manifestPath, findErr := manifestToEdit(project, installLocal)
 	if findErr != nil {
-		manifestPath = filepath.Join(project, "vibes.yaml")
 		m = &manifest.Manifest{}
 	}
 
@@ -270,20 +277,27 @@ func installAgentsRun(names []string) {
 			agent.Registry = regName
 			agent.Path = value
 		}
+		if err := checkInstallPolicy(merged, agent); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return
+		}
 
-		m.Agents = append(m.Agents, agent)
-		if err := manifest.SaveManifest(m, manifestPath); err != nil {
+		err := manifest.EditManifest(manifestPath, func(d *manifest.Document) error {
+			return d.Add("agents", agent)
+		})
+		if err != nil {
 			fmt.Fprintf(os.Stderr, "error saving manifest: %v\n", err)
 			return
 		}
 		fmt.Printf("Added agent '%s' to %s\n", name, filepath.Base(manifestPath))
+		excludePersonalManifest(project)
 		fmt.Println("\nRun 'positive-vibes apply' to install everywhere!")
 		return
 	}
 
 	// Non-interactive: add named agents from registry when available,
 	// otherwise use local path convention.
-	added := 0
+	var added []manifest.AgentRef
 	for _, name := range names {
 		if existing[name] {
 			fmt.Fprintf(os.Stderr, "warning: agent '%s' already exists in manifest, skipping\n", name)
@@ -297,9 +311,12 @@ func installAgentsRun(names []string) {
 		} else {
 			agent.Path = fmt.Sprintf("./agents/%s.md", name)
 		}
-		m.Agents = append(m.Agents, agent)
+		if err := checkInstallPolicy(merged, agent); err != nil {
+			fmt.Fprintf(os.Stderr, "error: agent '%s': %v\n", name, err)
+			continue
+		}
+		added = append(added, agent)
 		existing[name] = true
-		added++
 		if agent.Registry != "" {
 			fmt.Printf("Added agent '%s' (registry: %s, path: %s)\n", name, agent.Registry, agent.Path)
 		} else {
@@ -307,12 +324,21 @@ func installAgentsRun(names []string) {
 		}
 	}
 
-	if added > 0 {
-		if err := manifest.SaveManifest(m, manifestPath); err != nil {
+	if len(added) > 0 {
+		err := manifest.EditManifest(manifestPath, func(d *manifest.Document) error {
+			for _, ref := range added {
+				if err := d.Add("agents", ref); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
 			fmt.Fprintf(os.Stderr, "error saving manifest: %v\n", err)
 			return
 		}
-		fmt.Printf("\nSaved %d agent(s) to %s\n", added, filepath.Base(manifestPath))
+		fmt.Printf("\nSaved %d agent(s) to %s\n", len(added), filepath.Base(manifestPath))
+		excludePersonalManifest(project)
 		fmt.Println("Run 'positive-vibes apply' to install everywhere!")
 	}
 }
@@ -321,9 +347,8 @@ func installInstructionsRun(names []string) {
 	project := ProjectDir()
 	globalPath := defaultGlobalManifestPath()
 
-	m, manifestPath, findErr := manifest.LoadManifestFromProject(project)
+	m, manifestPath, findErr := manifestToEdit(project, installLocal)
 	if findErr != nil {
-		manifestPath = filepath.Join(project, "vibes.yaml")
 		m = &manifest.Manifest{}
 	}
 
@@ -440,20 +465,27 @@ func installInstructionsRun(names []string) {
 		} else {
 			inst.Path = value
 		}
+		if err := checkInstallPolicy(merged, inst); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return
+		}
 
-		m.Instructions = append(m.Instructions, inst)
-		if err := manifest.SaveManifest(m, manifestPath); err != nil {
+		err := manifest.EditManifest(manifestPath, func(d *manifest.Document) error {
+			return d.Add("instructions", inst)
+		})
+		if err != nil {
 			fmt.Fprintf(os.Stderr, "error saving manifest: %v\n", err)
 			return
 		}
 		fmt.Printf("Added instruction '%s' to %s\n", name, filepath.Base(manifestPath))
+		excludePersonalManifest(project)
 		fmt.Println("\nRun 'positive-vibes apply' to install everywhere!")
 		return
 	}
 
 	// Non-interactive: add named instructions from registry when available,
 	// otherwise use local path convention.
-	added := 0
+	var added []manifest.InstructionRef
 	for _, name := range names {
 		if existing[name] {
 			fmt.Fprintf(os.Stderr, "warning: instruction '%s' already exists in manifest, skipping\n", name)
@@ -467,9 +499,12 @@ func installInstructionsRun(names []string) {
 		} else {
 			inst.Path = fmt.Sprintf("./instructions/%s.md", name)
 		}
-		m.Instructions = append(m.Instructions, inst)
+		if err := checkInstallPolicy(merged, inst); err != nil {
+			fmt.Fprintf(os.Stderr, "error: instruction '%s': %v\n", name, err)
+			continue
+		}
+		added = append(added, inst)
 		existing[name] = true
-		added++
 		if inst.Registry != "" {
 			fmt.Printf("Added instruction '%s' (registry: %s, path: %s)\n", name, inst.Registry, inst.Path)
 		} else {
@@ -477,16 +512,66 @@ func installInstructionsRun(names []string) {
 		}
 	}
 
-	if added > 0 {
-		if err := manifest.SaveManifest(m, manifestPath); err != nil {
+	if len(added) > 0 {
+		err := manifest.EditManifest(manifestPath, func(d *manifest.Document) error {
+			for _, ref := range added {
+				if err := d.Add("instructions", ref); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
 			fmt.Fprintf(os.Stderr, "error saving manifest: %v\n", err)
 			return
 		}
-		fmt.Printf("\nSaved %d instruction(s) to %s\n", added, filepath.Base(manifestPath))
+		fmt.Printf("\nSaved %d instruction(s) to %s\n", len(added), filepath.Base(manifestPath))
+		excludePersonalManifest(project)
 		fmt.Println("Run 'positive-vibes apply' to install everywhere!")
 	}
 }
 
+// checkInstallPolicy returns a *manifest.PolicyError when the policy in
+// merged forbids adding entry (a SkillRef, InstructionRef or AgentRef),
+// including through the registry it comes from.
+func checkInstallPolicy(merged *manifest.Manifest, entry any) error {
+	if merged == nil || merged.Policy == nil {
+		return nil
+	}
+	p := merged.Policy
+	var violations []manifest.PolicyViolation
+	var reg string
+	switch e := entry.(type) {
+	case manifest.SkillRef:
+		violations, reg = p.CheckSkill(e), e.Registry
+	case manifest.InstructionRef:
+		violations, reg = p.CheckInstruction(e), e.Registry
+	case manifest.AgentRef:
+		reg = e.Registry
+	}
+	for _, r := range merged.Registries {
+		if r.Name == reg {
+			violations = append(violations, p.CheckRegistry(r)...)
+		}
+	}
+	if len(violations) > 0 {
+		return &manifest.PolicyError{Violations: violations}
+	}
+	return nil
+}
+
+// excludePersonalManifest keeps vibes.local.yaml out of git after install
+// --local writes it.
+func excludePersonalManifest(project string) {
+	if !installLocal {
+		return
+	}
+	if err := syncGeneratedIgnores(project, defaultGlobalManifestPath()); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: update git ignore entries: %v\n", err)
+	}
+}
+
 func init() {
+	installCmd.Flags().BoolVar(&installLocal, "local", false, "add to vibes.local.yaml (personal, not committed) instead of vibes.yaml")
 	rootCmd.AddCommand(installCmd)
 }
@@ -0,0 +1,141 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/chaz8081/positive-vibes/internal/manifest"
+	"github.com/chaz8081/positive-vibes/internal/registry"
+	"github.com/spf13/cobra"
+)
+
+var (
+	registryIndexJSON    bool
+	registryHistoryLimit int
+)
+
+var registryCmd = &cobra.Command{
+	Use:   "registry",
+	Short: "Maintain skill registries",
+}
+
+var registryIndexCmd = &cobra.Command{
+	Use:   "index [dir]",
+	Short: "Generate the index file for a registry",
+	Long: `Scans a registry checkout (default: the current directory) and writes
+index.yaml at its root, listing every skill (directory with a SKILL.md),
+instruction (*.instructions.md) and agent (*.agent.md) outside .git with
+its name, description, tags, version and path. When a registry has an index, list
+and shell completion read it instead of walking the registry.
+
+Commit the index and run this again whenever resources change.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		dir := "."
+		if len(args) == 1 {
+			dir = args[0]
+		}
+		ix, err := registry.BuildIndex(dir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		path, err := registry.WriteIndex(dir, ix, registryIndexJSON)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Print(formatIndexSummary(ix, path))
+	},
+}
+
+var registryHistoryCmd = &cobra.Command{
+	Use:   "history <name>",
+	Short: "Show the commits leading to a git registry's checked-out version",
+	Long: `Lists the most recent commits (newest first) of the version a git
+registry in your manifests is checked out at. Registries are cloned with
+only the commit they need, so older history is fetched on demand into the
+shared object store the first time it is asked for.
+
+Examples:
+  positive-vibes registry history team
+  positive-vibes registry history team --limit 0   # the full history`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeGitRegistryNames,
+	Run: func(cmd *cobra.Command, args []string) {
+		merged, _ := manifest.LoadMergedManifest(ProjectDir(), defaultGlobalManifestPath())
+		reg, err := gitRegistryNamed(merged, args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		commits, err := reg.History(registryHistoryLimit)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Print(formatHistory(commits))
+	},
+}
+
+// gitRegistryNamed returns the enabled git registry called name in merged.
+func gitRegistryNamed(merged *manifest.Manifest, name string) (*registry.GitRegistry, error) {
+	if merged != nil {
+		for _, r := range merged.Registries {
+			if r.Name != name || r.Disabled() {
+				continue
+			}
+			if r.SourceType() != manifest.RegistryTypeGit {
+				return nil, fmt.Errorf("registry %q is a %s registry; only git registries have history", name, r.SourceType())
+			}
+			return registryFromRef(r).(*registry.GitRegistry), nil
+		}
+	}
+	return nil, fmt.Errorf("no registry named %q in your manifests", name)
+}
+
+// formatHistory renders one line per commit: short hash, date, author and
+// subject.
+func formatHistory(commits []registry.CommitInfo) string {
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	for _, c := range commits {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", c.Hash[:min(12, len(c.Hash))], c.When.Local().Format("2006-01-02"), c.Author, c.Subject)
+	}
+	w.Flush()
+	return b.String()
+}
+
+func completeGitRegistryNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	merged, _ := manifest.LoadMergedManifest(ProjectDir(), defaultGlobalManifestPath())
+	var names []string
+	if merged != nil {
+		for _, r := range merged.Registries {
+			if !r.Disabled() && r.SourceType() == manifest.RegistryTypeGit {
+				names = append(names, r.Name)
+			}
+		}
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// formatIndexSummary reports how many resources of each kind an index lists.
+func formatIndexSummary(ix *registry.Index, path string) string {
+	counts := make(map[string]int)
+	for _, e := range ix.Resources {
+		counts[e.Kind]++
+	}
+	return fmt.Sprintf("Wrote %s: %d skills, %d instructions, %d agents\n", path, counts["skills"], counts["instructions"], counts["agents"])
+}
+
+func init() {
+	registryIndexCmd.Flags().BoolVar(&registryIndexJSON, "json", false, "write index.json instead of index.yaml")
+	registryHistoryCmd.Flags().IntVarP(&registryHistoryLimit, "limit", "n", 10, "number of commits to show (0 for all)")
+	registryCmd.AddCommand(registryIndexCmd, registryHistoryCmd)
+	rootCmd.AddCommand(registryCmd)
+}
@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"testing"
+	"time"
+
+	"github.com/chaz8081/positive-vibes/internal/manifest"
+	"github.com/chaz8081/positive-vibes/internal/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatIndexSummary(t *testing.T) {
+	ix := &registry.Index{Version: registry.IndexVersion, Resources: []registry.IndexEntry{
+		{Name: "tdd", Kind: "skills", Path: "skills/tdd"},
+		{Name: "review", Kind: "skills", Path: "skills/review"},
+		{Name: "go", Kind: "instructions", Path: "go.instructions.md"},
+	}}
+	assert.Equal(t, "Wrote index.yaml: 2 skills, 1 instructions, 0 agents\n", formatIndexSummary(ix, "index.yaml"))
+}
+
+func TestFormatHistory(t *testing.T) {
+	when := time.Date(2026, 3, 4, 12, 0, 0, 0, time.Local)
+	out := formatHistory([]registry.CommitInfo{
+		{Hash: "0123456789abcdef0123", Subject: "Add tdd skill", Author: "Ada", When: when},
+		{Hash: "fedcba9876543210fedc", Subject: "Initial commit", Author: "Grace Hopper", When: when},
+	})
+	assert.Equal(t, "0123456789ab  2026-03-04  Ada           Add tdd skill\n"+
+		"fedcba987654  2026-03-04  Grace Hopper  Initial commit\n", out)
+}
+
+func TestGitRegistryNamed(t *testing.T) {
+	off := false
+	merged := &manifest.Manifest{Registries: []manifest.RegistryRef{
+		{Name: "team", URL: "https://example.com/team.git", Ref: "v1.0.0"},
+		{Name: "shared", URL: "../shared", Type: manifest.RegistryTypeDir},
+		{Name: "old", URL: "https://example.com/old.git", Ref: "latest", Enabled: &off},
+	}}
+
+	reg, err := gitRegistryNamed(merged, "team")
+	require.NoError(t, err)
+	assert.Equal(t, "v1.0.0", reg.Ref)
+
+	_, err = gitRegistryNamed(merged, "shared")
+	assert.ErrorContains(t, err, "only git registries have history")
+	_, err = gitRegistryNamed(merged, "old")
+	assert.ErrorContains(t, err, `no registry named "old"`)
+	_, err = gitRegistryNamed(nil, "team")
+	assert.Error(t, err)
+}
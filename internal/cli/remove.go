@@ -8,9 +8,13 @@ import (
 	"github.com/charmbracelet/huh"
 	"github.com/chaz8081/positive-vibes/internal/engine"
 	"github.com/chaz8081/positive-vibes/internal/manifest"
+	"github.com/chaz8081/positive-vibes/internal/target"
 	"github.com/spf13/cobra"
 )
 
+var removeKeepFiles bool
+var removeLocal bool
+
 var removeCmd = &cobra.Command{
 	Use:   "remove <resource-type> [name...]",
 	Short: "Remove resources from the manifest",
@@ -18,12 +22,19 @@ var removeCmd = &cobra.Command{
 
 If no names are given, an interactive picker is shown.
 
+Removed resources are also uninstalled from every configured target. Only
+files positive-vibes recorded installing are deleted; hand-written files and
+installed files you have since modified are left in place. Use --keep-files
+to only edit the manifest and leave installed files in place.
+Use --local to remove resources from vibes.local.yaml instead of vibes.yaml.
+
 Resource types: skills, agents, instructions
 
 Examples:
   positive-vibes remove skills                      # interactive picker
   positive-vibes remove skills code-review           # remove by name
-  positive-vibes remove skills code-review tdd       # remove multiple`,
+  positive-vibes remove skills code-review tdd       # remove multiple
+  positive-vibes remove agents reviewer --keep-files # keep installed files`,
 	Args:              cobra.MinimumNArgs(1),
 	ValidArgsFunction: makeValidArgsFunction("installed"),
 	Run: func(cmd *cobra.Command, args []string) {
@@ -49,9 +60,9 @@ Examples:
 func removeSkillsRun(names []string) {
 	project := ProjectDir()
 
-	_, manifestPath, findErr := manifest.LoadManifestFromProject(project)
+	_, manifestPath, findErr := manifestToEdit(project, removeLocal)
 	if findErr != nil {
-		fmt.Fprintf(os.Stderr, "error: no manifest found in %s\n", project)
+		fmt.Fprintf(os.Stderr, "error: %v\n", findErr)
 		return
 	}
 
@@ -103,15 +114,16 @@ func removeSkillsRun(names []string) {
 			continue
 		}
 		fmt.Printf("Removed '%s' from %s\n", name, filepath.Base(manifestPath))
+		uninstallRemoved(project, engine.KindSkill, name)
 	}
 }
 
 func removeAgentsRun(names []string) {
 	project := ProjectDir()
 
-	m, manifestPath, findErr := manifest.LoadManifestFromProject(project)
+	m, manifestPath, findErr := manifestToEdit(project, removeLocal)
 	if findErr != nil {
-		fmt.Fprintf(os.Stderr, "error: no manifest found in %s\n", project)
+		fmt.Fprintf(os.Stderr, "error: %v\n", findErr)
 		return
 	}
 
@@ -152,34 +164,44 @@ func removeAgentsRun(names []string) {
 		names = selected
 	}
 
+	var removed []string
 	for _, name := range names {
-		found := -1
-		for i, a := range m.Agents {
+		found := false
+		for _, a := range m.Agents {
 			if a.Name == name {
-				found = i
+				found = true
 				break
 			}
 		}
-		if found < 0 {
+		if !found {
 			fmt.Fprintf(os.Stderr, "error: agent not found in manifest: %s\n", name)
 			continue
 		}
-		m.Agents = append(m.Agents[:found], m.Agents[found+1:]...)
 		fmt.Printf("Removed agent '%s'\n", name)
+		removed = append(removed, name)
 	}
 
-	if err := manifest.SaveManifest(m, manifestPath); err != nil {
+	err := manifest.EditManifest(manifestPath, func(d *manifest.Document) error {
+		for _, name := range removed {
+			d.Remove("agents", name)
+		}
+		return nil
+	})
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "error saving manifest: %v\n", err)
 		return
 	}
+	for _, name := range removed {
+		uninstallRemoved(project, engine.KindAgent, name)
+	}
 }
 
 func removeInstructionsRun(names []string) {
 	project := ProjectDir()
 
-	m, manifestPath, findErr := manifest.LoadManifestFromProject(project)
+	m, manifestPath, findErr := manifestToEdit(project, removeLocal)
 	if findErr != nil {
-		fmt.Fprintf(os.Stderr, "error: no manifest found in %s\n", project)
+		fmt.Fprintf(os.Stderr, "error: %v\n", findErr)
 		return
 	}
 
@@ -220,28 +242,116 @@ func removeInstructionsRun(names []string) {
 		names = selected
 	}
 
+	var removed []string
 	for _, name := range names {
-		found := -1
-		for i, inst := range m.Instructions {
+		found := false
+		for _, inst := range m.Instructions {
 			if inst.Name == name {
-				found = i
+				found = true
 				break
 			}
 		}
-		if found < 0 {
+		if !found {
 			fmt.Fprintf(os.Stderr, "error: instruction not found in manifest: %s\n", name)
 			continue
 		}
-		m.Instructions = append(m.Instructions[:found], m.Instructions[found+1:]...)
 		fmt.Printf("Removed instruction '%s'\n", name)
+		removed = append(removed, name)
 	}
 
-	if err := manifest.SaveManifest(m, manifestPath); err != nil {
+	err := manifest.EditManifest(manifestPath, func(d *manifest.Document) error {
+		for _, name := range removed {
+			d.Remove("instructions", name)
+		}
+		return nil
+	})
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "error saving manifest: %v\n", err)
 		return
 	}
+	for _, name := range removed {
+		uninstallRemoved(project, engine.KindInstruction, name)
+	}
+}
+
+// uninstallRemoved deletes a resource that was just removed from the manifest
+// from every configured target, unless --keep-files is set.
+func uninstallRemoved(project string, kind engine.ApplyOpKind, name string) {
+	if removeKeepFiles {
+		return
+	}
+	globalPath := defaultGlobalManifestPath()
+	res, err := uninstallFromTargets(project, globalPath, kind, name)
+	if res != nil {
+		fmt.Print(formatRemovalOps(res, "removed"))
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return
+	}
+	if err := syncGeneratedIgnores(project, globalPath); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: update git ignore entries: %v\n", err)
+	}
+}
+
+// uninstallFromTargets resolves the targets configured for project and
+// uninstalls kind/name from each of them.
+func uninstallFromTargets(project, globalPath string, kind engine.ApplyOpKind, name string) (*engine.CleanResult, error) {
+	merged, err := manifest.LoadMergedManifest(project, globalPath)
+	if err != nil {
+		return nil, fmt.Errorf("load manifest: %w", err)
+	}
+	targets, err := target.ResolveTargets(merged.Targets)
+	if err != nil {
+		return nil, err
+	}
+	// A resource still inherited from the global or workspace root manifest
+	// stays installed.
+	if manifestHasResource(merged, kind, name) {
+		res := &engine.CleanResult{}
+		for _, t := range targets {
+			res.Ops = append(res.Ops, engine.ApplyOp{
+				SkillName: name, TargetName: t.Name(), Kind: kind,
+				Status: engine.OpSkipped, Error: "still inherited from another manifest",
+			})
+			res.Skipped++
+		}
+		return res, nil
+	}
+	return engine.Uninstall(project, targets, kind, name)
+}
+
+// manifestHasResource reports whether m declares kind/name. A nil manifest
+// declares nothing.
+func manifestHasResource(m *manifest.Manifest, kind engine.ApplyOpKind, name string) bool {
+	if m == nil {
+		return false
+	}
+	switch kind {
+	case engine.KindInstruction:
+		for _, r := range m.Instructions {
+			if r.Name == name {
+				return true
+			}
+		}
+	case engine.KindAgent:
+		for _, r := range m.Agents {
+			if r.Name == name {
+				return true
+			}
+		}
+	default:
+		for _, r := range m.Skills {
+			if r.Name == name {
+				return true
+			}
+		}
+	}
+	return false
 }
 
 func init() {
+	removeCmd.Flags().BoolVar(&removeKeepFiles, "keep-files", false, "only edit the manifest; leave installed files in targets")
+	removeCmd.Flags().BoolVar(&removeLocal, "local", false, "remove from vibes.local.yaml instead of vibes.yaml")
 	rootCmd.AddCommand(removeCmd)
 }
@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/chaz8081/positive-vibes/internal/engine"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUninstallFromTargets_RemovesFromConfiguredTargets(t *testing.T) {
+	projectDir := t.TempDir()
+	globalPath := filepath.Join(t.TempDir(), "vibes.yaml")
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "vibes.yaml"), []byte("targets:\n  - cursor\n  - opencode\n"), 0o644))
+
+	handWritten := filepath.Join(projectDir, ".cursor", "agents", "reviewer.md")
+	require.NoError(t, os.MkdirAll(filepath.Dir(handWritten), 0o755))
+	require.NoError(t, os.WriteFile(handWritten, []byte("# Reviewer"), 0o644))
+
+	res, err := uninstallFromTargets(projectDir, globalPath, engine.KindAgent, "reviewer")
+	require.NoError(t, err)
+	require.Len(t, res.Ops, 2)
+	assert.Equal(t, 0, res.Removed)
+	assert.Equal(t, 1, res.Skipped)
+	assert.FileExists(t, handWritten, "files without an install record are never deleted")
+
+	out := formatRemovalOps(res, "removed")
+	assert.Contains(t, out, "skipped agent:   reviewer -> cursor (not installed by positive-vibes)")
+	assert.Contains(t, out, "not found agent: reviewer -> opencode")
+	assert.Contains(t, out, "left untracked .cursor/agents/reviewer.md in place")
+}
+
+func TestUninstallFromTargets_KeepsResourceStillInGlobal(t *testing.T) {
+	projectDir := t.TempDir()
+	globalPath := filepath.Join(t.TempDir(), "vibes.yaml")
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "vibes.yaml"), []byte("targets:\n  - cursor\n"), 0o644))
+	require.NoError(t, os.WriteFile(globalPath, []byte("skills:\n  - name: tdd\n"), 0o644))
+
+	installed := filepath.Join(projectDir, ".cursor", "skills", "tdd", "SKILL.md")
+	require.NoError(t, os.MkdirAll(filepath.Dir(installed), 0o755))
+	require.NoError(t, os.WriteFile(installed, []byte("# TDD"), 0o644))
+
+	res, err := uninstallFromTargets(projectDir, globalPath, engine.KindSkill, "tdd")
+	require.NoError(t, err)
+	require.Len(t, res.Ops, 1)
+	assert.Equal(t, engine.OpSkipped, res.Ops[0].Status)
+	assert.FileExists(t, installed)
+}
+
+func TestRemoveCommand_HasKeepFilesFlag(t *testing.T) {
+	assert.NotNil(t, removeCmd.Flags().Lookup("keep-files"))
+}
+
+func TestInstallAndRemoveCommands_HaveLocalFlag(t *testing.T) {
+	assert.NotNil(t, installCmd.Flags().Lookup("local"))
+	assert.NotNil(t, removeCmd.Flags().Lookup("local"))
+}
+
+func TestManifestToEdit(t *testing.T) {
+	projectDir := t.TempDir()
+
+	_, p, err := manifestToEdit(projectDir, false)
+	require.Error(t, err)
+	assert.Equal(t, filepath.Join(projectDir, "vibes.yaml"), p)
+
+	_, p, err = manifestToEdit(projectDir, true)
+	require.Error(t, err)
+	assert.Equal(t, filepath.Join(projectDir, "vibes.local.yaml"), p)
+
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "vibes.local.yaml"), []byte("agents:\n  - name: mine\n    path: ./mine.md\n"), 0o644))
+	m, p, err := manifestToEdit(projectDir, true)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(projectDir, "vibes.local.yaml"), p)
+	require.Len(t, m.Agents, 1)
+}
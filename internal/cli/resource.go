@@ -62,7 +62,7 @@ type registrySkillSet struct {
 }
 
 // collectSkillSets gathers available skills from the embedded registry and
-// any git registries defined in the merged manifest. If merged is nil, only
+// any registries defined in the merged manifest. If merged is nil, only
 // the embedded registry is consulted.
 func collectSkillSets(merged *manifest.Manifest) []registrySkillSet {
 	var sets []registrySkillSet
@@ -76,9 +76,9 @@ func collectSkillSets(merged *manifest.Manifest) []registrySkillSet {
 		})
 	}
 
-	// Add git registries from merged manifest.
+	// Add registries from merged manifest.
 	if merged != nil {
-		for _, src := range gitRegistriesFromManifest(merged) {
+		for _, src := range registriesFromManifest(merged) {
 			names, err := src.List()
 			if err != nil {
 				sets = append(sets, registrySkillSet{
@@ -91,7 +91,7 @@ func collectSkillSets(merged *manifest.Manifest) []registrySkillSet {
 				var url string
 				for _, r := range merged.Registries {
 					if r.Name == src.Name() {
-						url = r.URL
+						url = registry.RedactURL(r.URL)
 						break
 					}
 				}
@@ -305,12 +305,12 @@ func formatResourceListJSON(resType ResourceType, items []ResourceItem) string {
 
 // --- Show helpers ---
 
-// buildAllSources returns the embedded registry plus any git registries
+// buildAllSources returns the embedded registry plus any registries
 // from the merged manifest as a unified slice of SkillSource.
 func buildAllSources(merged *manifest.Manifest) []registry.SkillSource {
 	sources := []registry.SkillSource{registry.NewEmbeddedRegistry()}
 	if merged != nil {
-		sources = append(sources, gitRegistriesFromManifest(merged)...)
+		sources = append(sources, registriesFromManifest(merged)...)
 	}
 	return sources
 }
@@ -510,14 +510,13 @@ func collectAvailableInstructions(merged *manifest.Manifest) []ResourceItem {
 	return items
 }
 
+// collectRegistryResourceItems lists instructions or agents available from the
+// embedded registry and any registries in the merged manifest.
 func collectRegistryResourceItems(merged *manifest.Manifest, resType ResourceType) []registryResourceItem {
-	if merged == nil {
-		return nil
-	}
 	kind := string(resType)
 	seen := make(map[string]bool)
 	var items []registryResourceItem
-	for _, src := range gitRegistriesFromManifest(merged) {
+	for _, src := range buildAllSources(merged) {
 		fs, ok := src.(registry.ResourceSource)
 		if !ok {
 			continue
@@ -677,6 +676,23 @@ func completeInstructionNames(merged *manifest.Manifest, mode string) []string {
 	}
 }
 
+// manifestToEdit loads the manifest that install and remove edit in project:
+// vibes.local.yaml when personal is set, otherwise the project manifest. The
+// returned path is usable even when loading fails, e.g. to create the file;
+// for a project without a manifest it is vibes.yaml.
+func manifestToEdit(project string, personal bool) (*manifest.Manifest, string, error) {
+	if personal {
+		p := manifest.PersonalManifestPath(project)
+		m, err := manifest.LoadManifest(p)
+		return m, p, err
+	}
+	m, p, err := manifest.LoadManifestFromProject(project)
+	if err != nil {
+		return nil, filepath.Join(project, "vibes.yaml"), err
+	}
+	return m, p, nil
+}
+
 func contains(items []string, v string) bool {
 	for _, item := range items {
 		if item == v {
@@ -729,16 +745,47 @@ func makeValidArgsFunction(nameMode string) func(cmd *cobra.Command, args []stri
 		}
 
 		all := completeResourceNames(resType, nameMode)
+		descriptions := indexedDescriptions(resType)
 		var suggestions []string
 		for _, name := range all {
-			if !existing[name] {
-				suggestions = append(suggestions, name)
+			if existing[name] {
+				continue
 			}
+			if d := descriptions[name]; d != "" {
+				name += "\t" + d
+			}
+			suggestions = append(suggestions, name)
 		}
 		return suggestions, cobra.ShellCompDirectiveNoFileComp
 	}
 }
 
+// indexedDescriptions maps resource names of the given type to the
+// descriptions in their registries' index files, for shell completion.
+// Registries without an index contribute nothing.
+func indexedDescriptions(resType ResourceType) map[string]string {
+	merged, _ := manifest.LoadMergedManifest(ProjectDir(), defaultGlobalManifestPath())
+	descriptions := make(map[string]string)
+	for _, src := range buildAllSources(merged) {
+		is, ok := src.(registry.IndexSource)
+		if !ok {
+			continue
+		}
+		ix, err := is.Index()
+		if err != nil || ix == nil {
+			continue
+		}
+		for _, e := range ix.Resources {
+			if e.Kind != string(resType) || descriptions[e.Name] != "" {
+				continue
+			}
+			first, _, _ := strings.Cut(e.Description, "\n")
+			descriptions[e.Name] = strings.TrimSpace(first)
+		}
+	}
+	return descriptions
+}
+
 // dedup returns a new slice with duplicate strings removed, preserving order.
 func dedup(names []string) []string {
 	seen := make(map[string]bool, len(names))
@@ -2,10 +2,13 @@ package cli
 
 import (
 	"encoding/json"
+	"path/filepath"
 	"testing"
 
 	"github.com/chaz8081/positive-vibes/internal/manifest"
+	"github.com/chaz8081/positive-vibes/internal/registry"
 	"github.com/chaz8081/positive-vibes/pkg/schema"
+	gogit "github.com/go-git/go-git/v5"
 	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -591,3 +594,30 @@ func TestShowCmd_HasValidArgsFunction(t *testing.T) {
 func TestRemoveCmd_HasValidArgsFunction(t *testing.T) {
 	assert.NotNil(t, removeCmd.ValidArgsFunction, "remove command should have ValidArgsFunction set")
 }
+
+func TestRegistryFromRef_PicksSourceType(t *testing.T) {
+	git := registryFromRef(manifest.RegistryRef{Name: "team", URL: "https://github.com/org/skills", Ref: "latest"})
+	require.IsType(t, &registry.GitRegistry{}, git)
+	gr := git.(*registry.GitRegistry)
+	assert.Equal(t, filepath.Join(defaultCacheRoot(), registry.CacheKey("team", "https://github.com/org/skills", "latest")), gr.CachePath)
+	assert.Equal(t, filepath.Join(defaultCacheRoot(), "objects", registry.RepoKey("https://github.com/org/skills")), gr.ObjectsPath)
+	assert.Equal(t, filepath.Join(defaultCacheRoot(), "team"), gr.LegacyCachePath)
+
+	forked := registryFromRef(manifest.RegistryRef{Name: "team", URL: "https://github.com/fork/skills", Ref: "latest"})
+	assert.NotEqual(t, gr.CachePath, forked.(*registry.GitRegistry).CachePath, "same-named registries of different repos get separate caches")
+
+	archive := registryFromRef(manifest.RegistryRef{Name: "release", URL: "https://artifacts.example.com/skills-1.2.tar.gz", Ref: "v1.2", SHA256: "abc"})
+	require.IsType(t, &registry.ArchiveRegistry{}, archive)
+	assert.Equal(t, "abc", archive.(*registry.ArchiveRegistry).SHA256)
+
+	dir := registryFromRef(manifest.RegistryRef{Name: "shared", URL: "file:///mnt/skills", Ref: "latest"})
+	require.IsType(t, &registry.DirRegistry{}, dir)
+	assert.Equal(t, "/mnt/skills", dir.(*registry.DirRegistry).Path)
+
+	repo := t.TempDir()
+	_, err := gogit.PlainInit(repo, false)
+	require.NoError(t, err)
+	local := registryFromRef(manifest.RegistryRef{Name: "local", URL: repo, Ref: "v1.0.0"})
+	require.IsType(t, &registry.GitRegistry{}, local, "an untyped path to a git repository is still cloned")
+	assert.Equal(t, "v1.0.0", local.(*registry.GitRegistry).Ref)
+}
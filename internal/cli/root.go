@@ -36,6 +36,8 @@ func init() {
 	// Persistent flags
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "enable verbose output")
 	rootCmd.PersistentFlags().StringVarP(&projectDir, "project-dir", "p", ".", "override project root directory")
+
+	manifest.RegistryFileFetcher = fetchRegistryManifestFile
 }
 
 // Execute runs the root cobra command
@@ -56,13 +58,34 @@ func debugf(format string, a ...interface{}) {
 	}
 }
 
-// defaultCachePath returns ~/.positive-vibes/cache/<name>.
-func defaultCachePath(name string) string {
+// defaultCacheRoot returns ~/.positive-vibes/cache, which holds one
+// directory per git or archive registry and ref, plus the git object stores
+// they share.
+func defaultCacheRoot() string {
 	home, err := os.UserHomeDir()
 	if err != nil {
 		home = "."
 	}
-	return filepath.Join(home, ".positive-vibes", "cache", name)
+	return filepath.Join(home, ".positive-vibes", "cache")
+}
+
+// registryCachePath returns the cache directory of a git or archive
+// registry: ~/.positive-vibes/cache/<name>-<key>, keyed by its URL and ref
+// so same-named registries of different projects never share a cache.
+func registryCachePath(r manifest.RegistryRef) string {
+	return filepath.Join(defaultCacheRoot(), registry.CacheKey(r.Name, r.URL, r.Ref))
+}
+
+// registryObjectsPath returns the object store shared by every ref of a git
+// registry's repository: ~/.positive-vibes/cache/objects/<key>.
+func registryObjectsPath(r manifest.RegistryRef) string {
+	return filepath.Join(defaultCacheRoot(), registry.ObjectStoreDir, registry.RepoKey(r.URL))
+}
+
+// legacyCachePath returns where a registry was cached before caches were
+// keyed by URL and ref; a matching cache there is moved on first use.
+func legacyCachePath(r manifest.RegistryRef) string {
+	return filepath.Join(defaultCacheRoot(), r.Name)
 }
 
 // defaultGlobalManifestPath returns the path to the user-level global config.
@@ -80,19 +103,77 @@ func defaultGlobalManifestPath() string {
 	return filepath.Join(configDir, "positive-vibes", "vibes.yaml")
 }
 
-// gitRegistriesFromManifest builds GitRegistry sources for each registry in the manifest.
-func gitRegistriesFromManifest(m *manifest.Manifest) []registry.SkillSource {
-	var sources []registry.SkillSource
-	for _, r := range m.Registries {
-		sources = append(sources, &registry.GitRegistry{
+// registryFromRef builds the registry source for a manifest registry entry.
+func registryFromRef(r manifest.RegistryRef) registry.SkillSource {
+	switch r.SourceType() {
+	case manifest.RegistryTypeDir:
+		return &registry.DirRegistry{
+			RegistryName:     r.Name,
+			Path:             r.DirPath(),
+			SkillsPath:       r.SkillsPath(),
+			InstructionsPath: r.InstructionsPath(),
+			AgentsPath:       r.AgentsPath(),
+		}
+	case manifest.RegistryTypeArchive:
+		return &registry.ArchiveRegistry{
 			RegistryName:     r.Name,
 			URL:              r.URL,
-			CachePath:        defaultCachePath(r.Name),
+			SHA256:           r.SHA256,
+			CachePath:        registryCachePath(r),
+			LegacyCachePath:  legacyCachePath(r),
 			SkillsPath:       r.SkillsPath(),
 			InstructionsPath: r.InstructionsPath(),
 			AgentsPath:       r.AgentsPath(),
 			Ref:              r.Ref,
-		})
+		}
+	}
+	return &registry.GitRegistry{
+		RegistryName:     r.Name,
+		URL:              r.URL,
+		CachePath:        registryCachePath(r),
+		ObjectsPath:      registryObjectsPath(r),
+		LegacyCachePath:  legacyCachePath(r),
+		SkillsPath:       r.SkillsPath(),
+		InstructionsPath: r.InstructionsPath(),
+		AgentsPath:       r.AgentsPath(),
+		Ref:              r.Ref,
+		Auth:             gitAuthFromRef(r.Auth),
+	}
+}
+
+// gitAuthFromRef converts a manifest auth block to registry credentials.
+func gitAuthFromRef(a *manifest.RegistryAuth) *registry.GitAuth {
+	if a == nil {
+		return nil
+	}
+	return &registry.GitAuth{
+		TokenEnv:   a.TokenEnv,
+		Username:   a.Username,
+		SSHKey:     a.SSHKey,
+		KnownHosts: a.KnownHosts,
+	}
+}
+
+// registriesFromManifest builds sources for each registry in the manifest
+// that its policy allows. Disallowed registries are left out so nothing ever
+// fetches, clones or searches them.
+func registriesFromManifest(m *manifest.Manifest) []registry.SkillSource {
+	var sources []registry.SkillSource
+	for _, r := range m.Registries {
+		if len(m.Policy.CheckRegistry(r)) > 0 {
+			debugf("skipping registry %s: not allowed by policy", r.Name)
+			continue
+		}
+		sources = append(sources, registryFromRef(r))
 	}
 	return sources
 }
+
+// fetchRegistryManifestFile serves "registry:path" extends entries.
+func fetchRegistryManifestFile(r manifest.RegistryRef, relPath string) ([]byte, error) {
+	src, ok := registryFromRef(r).(registry.RootFileSource)
+	if !ok {
+		return nil, fmt.Errorf("registry %q does not support extends", r.Name)
+	}
+	return src.FetchRootFile(relPath)
+}
@@ -0,0 +1,301 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/chaz8081/positive-vibes/internal/engine"
+	"github.com/chaz8081/positive-vibes/internal/manifest"
+	"github.com/chaz8081/positive-vibes/internal/registry"
+	"github.com/chaz8081/positive-vibes/pkg/schema"
+	"github.com/spf13/cobra"
+)
+
+var (
+	searchKinds    []string
+	searchRegistry string
+	searchTags     []string
+	searchLimit    int
+	searchJSON     bool
+)
+
+var searchCmd = &cobra.Command{
+	Use:   "search <query>",
+	Short: "Search skills, instructions and agents across registries",
+	Long: `Ranks the skills, instructions and agents offered by the embedded
+registry and every configured registry against the query. Names, tags and
+descriptions count more than the body text. Each result shows the command
+that installs it.
+
+Examples:
+  positive-vibes search code review
+  positive-vibes search testing --kind skills --tag go
+  positive-vibes search deploy --registry team --json`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var kinds []ResourceType
+		for _, k := range searchKinds {
+			resType, err := ParseResourceType(k)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "error: %v\n", err)
+				os.Exit(1)
+			}
+			kinds = append(kinds, resType)
+		}
+
+		merged, _ := manifest.LoadMergedManifest(ProjectDir(), defaultGlobalManifestPath())
+		docs, errs := collectSearchDocs(buildAllSources(merged), kinds, searchRegistry)
+		for _, err := range errs {
+			fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+		}
+
+		query := strings.Join(args, " ")
+		results := filterSearchTags(engine.Search(docs, query), searchTags)
+		if searchLimit > 0 && len(results) > searchLimit {
+			results = results[:searchLimit]
+		}
+
+		installed := installedResourceNames(merged)
+		if searchJSON {
+			fmt.Println(formatSearchJSON(query, results, installed))
+			return
+		}
+		fmt.Print(formatSearchResults(results, installed))
+	},
+}
+
+// collectSearchDocs reads every resource of the given kinds (all kinds when
+// empty) from sources, skipping registries other than registryName when it
+// is set. A kind that can't be read from a registry is reported and skipped.
+func collectSearchDocs(sources []registry.SkillSource, kinds []ResourceType, registryName string) ([]engine.SearchDoc, []error) {
+	if len(kinds) == 0 {
+		kinds = []ResourceType{ResourceSkills, ResourceInstructions, ResourceAgents}
+	}
+	var docs []engine.SearchDoc
+	var errs []error
+	for _, src := range sources {
+		if registryName != "" && src.Name() != registryName {
+			continue
+		}
+		for _, kind := range kinds {
+			found, err := searchDocsFrom(src, kind)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("registry %s: %w", src.Name(), err))
+				continue
+			}
+			docs = append(docs, found...)
+		}
+	}
+	return docs, errs
+}
+
+// searchDocsFrom reads the resources of one kind from src. Resources listed
+// in the registry's index are described from it, without their body text,
+// instead of being fetched.
+func searchDocsFrom(src registry.SkillSource, kind ResourceType) ([]engine.SearchDoc, error) {
+	indexed, err := indexEntries(src, kind)
+	if err != nil {
+		return nil, err
+	}
+	var docs []engine.SearchDoc
+	if kind == ResourceSkills {
+		names, err := src.List()
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			if e, ok := indexed[name]; ok {
+				docs = append(docs, indexedSearchDoc(src, kind, e))
+				continue
+			}
+			sk, _, err := src.Fetch(name)
+			if err != nil {
+				continue
+			}
+			docs = append(docs, engine.SearchDoc{
+				Kind: string(kind), Name: name, Registry: src.Name(),
+				Description: sk.Description, Tags: sk.Tags, Body: sk.Instructions,
+			})
+		}
+		return docs, nil
+	}
+
+	rs, ok := src.(registry.ResourceSource)
+	if !ok {
+		return nil, nil
+	}
+	files, err := rs.ListResourceFiles(string(kind))
+	if err != nil {
+		return nil, err
+	}
+	for _, rel := range files {
+		name := resourceNameFromPath(kind, rel)
+		if name == "" {
+			continue
+		}
+		if e, ok := indexed[name]; ok {
+			docs = append(docs, indexedSearchDoc(src, kind, e))
+			continue
+		}
+		data, err := rs.FetchResourceFile(string(kind), rel)
+		if err != nil {
+			continue
+		}
+		doc := engine.SearchDoc{Kind: string(kind), Name: name, Registry: src.Name(), Body: string(data)}
+		if meta, err := schema.ParseSkillFile(data); err == nil {
+			doc.Description, doc.Tags, doc.Body = meta.Description, meta.Tags, meta.Instructions
+		}
+		docs = append(docs, doc)
+	}
+	return docs, nil
+}
+
+// indexEntries returns src's index entries of the given kind by name, or
+// nil when src has no index.
+func indexEntries(src registry.SkillSource, kind ResourceType) (map[string]registry.IndexEntry, error) {
+	is, ok := src.(registry.IndexSource)
+	if !ok {
+		return nil, nil
+	}
+	ix, err := is.Index()
+	if err != nil || ix == nil {
+		return nil, err
+	}
+	entries := make(map[string]registry.IndexEntry)
+	for _, e := range ix.Resources {
+		if _, dup := entries[e.Name]; e.Kind == string(kind) && !dup {
+			entries[e.Name] = e
+		}
+	}
+	return entries, nil
+}
+
+func indexedSearchDoc(src registry.SkillSource, kind ResourceType, e registry.IndexEntry) engine.SearchDoc {
+	return engine.SearchDoc{Kind: string(kind), Name: e.Name, Registry: src.Name(), Description: e.Description, Tags: e.Tags}
+}
+
+// filterSearchTags keeps results carrying every tag in tags (ignoring case).
+func filterSearchTags(results []engine.SearchResult, tags []string) []engine.SearchResult {
+	if len(tags) == 0 {
+		return results
+	}
+	var out []engine.SearchResult
+	for _, r := range results {
+		have := make(map[string]bool, len(r.Tags))
+		for _, t := range r.Tags {
+			have[strings.ToLower(t)] = true
+		}
+		keep := true
+		for _, t := range tags {
+			if !have[strings.ToLower(t)] {
+				keep = false
+				break
+			}
+		}
+		if keep {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// installedResourceNames returns the names in merged keyed by
+// SourceKey(kind, name).
+func installedResourceNames(merged *manifest.Manifest) map[string]bool {
+	installed := make(map[string]bool)
+	if merged == nil {
+		return installed
+	}
+	for _, s := range merged.Skills {
+		installed[manifest.SourceKey(string(ResourceSkills), s.Name)] = true
+	}
+	for _, i := range merged.Instructions {
+		installed[manifest.SourceKey(string(ResourceInstructions), i.Name)] = true
+	}
+	for _, a := range merged.Agents {
+		installed[manifest.SourceKey(string(ResourceAgents), a.Name)] = true
+	}
+	return installed
+}
+
+func searchInstallCommand(r engine.SearchResult) string {
+	return fmt.Sprintf("positive-vibes install %s %s", r.Kind, r.Name)
+}
+
+// formatSearchResults renders results best first, each with the command
+// that installs it.
+func formatSearchResults(results []engine.SearchResult, installed map[string]bool) string {
+	if len(results) == 0 {
+		return "No matching resources found.\n"
+	}
+	var b strings.Builder
+	for i, r := range results {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "%s/%s (%s)", r.Kind, r.Name, r.Registry)
+		if installed[manifest.SourceKey(r.Kind, r.Name)] {
+			b.WriteString("  [installed]")
+		}
+		b.WriteString("\n")
+		if d := strings.TrimSpace(r.Description); d != "" {
+			first, _, _ := strings.Cut(d, "\n")
+			fmt.Fprintf(&b, "  %s\n", first)
+		}
+		if len(r.Tags) > 0 {
+			fmt.Fprintf(&b, "  tags: %s\n", strings.Join(r.Tags, ", "))
+		}
+		fmt.Fprintf(&b, "  %s\n", searchInstallCommand(r))
+	}
+	fmt.Fprintf(&b, "\n%d result(s)\n", len(results))
+	return b.String()
+}
+
+type searchJSONOutput struct {
+	Query   string             `json:"query"`
+	Results []searchResultJSON `json:"results"`
+	Total   int                `json:"total"`
+}
+
+type searchResultJSON struct {
+	Name        string   `json:"name"`
+	Kind        string   `json:"kind"`
+	Registry    string   `json:"registry"`
+	Description string   `json:"description,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	Score       float64  `json:"score"`
+	Installed   bool     `json:"installed"`
+	Install     string   `json:"install"`
+}
+
+func formatSearchJSON(query string, results []engine.SearchResult, installed map[string]bool) string {
+	out := searchJSONOutput{Query: query, Results: make([]searchResultJSON, 0, len(results)), Total: len(results)}
+	for _, r := range results {
+		out.Results = append(out.Results, searchResultJSON{
+			Name:        r.Name,
+			Kind:        r.Kind,
+			Registry:    r.Registry,
+			Description: r.Description,
+			Tags:        r.Tags,
+			Score:       r.Score,
+			Installed:   installed[manifest.SourceKey(r.Kind, r.Name)],
+			Install:     searchInstallCommand(r),
+		})
+	}
+	data, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return fmt.Sprintf(`{"error": %q}`, err.Error())
+	}
+	return string(data)
+}
+
+func init() {
+	searchCmd.Flags().StringSliceVar(&searchKinds, "kind", nil, "only search these resource types (skills, agents, instructions)")
+	searchCmd.Flags().StringVar(&searchRegistry, "registry", "", "only search this registry")
+	searchCmd.Flags().StringSliceVar(&searchTags, "tag", nil, "only show results with every one of these tags")
+	searchCmd.Flags().IntVar(&searchLimit, "limit", 20, "maximum number of results (0 for all)")
+	searchCmd.Flags().BoolVar(&searchJSON, "json", false, "output as JSON")
+	rootCmd.AddCommand(searchCmd)
+}
@@ -0,0 +1,135 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/chaz8081/positive-vibes/internal/engine"
+	"github.com/chaz8081/positive-vibes/internal/manifest"
+	"github.com/chaz8081/positive-vibes/internal/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func searchTestRegistry(t *testing.T) *registry.DirRegistry {
+	t.Helper()
+	root := t.TempDir()
+	files := map[string]string{
+		"skills/tdd/SKILL.md":                   "---\nname: tdd\ndescription: Test-driven development\ntags: [testing, go]\n---\nWrite the failing test first.\n",
+		"skills/deploy/SKILL.md":                "---\nname: deploy\ndescription: Ship releases\n---\nTag and push.\n",
+		"instructions/go-tests.instructions.md": "---\ndescription: How we write Go tests\ntags: [testing]\n---\nUse table-driven tests.\n",
+		"agents/reviewer.agent.md":              "# Reviewer\nChecks that every change has tests.\n",
+		"agents/notes.md":                       "not an agent",
+	}
+	for rel, content := range files {
+		path := filepath.Join(root, rel)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	return &registry.DirRegistry{RegistryName: "team", Path: root, SkillsPath: "skills", InstructionsPath: "instructions", AgentsPath: "agents"}
+}
+
+func TestCollectSearchDocs(t *testing.T) {
+	src := searchTestRegistry(t)
+
+	docs, errs := collectSearchDocs([]registry.SkillSource{src}, nil, "")
+	require.Empty(t, errs)
+	var keys []string
+	for _, d := range docs {
+		keys = append(keys, manifest.SourceKey(d.Kind, d.Name))
+	}
+	assert.ElementsMatch(t, []string{"skills/deploy", "skills/tdd", "instructions/go-tests", "agents/reviewer"}, keys)
+
+	docs, _ = collectSearchDocs([]registry.SkillSource{src}, []ResourceType{ResourceInstructions}, "")
+	require.Len(t, docs, 1)
+	assert.Equal(t, "How we write Go tests", docs[0].Description)
+	assert.Equal(t, []string{"testing"}, docs[0].Tags)
+
+	docs, _ = collectSearchDocs([]registry.SkillSource{src}, nil, "other")
+	assert.Empty(t, docs)
+}
+
+func TestCollectSearchDocs_ReportsUnreadableRegistry(t *testing.T) {
+	missing := &registry.DirRegistry{RegistryName: "gone", Path: filepath.Join(t.TempDir(), "gone")}
+	docs, errs := collectSearchDocs([]registry.SkillSource{missing, searchTestRegistry(t)}, []ResourceType{ResourceSkills}, "")
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "registry gone")
+	assert.Len(t, docs, 2)
+}
+
+func TestCollectSearchDocs_KeepsReadableKinds(t *testing.T) {
+	src := searchTestRegistry(t)
+	src.SkillsPath = "no-such-dir"
+	docs, errs := collectSearchDocs([]registry.SkillSource{src}, nil, "")
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "registry team")
+	var keys []string
+	for _, d := range docs {
+		keys = append(keys, manifest.SourceKey(d.Kind, d.Name))
+	}
+	assert.ElementsMatch(t, []string{"instructions/go-tests", "agents/reviewer"}, keys)
+}
+
+func TestCollectSearchDocs_UsesIndex(t *testing.T) {
+	src := searchTestRegistry(t)
+	require.NoError(t, os.WriteFile(filepath.Join(src.Path, "index.yaml"), []byte(`version: 1
+resources:
+  - name: tdd
+    kind: skills
+    description: Indexed description
+    tags: [indexed]
+    path: skills/tdd
+  - name: go-tests
+    kind: instructions
+    description: Indexed instructions
+    path: instructions/go-tests.instructions.md
+`), 0o644))
+	// Indexed resources are not read from disk.
+	require.NoError(t, os.Remove(filepath.Join(src.Path, "instructions", "go-tests.instructions.md")))
+
+	docs, errs := collectSearchDocs([]registry.SkillSource{src}, []ResourceType{ResourceSkills, ResourceInstructions}, "")
+	require.Empty(t, errs)
+	require.Len(t, docs, 2)
+	assert.Equal(t, engine.SearchDoc{Kind: "skills", Name: "tdd", Registry: "team", Description: "Indexed description", Tags: []string{"indexed"}}, docs[0])
+	assert.Equal(t, "Indexed instructions", docs[1].Description)
+}
+
+func TestSearch_EndToEndRankingAndTags(t *testing.T) {
+	docs, _ := collectSearchDocs([]registry.SkillSource{searchTestRegistry(t)}, nil, "")
+	results := engine.Search(docs, "tests")
+	require.NotEmpty(t, results)
+	assert.Equal(t, "go-tests", results[0].Name)
+
+	tagged := filterSearchTags(engine.Search(docs, "test"), []string{"GO"})
+	require.Len(t, tagged, 1)
+	assert.Equal(t, "tdd", tagged[0].Name)
+}
+
+func TestFormatSearchResults(t *testing.T) {
+	results := []engine.SearchResult{
+		{SearchDoc: engine.SearchDoc{Kind: "skills", Name: "tdd", Registry: "team", Description: "Test-driven development\nMore detail.", Tags: []string{"testing"}}, Score: 2.5},
+		{SearchDoc: engine.SearchDoc{Kind: "agents", Name: "reviewer", Registry: "embedded"}, Score: 1},
+	}
+	installed := map[string]bool{"skills/tdd": true}
+
+	out := formatSearchResults(results, installed)
+	assert.Equal(t, `skills/tdd (team)  [installed]
+  Test-driven development
+  tags: testing
+  positive-vibes install skills tdd
+
+agents/reviewer (embedded)
+  positive-vibes install agents reviewer
+
+2 result(s)
+`, out)
+	assert.Equal(t, "No matching resources found.\n", formatSearchResults(nil, installed))
+
+	var parsed searchJSONOutput
+	require.NoError(t, json.Unmarshal([]byte(formatSearchJSON("tdd", results, installed)), &parsed))
+	assert.Equal(t, 2, parsed.Total)
+	assert.True(t, parsed.Results[0].Installed)
+	assert.Equal(t, "positive-vibes install agents reviewer", parsed.Results[1].Install)
+}
@@ -5,6 +5,7 @@ import (
 	"os"
 
 	"github.com/chaz8081/positive-vibes/internal/manifest"
+	"github.com/chaz8081/positive-vibes/internal/registry"
 	"github.com/spf13/cobra"
 )
 
@@ -56,7 +57,7 @@ func showSkillRun(name string, merged *manifest.Manifest) {
 	if merged != nil {
 		for _, r := range merged.Registries {
 			if r.Name == regName {
-				regURL = r.URL
+				regURL = registry.RedactURL(r.URL)
 				break
 			}
 		}
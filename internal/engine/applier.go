@@ -2,6 +2,7 @@ package engine
 
 import (
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 
@@ -44,11 +45,19 @@ type ApplyResult struct {
 	Installed int
 	Skipped   int
 	Errors    []string
+	Warnings  []string
 	Ops       []ApplyOp
 }
 
 type Applier struct {
 	Registries []registry.SkillSource
+	// Filter restricts ApplyManifest to part of the manifest. Resources it
+	// excludes are never fetched.
+	Filter ApplyFilter
+	// CacheRoot is where registries cache their fetches. Sources under it
+	// are copied even in link mode: cache clean, prune and re-keying delete
+	// them and would leave the links dangling.
+	CacheRoot string
 }
 
 func NewApplier(regs []registry.SkillSource) *Applier {
@@ -65,6 +74,17 @@ func (a *Applier) Apply(manifestPath string, opts target.InstallOpts) (*ApplyRes
 	return a.ApplyManifest(m, projectDir, opts)
 }
 
+// NeededRegistries returns the names of registries that ApplyManifest(m)
+// may read from once a.Filter is applied; see the NeededRegistries function.
+func (a *Applier) NeededRegistries(m *manifest.Manifest) (names []string, all bool, err error) {
+	selected, err := a.Filter.Select(m.WithoutDisabled())
+	if err != nil {
+		return nil, false, fmt.Errorf("select resources: %w", err)
+	}
+	names, all = NeededRegistries(selected)
+	return names, all, nil
+}
+
 // ApplyManifest installs resources from an already-loaded manifest.
 // projectDir is used as the base for resolving relative resource paths.
 func (a *Applier) ApplyManifest(m *manifest.Manifest, projectDir string, opts target.InstallOpts) (*ApplyResult, error) {
@@ -74,28 +94,42 @@ func (a *Applier) ApplyManifest(m *manifest.Manifest, projectDir string, opts ta
 	if err := m.Validate(); err != nil {
 		return nil, fmt.Errorf("validate manifest: %w", err)
 	}
+	if err := m.EnforcePolicy(); err != nil {
+		return nil, err
+	}
+	m, err := a.Filter.Select(m.WithoutDisabled())
+	if err != nil {
+		return nil, fmt.Errorf("select resources: %w", err)
+	}
 
 	targets, err := target.ResolveTargets(m.Targets)
 	if err != nil {
 		return nil, fmt.Errorf("resolve targets: %w", err)
 	}
 
+	state, err := LoadInstallState(projectDir)
+	if err != nil {
+		return nil, err
+	}
+
 	res := &ApplyResult{}
 
 	// iterate skills
 	for _, s := range m.Skills {
 		var sk *schema.Skill
 		var srcDir string
+		var srcFS fs.FS
 
 		if s.Registry != "" {
 			skillPath := s.Path
 			if skillPath == "" {
 				skillPath = s.Name
 			}
-			got, dir, err := a.fetchSkillFromRegistry(s.Registry, skillPath)
+			got, dir, fsys, err := a.fetchSkillFromRegistry(s.Registry, skillPath)
 			if err == nil {
 				sk = got
 				srcDir = dir
+				srcFS = fsys
 			}
 		} else if s.Path != "" {
 			// local override path -- resolve relative to project directory
@@ -116,10 +150,11 @@ func (a *Applier) ApplyManifest(m *manifest.Manifest, projectDir string, opts ta
 		// if not local, search registries
 		if sk == nil {
 			for _, r := range a.Registries {
-				got, dir, err := r.Fetch(s.Name)
+				got, dir, fsys, err := fetchSkill(r, s.Name)
 				if err == nil {
 					sk = got
 					srcDir = dir
+					srcFS = fsys
 					break
 				}
 			}
@@ -136,6 +171,9 @@ func (a *Applier) ApplyManifest(m *manifest.Manifest, projectDir string, opts ta
 			continue
 		}
 
+		skillOpts := linkFallback(opts, srcDir == "" || a.isEphemeralSource(srcDir, projectDir), KindSkill, sk.Name, res)
+		skillOpts.SourceFS = srcFS
+
 		// install to each target
 		for _, t := range targets {
 			if t.SkillExists(sk.Name, projectDir) {
@@ -150,7 +188,10 @@ func (a *Applier) ApplyManifest(m *manifest.Manifest, projectDir string, opts ta
 					continue
 				}
 			}
-			if err := t.Install(sk, srcDir, projectDir, opts); err != nil {
+			dest := target.SkillPath(t, projectDir, sk.Name)
+			if err := trackInstall(state, projectDir, t.Name(), KindSkill, sk.Name, dest, func() error {
+				return t.Install(sk, srcDir, projectDir, skillOpts)
+			}); err != nil {
 				errMsg := fmt.Sprintf("install %s -> %s: %v", sk.Name, t.Name(), err)
 				res.Errors = append(res.Errors, errMsg)
 				res.Ops = append(res.Ops, ApplyOp{
@@ -200,6 +241,10 @@ func (a *Applier) ApplyManifest(m *manifest.Manifest, projectDir string, opts ta
 				sourcePath = filepath.Join(projectDir, sourcePath)
 			}
 		}
+		instOpts := opts
+		if inst.Content == "" {
+			instOpts = linkFallback(opts, tempFile != "" || a.isEphemeralSource(sourcePath, projectDir), KindInstruction, inst.Name, res)
+		}
 
 		for _, t := range targets {
 			// If ApplyTo is set, only install to matching target
@@ -207,7 +252,10 @@ func (a *Applier) ApplyManifest(m *manifest.Manifest, projectDir string, opts ta
 				continue
 			}
 
-			if err := t.InstallInstruction(inst.Name, inst.Content, sourcePath, projectDir, opts); err != nil {
+			dest := target.InstructionPath(t, projectDir, inst.Name)
+			if err := trackInstall(state, projectDir, t.Name(), KindInstruction, inst.Name, dest, func() error {
+				return t.InstallInstruction(inst.Name, inst.Content, sourcePath, projectDir, instOpts)
+			}); err != nil {
 				errMsg := fmt.Sprintf("install instruction %s -> %s: %v", inst.Name, t.Name(), err)
 				res.Errors = append(res.Errors, errMsg)
 				res.Ops = append(res.Ops, ApplyOp{
@@ -271,9 +319,13 @@ func (a *Applier) ApplyManifest(m *manifest.Manifest, projectDir string, opts ta
 			tempFile = tmp
 			sourcePath = tempFile
 		}
+		agentOpts := linkFallback(opts, tempFile != "" || a.isEphemeralSource(sourcePath, projectDir), KindAgent, agent.Name, res)
 
 		for _, t := range targets {
-			if err := t.InstallAgent(agent.Name, sourcePath, projectDir, opts); err != nil {
+			dest := target.AgentPath(t, projectDir, agent.Name)
+			if err := trackInstall(state, projectDir, t.Name(), KindAgent, agent.Name, dest, func() error {
+				return t.InstallAgent(agent.Name, sourcePath, projectDir, agentOpts)
+			}); err != nil {
 				errMsg := fmt.Sprintf("install agent %s -> %s: %v", agent.Name, t.Name(), err)
 				res.Errors = append(res.Errors, errMsg)
 				res.Ops = append(res.Ops, ApplyOp{
@@ -300,9 +352,55 @@ func (a *Applier) ApplyManifest(m *manifest.Manifest, projectDir string, opts ta
 		}
 	}
 
+	if err := SaveInstallState(projectDir, state); err != nil {
+		return res, err
+	}
+
 	return res, nil
 }
 
+// trackInstall runs install and records the files it left at dest, plus any
+// parent directories it had to create, in state.
+func trackInstall(state *InstallState, projectDir, targetName string, kind ApplyOpKind, name, dest string, install func() error) error {
+	created := missingDirs(projectDir, filepath.Dir(dest))
+	if err := install(); err != nil {
+		return err
+	}
+	rec, err := recordInstall(projectDir, targetName, kind, name, dest)
+	if err != nil {
+		return fmt.Errorf("record install: %w", err)
+	}
+	state.Put(rec)
+	state.AddCreatedDirs(created)
+	return nil
+}
+
+// isEphemeralSource reports whether source lives in a temporary directory
+// or the registry cache outside the project, such as the scratch files and
+// clones registries fetch into. Sources inside the project are never treated
+// as ephemeral.
+func (a *Applier) isEphemeralSource(source, projectDir string) bool {
+	if source == "" || target.PathWithin(projectDir, source) {
+		return false
+	}
+	if a.CacheRoot != "" && target.PathWithin(a.CacheRoot, source) {
+		return true
+	}
+	return target.PathWithin(os.TempDir(), source)
+}
+
+// linkFallback disables link mode for sources without a stable location on
+// disk (temporary fetches, registry caches, embedded files), which would
+// leave dangling or impossible symlinks, and records a warning on res.
+func linkFallback(opts target.InstallOpts, unlinkable bool, kind ApplyOpKind, name string, res *ApplyResult) target.InstallOpts {
+	if !opts.Link || !unlinkable {
+		return opts
+	}
+	res.Warnings = append(res.Warnings, fmt.Sprintf("%s %s: source is temporary, cached or embedded, copying instead of linking", kind, name))
+	opts.Link = false
+	return opts
+}
+
 func writeTempResourceFile(projectDir, pattern string, data []byte) (string, error) {
 	tmp, err := os.CreateTemp(projectDir, pattern)
 	if err != nil {
@@ -320,14 +418,31 @@ func writeTempResourceFile(projectDir, pattern string, data []byte) (string, err
 	return tmp.Name(), nil
 }
 
-func (a *Applier) fetchSkillFromRegistry(regName, skillName string) (*schema.Skill, string, error) {
+func (a *Applier) fetchSkillFromRegistry(regName, skillName string) (*schema.Skill, string, fs.FS, error) {
 	for _, r := range a.Registries {
 		if r.Name() != regName {
 			continue
 		}
-		return r.Fetch(skillName)
+		return fetchSkill(r, skillName)
+	}
+	return nil, "", nil, fmt.Errorf("registry %q not found", regName)
+}
+
+// fetchSkill fetches a skill from r. Registries that serve skills from an
+// fs.FS return no source dir, so their skill directory is returned as an FS.
+func fetchSkill(r registry.SkillSource, name string) (*schema.Skill, string, fs.FS, error) {
+	sk, dir, err := r.Fetch(name)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	if fr, ok := r.(registry.FSSource); ok && dir == "" {
+		fsys, err := fr.SkillFS(name)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		return sk, "", fsys, nil
 	}
-	return nil, "", fmt.Errorf("registry %q not found", regName)
+	return sk, dir, nil, nil
 }
 
 // fetchResourceFileFromRegistry looks up a registry by name, asserts it
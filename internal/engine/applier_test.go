@@ -1,11 +1,13 @@
 package engine
 
 import (
+	"errors"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
 	"testing"
+	"testing/fstest"
 
 	"github.com/chaz8081/positive-vibes/internal/manifest"
 	"github.com/chaz8081/positive-vibes/internal/registry"
@@ -1044,3 +1046,186 @@ agents:
 		t.Fatalf("expected KindAgent for my-agent, got %q", kindMap["my-agent"])
 	}
 }
+
+func TestApplierApply_LinkLocalResourcesRelative(t *testing.T) {
+	tmp := t.TempDir()
+	agentSrc := filepath.Join(tmp, "agents", "reviewer.md")
+	instSrc := filepath.Join(tmp, "instructions", "guide.md")
+	for path, data := range map[string]string{agentSrc: "# Reviewer", instSrc: "Guide"} {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+
+	m := &manifest.Manifest{
+		Instructions: []manifest.InstructionRef{{Name: "guide", Path: "./instructions/guide.md"}},
+		Agents:       []manifest.AgentRef{{Name: "reviewer", Path: "./agents/reviewer.md"}},
+		Targets:      []string{"opencode"},
+	}
+
+	a := NewApplier(nil)
+	res, err := a.ApplyManifest(m, tmp, target.InstallOpts{Link: true})
+	if err != nil {
+		t.Fatalf("apply manifest error: %v", err)
+	}
+	if len(res.Errors) > 0 || len(res.Warnings) > 0 {
+		t.Fatalf("unexpected errors %v / warnings %v", res.Errors, res.Warnings)
+	}
+
+	for dest, want := range map[string]string{
+		filepath.Join(tmp, ".opencode", "agents", "reviewer.md"):    filepath.Join("..", "..", "agents", "reviewer.md"),
+		filepath.Join(tmp, ".opencode", "instructions", "guide.md"): filepath.Join("..", "..", "instructions", "guide.md"),
+	} {
+		link, err := os.Readlink(dest)
+		if err != nil {
+			t.Fatalf("expected symlink at %s: %v", dest, err)
+		}
+		if link != want {
+			t.Fatalf("link target mismatch for %s: got %q, want %q", dest, link, want)
+		}
+	}
+}
+
+func TestApplierApply_LinkFallsBackToCopyForRegistrySources(t *testing.T) {
+	repoDir := setupTestGitRepoWithFiles(t, ".", map[string]string{
+		"agents/reviewer.agent.md": "# Registry Reviewer",
+	})
+	gitReg := &registry.GitRegistry{
+		RegistryName: "test-remote",
+		URL:          repoDir,
+		CachePath:    filepath.Join(t.TempDir(), "test-remote"),
+		SkillsPath:   ".",
+	}
+
+	tmp := t.TempDir()
+	m := &manifest.Manifest{
+		Skills:  []manifest.SkillRef{{Name: "conventional-commits"}},
+		Agents:  []manifest.AgentRef{{Name: "reviewer", Registry: "test-remote", Path: "agents/reviewer.agent.md"}},
+		Targets: []string{"opencode"},
+	}
+
+	a := NewApplier([]registry.SkillSource{registry.NewEmbeddedRegistry(), gitReg})
+	res, err := a.ApplyManifest(m, tmp, target.InstallOpts{Link: true})
+	if err != nil {
+		t.Fatalf("apply manifest error: %v", err)
+	}
+	if len(res.Errors) > 0 {
+		t.Fatalf("unexpected errors: %v", res.Errors)
+	}
+	if len(res.Warnings) != 2 {
+		t.Fatalf("expected a fallback warning per temporary source, got %v", res.Warnings)
+	}
+
+	agentDest := filepath.Join(tmp, ".opencode", "agents", "reviewer.md")
+	fi, err := os.Lstat(agentDest)
+	if err != nil {
+		t.Fatalf("agent not installed: %v", err)
+	}
+	if !fi.Mode().IsRegular() {
+		t.Fatalf("expected registry agent to be copied, got mode %v", fi.Mode())
+	}
+	skillDest := filepath.Join(tmp, ".opencode", "skills", "conventional-commits")
+	fi, err = os.Lstat(skillDest)
+	if err != nil {
+		t.Fatalf("skill not installed: %v", err)
+	}
+	if fi.Mode()&os.ModeSymlink != 0 {
+		t.Fatalf("expected embedded skill to be copied, not linked")
+	}
+}
+
+func TestApplierApply_LinkFallsBackToCopyForCachedRegistrySkills(t *testing.T) {
+	repoDir := setupTestGitRepoWithFiles(t, ".", map[string]string{
+		"my-skill/SKILL.md": "---\nname: my-skill\n---\n# My Skill\n",
+	})
+	// The cache lives outside the temporary directory, as it does under the
+	// user's home.
+	cacheRoot := t.TempDir()
+	t.Setenv("TMPDIR", t.TempDir())
+	gitReg := &registry.GitRegistry{
+		RegistryName: "test-remote",
+		URL:          repoDir,
+		CachePath:    filepath.Join(cacheRoot, "test-remote"),
+		SkillsPath:   ".",
+	}
+
+	tmp := t.TempDir()
+	m := &manifest.Manifest{
+		Skills:  []manifest.SkillRef{{Name: "my-skill", Registry: "test-remote", Path: "my-skill"}},
+		Targets: []string{"opencode"},
+	}
+
+	a := NewApplier([]registry.SkillSource{gitReg})
+	a.CacheRoot = cacheRoot
+	res, err := a.ApplyManifest(m, tmp, target.InstallOpts{Link: true})
+	if err != nil {
+		t.Fatalf("apply manifest error: %v", err)
+	}
+	if len(res.Errors) > 0 {
+		t.Fatalf("unexpected errors: %v", res.Errors)
+	}
+	if len(res.Warnings) != 1 {
+		t.Fatalf("expected a fallback warning for the cached skill, got %v", res.Warnings)
+	}
+	fi, err := os.Lstat(filepath.Join(tmp, ".opencode", "skills", "my-skill"))
+	if err != nil {
+		t.Fatalf("skill not installed: %v", err)
+	}
+	if fi.Mode()&os.ModeSymlink != 0 {
+		t.Fatalf("expected cached skill to be copied, not linked into the cache")
+	}
+}
+
+func TestApplierApply_EmbeddedSkillCopiesSupportFiles(t *testing.T) {
+	emb := &registry.EmbeddedRegistry{
+		RegistryName: "embedded",
+		FS: fstest.MapFS{
+			"demo/SKILL.md":       {Data: []byte("---\nname: demo\ndescription: demo skill\n---\n# Demo\n")},
+			"demo/scripts/run.sh": {Data: []byte("echo hi\n")},
+		},
+	}
+
+	tmp := t.TempDir()
+	m := &manifest.Manifest{
+		Skills:  []manifest.SkillRef{{Name: "demo"}},
+		Targets: []string{"cursor"},
+	}
+
+	a := NewApplier([]registry.SkillSource{emb})
+	res, err := a.ApplyManifest(m, tmp, target.InstallOpts{})
+	if err != nil {
+		t.Fatalf("apply manifest error: %v", err)
+	}
+	if len(res.Errors) > 0 {
+		t.Fatalf("unexpected errors: %v", res.Errors)
+	}
+
+	data, err := os.ReadFile(filepath.Join(tmp, ".cursor", "skills", "demo", "scripts", "run.sh"))
+	if err != nil {
+		t.Fatalf("expected embedded support file to be installed: %v", err)
+	}
+	if string(data) != "echo hi\n" {
+		t.Fatalf("unexpected script content %q", data)
+	}
+}
+
+func TestApplierApplyManifest_EnforcesPolicy(t *testing.T) {
+	tmp := t.TempDir()
+	m := &manifest.Manifest{
+		Policy:       &manifest.Policy{DenyInlineInstructions: true},
+		Instructions: []manifest.InstructionRef{{Name: "style", Content: "Use tabs."}},
+		Targets:      []string{"opencode"},
+	}
+
+	_, err := NewApplier(nil).ApplyManifest(m, tmp, target.InstallOpts{})
+	var pe *manifest.PolicyError
+	if !errors.As(err, &pe) {
+		t.Fatalf("expected policy error, got %v", err)
+	}
+	if _, statErr := os.Stat(filepath.Join(tmp, ".opencode")); !os.IsNotExist(statErr) {
+		t.Fatalf("expected nothing installed, stat err %v", statErr)
+	}
+}
@@ -0,0 +1,266 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/chaz8081/positive-vibes/internal/target"
+)
+
+// OpRemoved marks a resource that was (or, in a dry run, would be) uninstalled.
+const OpRemoved ApplyOpStatus = "removed"
+
+// CleanOpts selects which recorded installs Clean removes.
+// Empty Targets or Kinds match everything.
+type CleanOpts struct {
+	Targets []string
+	Kinds   []ApplyOpKind
+	DryRun  bool
+}
+
+func (o CleanOpts) matches(rec InstallRecord) bool {
+	if len(o.Targets) > 0 && !containsString(o.Targets, rec.Target) {
+		return false
+	}
+	if len(o.Kinds) > 0 {
+		found := false
+		for _, k := range o.Kinds {
+			if k == rec.Kind {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// CleanResult summarizes a clean run.
+type CleanResult struct {
+	Removed  int
+	Skipped  int
+	Errors   []string
+	Warnings []string
+	Ops      []ApplyOp
+	// Paths lists the project-relative files, links and directories that were
+	// (or would be) deleted.
+	Paths []string
+}
+
+// Clean uninstalls everything positive-vibes recorded installing into
+// projectDir that matches opts. Only recorded files are touched: a file whose
+// content (or link target) changed since it was installed is left alone and
+// reported as skipped, and stays recorded along with any file that could not
+// be removed. Directories are only removed once empty, and only if
+// positive-vibes created them.
+func Clean(projectDir string, opts CleanOpts) (*CleanResult, error) {
+	state, err := LoadInstallState(projectDir)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &CleanResult{}
+	var kept []InstallRecord
+	for _, rec := range state.Records {
+		if !opts.matches(rec) {
+			kept = append(kept, rec)
+			continue
+		}
+		if left := cleanRecord(projectDir, rec, opts.DryRun, res); len(left) > 0 {
+			rec.Files = left
+			kept = append(kept, rec)
+		}
+	}
+
+	if opts.DryRun {
+		return res, nil
+	}
+
+	state.Records = kept
+	state.CreatedDirs = removeEmptyDirs(projectDir, state.CreatedDirs, res)
+	if err := SaveInstallState(projectDir, state); err != nil {
+		return res, err
+	}
+	return res, nil
+}
+
+// Uninstall removes one resource from every target it was installed to: each
+// recorded install of kind/name. Only recorded files are deleted, and
+// recorded files modified since install are kept, as with Clean. A file at
+// the conventional location in a target with no record (hand-written, or
+// installed before install state was tracked) is left in place and reported
+// as OpSkipped. Targets where nothing is installed are reported as
+// OpNotFound.
+func Uninstall(projectDir string, targets []target.Target, kind ApplyOpKind, name string) (*CleanResult, error) {
+	state, err := LoadInstallState(projectDir)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &CleanResult{}
+	handled := map[string]bool{}
+	var kept []InstallRecord
+	for _, rec := range state.Records {
+		if rec.Kind != kind || rec.Name != name {
+			kept = append(kept, rec)
+			continue
+		}
+		handled[rec.Target] = true
+		if left := cleanRecord(projectDir, rec, false, res); len(left) > 0 {
+			rec.Files = left
+			kept = append(kept, rec)
+		}
+	}
+
+	for _, t := range targets {
+		if handled[t.Name()] {
+			continue
+		}
+		handled[t.Name()] = true
+		skipUntracked(projectDir, t, kind, name, res)
+	}
+
+	state.Records = kept
+	state.CreatedDirs = removeEmptyDirs(projectDir, state.CreatedDirs, res)
+	if err := SaveInstallState(projectDir, state); err != nil {
+		return res, err
+	}
+	return res, nil
+}
+
+// skipUntracked reports what sits at the conventional install location of a
+// resource that has no install record for t, without touching it.
+func skipUntracked(projectDir string, t target.Target, kind ApplyOpKind, name string, res *CleanResult) {
+	var dest string
+	switch kind {
+	case KindInstruction:
+		dest = target.InstructionPath(t, projectDir, name)
+	case KindAgent:
+		dest = target.AgentPath(t, projectDir, name)
+	default:
+		dest = target.SkillPath(t, projectDir, name)
+	}
+
+	op := ApplyOp{SkillName: name, TargetName: t.Name(), Kind: kind, Status: OpNotFound, Error: "not installed"}
+	if _, err := os.Lstat(dest); err == nil {
+		op.Status, op.Error = OpSkipped, "not installed by positive-vibes"
+		rel := dest
+		if r, err := filepath.Rel(projectDir, dest); err == nil {
+			rel = filepath.ToSlash(r)
+		}
+		res.Warnings = append(res.Warnings, fmt.Sprintf("%s %s -> %s: left untracked %s in place", kind, name, t.Name(), rel))
+		res.Skipped++
+	}
+	res.Ops = append(res.Ops, op)
+}
+
+// cleanRecord removes the files recorded for one install and the directories
+// the install itself created inside its destination. It returns the recorded
+// files left on disk (modified, or failed to remove), whose record must be
+// kept so a later clean still knows about them.
+func cleanRecord(projectDir string, rec InstallRecord, dryRun bool, res *CleanResult) []InstalledFile {
+	op := ApplyOp{SkillName: rec.Name, TargetName: rec.Target, Kind: rec.Kind, Status: OpRemoved}
+
+	var modified []string
+	var left []InstalledFile
+	for _, f := range rec.Files {
+		ok, err := f.unchanged(projectDir)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue // already gone
+			}
+			msg := fmt.Sprintf("clean %s %s -> %s: %v", rec.Kind, rec.Name, rec.Target, err)
+			res.Errors = append(res.Errors, msg)
+			op.Status, op.Error = OpError, msg
+			left = append(left, f)
+			continue
+		}
+		if !ok {
+			modified = append(modified, f.Path)
+			left = append(left, f)
+			continue
+		}
+		res.Paths = append(res.Paths, f.Path)
+		if dryRun {
+			continue
+		}
+		if err := os.Remove(filepath.Join(projectDir, filepath.FromSlash(f.Path))); err != nil && !os.IsNotExist(err) {
+			msg := fmt.Sprintf("clean %s %s -> %s: %v", rec.Kind, rec.Name, rec.Target, err)
+			res.Errors = append(res.Errors, msg)
+			op.Status, op.Error = OpError, msg
+			left = append(left, f)
+		}
+	}
+
+	if len(modified) > 0 {
+		res.Warnings = append(res.Warnings, fmt.Sprintf("%s %s -> %s: left modified files in place: %s",
+			rec.Kind, rec.Name, rec.Target, strings.Join(modified, ", ")))
+		if op.Status == OpRemoved {
+			op.Status = OpSkipped
+			op.Error = "modified since install"
+		}
+	}
+
+	// Directories inside a skill destination were created by the install.
+	if !dryRun {
+		var dirs []string
+		root := filepath.Join(projectDir, filepath.FromSlash(rec.Path))
+		_ = filepath.WalkDir(root, func(p string, d os.DirEntry, err error) error {
+			if err == nil && d.IsDir() {
+				if rel, relErr := filepath.Rel(projectDir, p); relErr == nil {
+					dirs = append(dirs, filepath.ToSlash(rel))
+				}
+			}
+			return nil
+		})
+		removeEmptyDirs(projectDir, dirs, res)
+	}
+
+	switch op.Status {
+	case OpRemoved:
+		res.Removed++
+	case OpSkipped:
+		res.Skipped++
+	}
+	res.Ops = append(res.Ops, op)
+	return left
+}
+
+// removeEmptyDirs deletes each of dirs (project-relative) that is empty,
+// deepest first, and returns the ones that still exist afterwards.
+func removeEmptyDirs(projectDir string, dirs []string, res *CleanResult) []string {
+	sorted := append([]string(nil), dirs...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return strings.Count(sorted[i], "/") > strings.Count(sorted[j], "/")
+	})
+
+	var remaining []string
+	for _, d := range sorted {
+		p := filepath.Join(projectDir, filepath.FromSlash(d))
+		entries, err := os.ReadDir(p)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			remaining = append(remaining, d)
+			continue
+		}
+		if len(entries) > 0 {
+			remaining = append(remaining, d)
+			continue
+		}
+		if err := os.Remove(p); err != nil {
+			remaining = append(remaining, d)
+			continue
+		}
+		res.Paths = append(res.Paths, d+"/")
+	}
+	sort.Strings(remaining)
+	return remaining
+}
@@ -0,0 +1,257 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/chaz8081/positive-vibes/internal/manifest"
+	"github.com/chaz8081/positive-vibes/internal/registry"
+	"github.com/chaz8081/positive-vibes/internal/target"
+)
+
+// applyForClean installs one skill, one instruction and one agent into
+// opencode and cursor, returning the project dir.
+func applyForClean(t *testing.T, opts target.InstallOpts) string {
+	t.Helper()
+	tmp := t.TempDir()
+	agentSrc := filepath.Join(tmp, "agents", "reviewer.md")
+	if err := os.MkdirAll(filepath.Dir(agentSrc), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(agentSrc, []byte("# Reviewer"), 0o644); err != nil {
+		t.Fatalf("write agent: %v", err)
+	}
+
+	m := &manifest.Manifest{
+		Skills:       []manifest.SkillRef{{Name: "conventional-commits"}},
+		Instructions: []manifest.InstructionRef{{Name: "style", Content: "Be nice."}},
+		Agents:       []manifest.AgentRef{{Name: "reviewer", Path: "./agents/reviewer.md"}},
+		Targets:      []string{"opencode", "cursor"},
+	}
+	a := NewApplier([]registry.SkillSource{registry.NewEmbeddedRegistry()})
+	res, err := a.ApplyManifest(m, tmp, opts)
+	if err != nil {
+		t.Fatalf("apply manifest error: %v", err)
+	}
+	if len(res.Errors) > 0 {
+		t.Fatalf("unexpected errors: %v", res.Errors)
+	}
+	return tmp
+}
+
+func TestApplier_RecordsInstallState(t *testing.T) {
+	tmp := applyForClean(t, target.InstallOpts{})
+
+	state, err := LoadInstallState(tmp)
+	if err != nil {
+		t.Fatalf("load state: %v", err)
+	}
+	if len(state.Records) != 6 {
+		t.Fatalf("expected 6 records (3 resources x 2 targets), got %d", len(state.Records))
+	}
+	rec, ok := state.Find("cursor", KindSkill, "conventional-commits")
+	if !ok {
+		t.Fatalf("missing cursor skill record")
+	}
+	if rec.Path != ".cursor/skills/conventional-commits" {
+		t.Fatalf("unexpected record path %q", rec.Path)
+	}
+	if len(rec.Files) == 0 || rec.Files[0].SHA256 == "" {
+		t.Fatalf("expected hashed files in record, got %+v", rec.Files)
+	}
+	if !containsString(state.CreatedDirs, ".cursor") || !containsString(state.CreatedDirs, ".cursor/skills") {
+		t.Fatalf("expected created dirs to be recorded, got %v", state.CreatedDirs)
+	}
+}
+
+func TestClean_RemovesEverythingAndCreatedDirs(t *testing.T) {
+	tmp := applyForClean(t, target.InstallOpts{Link: true})
+
+	res, err := Clean(tmp, CleanOpts{})
+	if err != nil {
+		t.Fatalf("clean error: %v", err)
+	}
+	if res.Removed != 6 || len(res.Errors) > 0 {
+		t.Fatalf("expected 6 removed and no errors, got %d / %v", res.Removed, res.Errors)
+	}
+	for _, dir := range []string{".opencode", ".cursor", StateDir} {
+		if _, err := os.Stat(filepath.Join(tmp, dir)); !os.IsNotExist(err) {
+			t.Fatalf("expected %s to be removed, stat err: %v", dir, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(tmp, "agents", "reviewer.md")); err != nil {
+		t.Fatalf("link source must survive clean: %v", err)
+	}
+}
+
+func TestClean_NeverTouchesUserFiles(t *testing.T) {
+	tmp := applyForClean(t, target.InstallOpts{})
+
+	userFile := filepath.Join(tmp, ".cursor", "agents", "mine.md")
+	if err := os.WriteFile(userFile, []byte("my own agent"), 0o644); err != nil {
+		t.Fatalf("write user file: %v", err)
+	}
+	edited := filepath.Join(tmp, ".opencode", "instructions", "style.md")
+	if err := os.WriteFile(edited, []byte("edited by hand"), 0o644); err != nil {
+		t.Fatalf("edit installed file: %v", err)
+	}
+
+	res, err := Clean(tmp, CleanOpts{})
+	if err != nil {
+		t.Fatalf("clean error: %v", err)
+	}
+	if res.Skipped != 1 {
+		t.Fatalf("expected the edited instruction to be skipped, got %+v", res.Ops)
+	}
+	for _, p := range []string{userFile, edited} {
+		if _, err := os.Stat(p); err != nil {
+			t.Fatalf("expected %s to be kept: %v", p, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(tmp, ".cursor", "skills")); !os.IsNotExist(err) {
+		t.Fatalf("expected empty .cursor/skills to be removed")
+	}
+
+	// The edited file is still on disk, so its record is kept.
+	state, err := LoadInstallState(tmp)
+	if err != nil {
+		t.Fatalf("load state: %v", err)
+	}
+	if len(state.Records) != 1 || state.Records[0].Name != "style" || len(state.Records[0].Files) != 1 {
+		t.Fatalf("expected only the edited instruction to stay recorded, got %+v", state.Records)
+	}
+}
+
+func TestClean_FiltersAndDryRun(t *testing.T) {
+	tmp := applyForClean(t, target.InstallOpts{})
+
+	dry, err := Clean(tmp, CleanOpts{Targets: []string{"cursor"}, Kinds: []ApplyOpKind{KindAgent}, DryRun: true})
+	if err != nil {
+		t.Fatalf("clean error: %v", err)
+	}
+	if dry.Removed != 1 || len(dry.Paths) != 1 || dry.Paths[0] != ".cursor/agents/reviewer.md" {
+		t.Fatalf("unexpected dry run result: %+v", dry)
+	}
+	if _, err := os.Stat(filepath.Join(tmp, ".cursor", "agents", "reviewer.md")); err != nil {
+		t.Fatalf("dry run must not delete: %v", err)
+	}
+
+	if _, err := Clean(tmp, CleanOpts{Targets: []string{"cursor"}, Kinds: []ApplyOpKind{KindAgent}}); err != nil {
+		t.Fatalf("clean error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmp, ".cursor", "agents")); !os.IsNotExist(err) {
+		t.Fatalf("expected cursor agents dir to be removed")
+	}
+	if _, err := os.Stat(filepath.Join(tmp, ".opencode", "agents", "reviewer.md")); err != nil {
+		t.Fatalf("opencode agent must be kept: %v", err)
+	}
+
+	state, err := LoadInstallState(tmp)
+	if err != nil {
+		t.Fatalf("load state: %v", err)
+	}
+	if len(state.Records) != 5 {
+		t.Fatalf("expected 5 remaining records, got %d", len(state.Records))
+	}
+}
+
+func TestUninstall_RemovesFromEveryTarget(t *testing.T) {
+	tmp := applyForClean(t, target.InstallOpts{})
+
+	// A file nothing recorded installing is never deleted.
+	copilot := &target.CopilotTarget{}
+	untracked := target.AgentPath(copilot, tmp, "reviewer")
+	if err := os.MkdirAll(filepath.Dir(untracked), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(untracked, []byte("# Reviewer"), 0o644); err != nil {
+		t.Fatalf("write untracked agent: %v", err)
+	}
+
+	targets := []target.Target{&target.OpenCodeTarget{}, &target.CursorTarget{}, copilot}
+	res, err := Uninstall(tmp, targets, KindAgent, "reviewer")
+	if err != nil {
+		t.Fatalf("uninstall error: %v", err)
+	}
+	if res.Removed != 2 || res.Skipped != 1 || len(res.Ops) != 3 {
+		t.Fatalf("expected 2 removals and 1 skip, got %+v", res.Ops)
+	}
+	if op := res.Ops[2]; op.TargetName != copilot.Name() || op.Status != OpSkipped {
+		t.Fatalf("expected the untracked copilot agent to be skipped, got %+v", op)
+	}
+	for _, p := range []string{
+		target.AgentPath(&target.OpenCodeTarget{}, tmp, "reviewer"),
+		target.AgentPath(&target.CursorTarget{}, tmp, "reviewer"),
+	} {
+		if _, err := os.Lstat(p); !os.IsNotExist(err) {
+			t.Fatalf("expected %s to be removed", p)
+		}
+	}
+	if _, err := os.Stat(untracked); err != nil {
+		t.Fatalf("untracked file must be kept: %v", err)
+	}
+
+	state, err := LoadInstallState(tmp)
+	if err != nil {
+		t.Fatalf("load state: %v", err)
+	}
+	if len(state.Records) != 4 {
+		t.Fatalf("expected 4 remaining records, got %d", len(state.Records))
+	}
+	if _, err := os.Stat(target.SkillPath(&target.CursorTarget{}, tmp, "conventional-commits")); err != nil {
+		t.Fatalf("other resources must be kept: %v", err)
+	}
+}
+
+func TestUninstall_KeepsRecordsOfFilesLeftInPlace(t *testing.T) {
+	tmp := applyForClean(t, target.InstallOpts{})
+
+	// One copy is edited by hand, the other replaced by something that
+	// cannot be checked; neither is removed, so both stay recorded.
+	edited := target.AgentPath(&target.OpenCodeTarget{}, tmp, "reviewer")
+	if err := os.WriteFile(edited, []byte("edited by hand"), 0o644); err != nil {
+		t.Fatalf("edit installed file: %v", err)
+	}
+	broken := target.AgentPath(&target.CursorTarget{}, tmp, "reviewer")
+	if err := os.Remove(broken); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	if err := os.Mkdir(broken, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+
+	targets := []target.Target{&target.OpenCodeTarget{}, &target.CursorTarget{}}
+	res, err := Uninstall(tmp, targets, KindAgent, "reviewer")
+	if err != nil {
+		t.Fatalf("uninstall error: %v", err)
+	}
+	if res.Removed != 0 || res.Skipped != 1 || len(res.Errors) != 1 {
+		t.Fatalf("expected 1 skip and 1 error, got %+v", res)
+	}
+
+	state, err := LoadInstallState(tmp)
+	if err != nil {
+		t.Fatalf("load state: %v", err)
+	}
+	var agents int
+	for _, rec := range state.Records {
+		if rec.Kind == KindAgent && rec.Name == "reviewer" {
+			agents++
+		}
+	}
+	if agents != 2 {
+		t.Fatalf("expected both reviewer records to be kept, got %+v", state.Records)
+	}
+}
+
+func TestUninstall_ReportsNotFound(t *testing.T) {
+	tmp := t.TempDir()
+	res, err := Uninstall(tmp, []target.Target{&target.CursorTarget{}}, KindSkill, "missing")
+	if err != nil {
+		t.Fatalf("uninstall error: %v", err)
+	}
+	if len(res.Ops) != 1 || res.Ops[0].Status != OpNotFound || res.Removed != 0 {
+		t.Fatalf("expected a single not-found op, got %+v", res.Ops)
+	}
+}
@@ -0,0 +1,134 @@
+package engine
+
+import (
+	"fmt"
+
+	"github.com/chaz8081/positive-vibes/internal/manifest"
+)
+
+// ResourceSelector names one manifest resource, e.g. {KindAgent, "reviewer"}.
+type ResourceSelector struct {
+	Kind ApplyOpKind
+	Name string
+}
+
+func (s ResourceSelector) String() string {
+	return fmt.Sprintf("%s:%s", s.Kind, s.Name)
+}
+
+// ApplyFilter narrows an apply to part of the manifest. Each non-empty field
+// restricts the selection further; the zero value selects everything.
+type ApplyFilter struct {
+	Only    []ResourceSelector
+	Kinds   []ApplyOpKind
+	Targets []string
+	// Lenient ignores selected resources and targets the manifest does not
+	// declare instead of failing, for applying one selection across several
+	// manifests.
+	Lenient bool
+}
+
+// IsZero reports whether f selects the whole manifest.
+func (f ApplyFilter) IsZero() bool {
+	return len(f.Only) == 0 && len(f.Kinds) == 0 && len(f.Targets) == 0
+}
+
+func (f ApplyFilter) selects(kind ApplyOpKind, name string) bool {
+	if len(f.Kinds) > 0 {
+		found := false
+		for _, k := range f.Kinds {
+			if k == kind {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(f.Only) > 0 {
+		for _, s := range f.Only {
+			if s.Kind == kind && s.Name == name {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// Select returns a copy of m holding only the resources and targets f
+// selects. Unless f is Lenient, it is an error to select a resource or
+// target m does not declare.
+func (f ApplyFilter) Select(m *manifest.Manifest) (*manifest.Manifest, error) {
+	if f.IsZero() {
+		return m, nil
+	}
+
+	out := *m
+	out.Skills, out.Instructions, out.Agents, out.Targets = nil, nil, nil, nil
+	declared := make(map[ResourceSelector]bool)
+
+	for _, s := range m.Skills {
+		declared[ResourceSelector{KindSkill, s.Name}] = true
+		if f.selects(KindSkill, s.Name) {
+			out.Skills = append(out.Skills, s)
+		}
+	}
+	for _, inst := range m.Instructions {
+		declared[ResourceSelector{KindInstruction, inst.Name}] = true
+		if f.selects(KindInstruction, inst.Name) {
+			out.Instructions = append(out.Instructions, inst)
+		}
+	}
+	for _, a := range m.Agents {
+		declared[ResourceSelector{KindAgent, a.Name}] = true
+		if f.selects(KindAgent, a.Name) {
+			out.Agents = append(out.Agents, a)
+		}
+	}
+	for _, s := range f.Only {
+		if !declared[s] && !f.Lenient {
+			return nil, fmt.Errorf("%s is not in the manifest", s)
+		}
+	}
+
+	if len(f.Targets) == 0 {
+		out.Targets = m.Targets
+	} else {
+		for _, t := range f.Targets {
+			if !containsString(m.Targets, t) {
+				if f.Lenient {
+					continue
+				}
+				return nil, fmt.Errorf("target %q is not configured in the manifest", t)
+			}
+			out.Targets = append(out.Targets, t)
+		}
+	}
+	return &out, nil
+}
+
+// NeededRegistries returns the names of registries that applying m may read
+// from. all is true when some skill has no registry or path and may
+// therefore be looked up in any registry.
+func NeededRegistries(m *manifest.Manifest) (names []string, all bool) {
+	add := func(name string) {
+		if name != "" && !containsString(names, name) {
+			names = append(names, name)
+		}
+	}
+	for _, s := range m.Skills {
+		if s.Registry == "" && s.Path == "" {
+			all = true
+		}
+		add(s.Registry)
+	}
+	for _, inst := range m.Instructions {
+		add(inst.Registry)
+	}
+	for _, a := range m.Agents {
+		add(a.Registry)
+	}
+	return names, all
+}
@@ -0,0 +1,151 @@
+package engine
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/chaz8081/positive-vibes/internal/manifest"
+	"github.com/chaz8081/positive-vibes/internal/registry"
+	"github.com/chaz8081/positive-vibes/internal/target"
+	"github.com/chaz8081/positive-vibes/pkg/schema"
+)
+
+// countingRegistry records every access so tests can assert it was never used.
+type countingRegistry struct {
+	name  string
+	calls int
+}
+
+func (r *countingRegistry) Name() string { return r.name }
+
+func (r *countingRegistry) Fetch(name string) (*schema.Skill, string, error) {
+	r.calls++
+	return nil, "", fmt.Errorf("skill not found: %s", name)
+}
+
+func (r *countingRegistry) List() ([]string, error) {
+	r.calls++
+	return nil, nil
+}
+
+func (r *countingRegistry) FetchResourceFile(kind, relPath string) ([]byte, error) {
+	r.calls++
+	return []byte("# from " + r.name), nil
+}
+
+func (r *countingRegistry) ListResourceFiles(kind string) ([]string, error) {
+	r.calls++
+	return nil, nil
+}
+
+func filterTestManifest() *manifest.Manifest {
+	return &manifest.Manifest{
+		Skills:       []manifest.SkillRef{{Name: "conventional-commits"}},
+		Instructions: []manifest.InstructionRef{{Name: "style", Content: "Be nice."}},
+		Agents: []manifest.AgentRef{
+			{Name: "reviewer", Registry: "team", Path: "agents/reviewer.md"},
+			{Name: "planner", Registry: "other", Path: "agents/planner.md"},
+		},
+		Targets: []string{"opencode", "cursor"},
+	}
+}
+
+func TestApplierApplyManifest_FilterOnlySkipsUnselectedRegistries(t *testing.T) {
+	tmp := t.TempDir()
+	team := &countingRegistry{name: "team"}
+	other := &countingRegistry{name: "other"}
+	a := NewApplier([]registry.SkillSource{registry.NewEmbeddedRegistry(), team, other})
+	a.Filter = ApplyFilter{
+		Only:    []ResourceSelector{{Kind: KindAgent, Name: "reviewer"}},
+		Targets: []string{"cursor"},
+	}
+
+	res, err := a.ApplyManifest(filterTestManifest(), tmp, target.InstallOpts{})
+	if err != nil {
+		t.Fatalf("apply manifest error: %v", err)
+	}
+	if res.Installed != 1 || len(res.Ops) != 1 {
+		t.Fatalf("expected exactly one install, got %+v", res.Ops)
+	}
+	if op := res.Ops[0]; op.SkillName != "reviewer" || op.TargetName != "cursor" {
+		t.Fatalf("unexpected op %+v", op)
+	}
+	if team.calls != 1 || other.calls != 0 {
+		t.Fatalf("expected only the team registry to be read, got team=%d other=%d", team.calls, other.calls)
+	}
+	if _, err := os.Stat(filepath.Join(tmp, ".opencode")); !os.IsNotExist(err) {
+		t.Fatalf("unselected target must not be written")
+	}
+}
+
+func TestApplierApplyManifest_FilterByKind(t *testing.T) {
+	tmp := t.TempDir()
+	a := NewApplier([]registry.SkillSource{registry.NewEmbeddedRegistry()})
+	a.Filter = ApplyFilter{Kinds: []ApplyOpKind{KindInstruction}}
+
+	res, err := a.ApplyManifest(filterTestManifest(), tmp, target.InstallOpts{})
+	if err != nil {
+		t.Fatalf("apply manifest error: %v", err)
+	}
+	if res.Installed != 2 || len(res.Errors) > 0 {
+		t.Fatalf("expected the instruction in both targets, got %+v", res.Ops)
+	}
+	for _, op := range res.Ops {
+		if op.Kind != KindInstruction {
+			t.Fatalf("unexpected op kind %+v", op)
+		}
+	}
+}
+
+func TestApplyFilterSelect_RejectsUnknownSelections(t *testing.T) {
+	m := filterTestManifest()
+	if _, err := (ApplyFilter{Only: []ResourceSelector{{Kind: KindSkill, Name: "nope"}}}).Select(m); err == nil {
+		t.Fatalf("expected error for unknown resource")
+	}
+	if _, err := (ApplyFilter{Targets: []string{"vscode-copilot"}}).Select(m); err == nil {
+		t.Fatalf("expected error for unconfigured target")
+	}
+}
+
+func TestNeededRegistries(t *testing.T) {
+	names, all := NeededRegistries(filterTestManifest())
+	if !all {
+		t.Fatalf("expected unqualified skill to need all registries")
+	}
+	if len(names) != 2 || names[0] != "team" || names[1] != "other" {
+		t.Fatalf("unexpected registries %v", names)
+	}
+
+	m, err := (ApplyFilter{Kinds: []ApplyOpKind{KindAgent}, Only: []ResourceSelector{{Kind: KindAgent, Name: "planner"}}}).Select(filterTestManifest())
+	if err != nil {
+		t.Fatalf("select error: %v", err)
+	}
+	names, all = NeededRegistries(m)
+	if all || len(names) != 1 || names[0] != "other" {
+		t.Fatalf("expected only the other registry, got %v (all=%v)", names, all)
+	}
+
+	a := NewApplier(nil)
+	a.Filter = ApplyFilter{Kinds: []ApplyOpKind{KindAgent}, Only: []ResourceSelector{{Kind: KindAgent, Name: "planner"}}}
+	names, all, err = a.NeededRegistries(filterTestManifest())
+	if err != nil || all || len(names) != 1 || names[0] != "other" {
+		t.Fatalf("expected the applier's filter to be applied, got %v (all=%v, err=%v)", names, all, err)
+	}
+}
+
+func TestApplyFilterSelect_LenientIgnoresUnknownSelections(t *testing.T) {
+	f := ApplyFilter{
+		Only:    []ResourceSelector{{Kind: KindSkill, Name: "nope"}, {Kind: KindAgent, Name: "reviewer"}},
+		Targets: []string{"vscode-copilot", "cursor"},
+		Lenient: true,
+	}
+	m, err := f.Select(filterTestManifest())
+	if err != nil {
+		t.Fatalf("select error: %v", err)
+	}
+	if len(m.Skills) != 0 || len(m.Agents) != 1 || len(m.Targets) != 1 || m.Targets[0] != "cursor" {
+		t.Fatalf("unexpected selection %+v", m)
+	}
+}
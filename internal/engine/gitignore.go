@@ -0,0 +1,232 @@
+package engine
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/chaz8081/positive-vibes/internal/manifest"
+)
+
+// Markers delimiting the block positive-vibes maintains in ignore files.
+const (
+	ignoreBlockBegin = "# BEGIN positive-vibes generated files (managed; do not edit)"
+	ignoreBlockEnd   = "# END positive-vibes generated files"
+)
+
+// blockMarkers returns the markers of the managed block for the project at
+// scope, its slash-separated path from the repository root. Projects below
+// the root (workspace packages) each keep their own block in
+// .git/info/exclude, which the whole repository shares.
+func blockMarkers(scope string) (begin, end string) {
+	if scope == "" {
+		return ignoreBlockBegin, ignoreBlockEnd
+	}
+	return "# BEGIN positive-vibes generated files in /" + scope + " (managed; do not edit)",
+		"# END positive-vibes generated files in /" + scope
+}
+
+// SyncGitIgnore rewrites the managed ignore blocks for projectDir from its
+// install state.
+//
+// With mode manifest.GeneratedIgnore, shared resources are listed in the
+// project's .gitignore. With manifest.GeneratedIgnore or
+// manifest.GeneratedCommit, personal resources (those for which personal
+// returns true) are listed in .git/info/exclude so they never reach the
+// repository. Each project keeps its own block there, so the packages of a
+// workspace do not overwrite each other. The personal manifest
+// (manifest.PersonalManifestFilename) is always excluded while it exists. With an empty mode, or once nothing is
+// installed, other managed entries are removed. Content outside the blocks is
+// never touched.
+func SyncGitIgnore(projectDir, mode string, personal func(InstallRecord) bool) error {
+	state, err := LoadInstallState(projectDir)
+	if err != nil {
+		return err
+	}
+
+	var shared, private []InstallRecord
+	if mode == manifest.GeneratedCommit || mode == manifest.GeneratedIgnore {
+		for _, rec := range state.Records {
+			switch {
+			case personal != nil && personal(rec):
+				private = append(private, rec)
+			case mode == manifest.GeneratedIgnore:
+				shared = append(shared, rec)
+			}
+		}
+	}
+
+	if err := writeManagedBlock(filepath.Join(projectDir, ".gitignore"), "", ignoreLines(shared, "")); err != nil {
+		return err
+	}
+
+	repoRoot, gitDir, ok := findGitDir(projectDir)
+	if !ok {
+		return nil // not a git checkout; nothing can be committed anyway
+	}
+	absProject, err := filepath.Abs(projectDir)
+	if err != nil {
+		return err
+	}
+	prefix, err := filepath.Rel(repoRoot, absProject)
+	if err != nil {
+		return err
+	}
+	if prefix == "." {
+		prefix = ""
+	}
+	excludes := ignoreLines(private, filepath.ToSlash(prefix))
+	if _, err := os.Stat(manifest.PersonalManifestPath(projectDir)); err == nil {
+		p := "/" + manifest.PersonalManifestFilename
+		if prefix != "" {
+			p = "/" + filepath.ToSlash(prefix) + p
+		}
+		excludes = append([]string{"# personal config", p}, excludes...)
+	}
+	return writeManagedBlock(filepath.Join(gitDir, "info", "exclude"), filepath.ToSlash(prefix), excludes)
+}
+
+// ignoreLines renders records as anchored ignore patterns grouped by target.
+// prefix is the project's path relative to the directory the patterns are
+// evaluated from.
+func ignoreLines(records []InstallRecord, prefix string) []string {
+	byTarget := map[string][]string{}
+	for _, rec := range records {
+		p := "/" + rec.Path
+		if prefix != "" {
+			p = "/" + prefix + p
+		}
+		if rec.Kind == KindSkill {
+			p += "/"
+		}
+		byTarget[rec.Target] = append(byTarget[rec.Target], p)
+	}
+
+	names := make([]string, 0, len(byTarget))
+	for name := range byTarget {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var lines []string
+	for _, name := range names {
+		paths := byTarget[name]
+		sort.Strings(paths)
+		lines = append(lines, "# "+name)
+		lines = append(lines, paths...)
+	}
+	return lines
+}
+
+// writeManagedBlock replaces the managed block for scope (see blockMarkers)
+// in the ignore file at path with lines, keeping its position, or appends it
+// if absent. Empty lines remove the block; a file left empty by that is
+// deleted. Other blocks are left alone.
+func writeManagedBlock(path, scope string, lines []string) error {
+	blockBegin, blockEnd := blockMarkers(scope)
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("read %s: %w", path, err)
+	}
+	existed := err == nil
+
+	var before, after []string
+	found := false
+	if existed {
+		all := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+		begin, end := -1, -1
+		for i, l := range all {
+			if l == blockBegin && begin < 0 {
+				begin = i
+			} else if l == blockEnd && begin >= 0 {
+				end = i
+				break
+			}
+		}
+		if begin >= 0 && end >= 0 {
+			found = true
+			before, after = all[:begin], all[end+1:]
+		} else {
+			before = all
+		}
+	}
+
+	if !found && len(lines) == 0 {
+		return nil
+	}
+
+	var out []string
+	out = append(out, before...)
+	if len(lines) > 0 {
+		if !found && len(out) > 0 && strings.TrimSpace(out[len(out)-1]) != "" {
+			out = append(out, "")
+		}
+		out = append(out, blockBegin)
+		out = append(out, lines...)
+		out = append(out, blockEnd)
+	} else if len(after) > 0 && len(out) > 0 && strings.TrimSpace(out[len(out)-1]) == "" {
+		out = out[:len(out)-1] // drop the separator left before the block
+	}
+	out = append(out, after...)
+
+	content := strings.TrimRight(strings.Join(out, "\n"), "\n")
+	if content == "" {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("remove %s: %w", path, err)
+		}
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create %s: %w", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, []byte(content+"\n"), 0o644); err != nil {
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	return nil
+}
+
+// findGitDir walks up from dir to the enclosing git checkout, returning its
+// root and the git directory holding info/exclude. Worktree-style ".git"
+// files are followed.
+func findGitDir(dir string) (root, gitDir string, ok bool) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", "", false
+	}
+	for {
+		p := filepath.Join(dir, ".git")
+		if fi, err := os.Stat(p); err == nil {
+			if fi.IsDir() {
+				return dir, p, true
+			}
+			data, err := os.ReadFile(p)
+			if err != nil {
+				return "", "", false
+			}
+			target, found := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir:")
+			if !found {
+				return "", "", false
+			}
+			target = strings.TrimSpace(target)
+			if !filepath.IsAbs(target) {
+				target = filepath.Join(dir, target)
+			}
+			// Linked worktrees share info/exclude with the main checkout.
+			if common, err := os.ReadFile(filepath.Join(target, "commondir")); err == nil {
+				c := strings.TrimSpace(string(common))
+				if !filepath.IsAbs(c) {
+					c = filepath.Join(target, c)
+				}
+				target = c
+			}
+			return dir, target, true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", "", false
+		}
+		dir = parent
+	}
+}
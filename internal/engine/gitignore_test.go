@@ -0,0 +1,197 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/chaz8081/positive-vibes/internal/manifest"
+	"github.com/chaz8081/positive-vibes/internal/target"
+)
+
+func readFileString(t *testing.T, p string) string {
+	t.Helper()
+	data, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatalf("read %s: %v", p, err)
+	}
+	return string(data)
+}
+
+func TestSyncGitIgnore_IgnoreModeWritesManagedBlocks(t *testing.T) {
+	tmp := applyForClean(t, target.InstallOpts{})
+	if err := os.MkdirAll(filepath.Join(tmp, ".git", "info"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmp, ".gitignore"), []byte("node_modules/\n"), 0o644); err != nil {
+		t.Fatalf("write gitignore: %v", err)
+	}
+
+	personal := func(rec InstallRecord) bool { return rec.Kind == KindAgent }
+	if err := SyncGitIgnore(tmp, manifest.GeneratedIgnore, personal); err != nil {
+		t.Fatalf("sync error: %v", err)
+	}
+
+	ignore := readFileString(t, filepath.Join(tmp, ".gitignore"))
+	if !strings.HasPrefix(ignore, "node_modules/\n\n"+ignoreBlockBegin) {
+		t.Fatalf("expected user entries to be kept before the block, got:\n%s", ignore)
+	}
+	for _, want := range []string{"# cursor", "/.cursor/skills/conventional-commits/", "/.opencode/instructions/style.md"} {
+		if !strings.Contains(ignore, want) {
+			t.Fatalf("expected %q in .gitignore, got:\n%s", want, ignore)
+		}
+	}
+	if strings.Contains(ignore, "reviewer") {
+		t.Fatalf("personal agents must not be in .gitignore:\n%s", ignore)
+	}
+
+	exclude := readFileString(t, filepath.Join(tmp, ".git", "info", "exclude"))
+	if !strings.Contains(exclude, "/.cursor/agents/reviewer.md") || !strings.Contains(exclude, "/.opencode/agents/reviewer.md") {
+		t.Fatalf("expected personal agents in exclude, got:\n%s", exclude)
+	}
+
+	// Syncing again is idempotent.
+	if err := SyncGitIgnore(tmp, manifest.GeneratedIgnore, personal); err != nil {
+		t.Fatalf("sync error: %v", err)
+	}
+	if again := readFileString(t, filepath.Join(tmp, ".gitignore")); again != ignore {
+		t.Fatalf("expected idempotent sync, got:\n%s", again)
+	}
+}
+
+func TestSyncGitIgnore_CommitModeOnlyExcludesPersonal(t *testing.T) {
+	tmp := applyForClean(t, target.InstallOpts{})
+	if err := os.MkdirAll(filepath.Join(tmp, ".git"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+
+	personal := func(rec InstallRecord) bool { return rec.Name == "style" }
+	if err := SyncGitIgnore(tmp, manifest.GeneratedCommit, personal); err != nil {
+		t.Fatalf("sync error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmp, ".gitignore")); !os.IsNotExist(err) {
+		t.Fatalf("commit mode must not create .gitignore")
+	}
+	exclude := readFileString(t, filepath.Join(tmp, ".git", "info", "exclude"))
+	if !strings.Contains(exclude, "/.cursor/instructions/style.md") || strings.Contains(exclude, "skills") {
+		t.Fatalf("unexpected exclude contents:\n%s", exclude)
+	}
+}
+
+func TestSyncGitIgnore_RemovesBlocksAfterClean(t *testing.T) {
+	tmp := applyForClean(t, target.InstallOpts{})
+	if err := os.WriteFile(filepath.Join(tmp, ".gitignore"), []byte("dist/\n"), 0o644); err != nil {
+		t.Fatalf("write gitignore: %v", err)
+	}
+	if err := SyncGitIgnore(tmp, manifest.GeneratedIgnore, nil); err != nil {
+		t.Fatalf("sync error: %v", err)
+	}
+
+	if _, err := Clean(tmp, CleanOpts{}); err != nil {
+		t.Fatalf("clean error: %v", err)
+	}
+	if err := SyncGitIgnore(tmp, manifest.GeneratedIgnore, nil); err != nil {
+		t.Fatalf("sync error: %v", err)
+	}
+	if got := readFileString(t, filepath.Join(tmp, ".gitignore")); got != "dist/\n" {
+		t.Fatalf("expected only user entries to remain, got:\n%q", got)
+	}
+}
+
+func TestSyncGitIgnore_ProjectInRepoSubdirectory(t *testing.T) {
+	repo := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(repo, ".git"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	project := filepath.Join(repo, "services", "api")
+	state := &InstallState{Records: []InstallRecord{{Target: "cursor", Kind: KindSkill, Name: "tdd", Path: ".cursor/skills/tdd"}}}
+	if err := SaveInstallState(project, state); err != nil {
+		t.Fatalf("save state: %v", err)
+	}
+
+	if err := SyncGitIgnore(project, manifest.GeneratedCommit, func(InstallRecord) bool { return true }); err != nil {
+		t.Fatalf("sync error: %v", err)
+	}
+	exclude := readFileString(t, filepath.Join(repo, ".git", "info", "exclude"))
+	if !strings.Contains(exclude, "/services/api/.cursor/skills/tdd/") {
+		t.Fatalf("expected repo-relative pattern, got:\n%s", exclude)
+	}
+}
+
+func TestSyncGitIgnore_ExcludesPersonalManifest(t *testing.T) {
+	repo := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(repo, ".git"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(manifest.PersonalManifestPath(repo), []byte("skills: []\n"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	if err := SyncGitIgnore(repo, "", nil); err != nil {
+		t.Fatalf("sync error: %v", err)
+	}
+	exclude := readFileString(t, filepath.Join(repo, ".git", "info", "exclude"))
+	if !strings.Contains(exclude, "\n/vibes.local.yaml\n") {
+		t.Fatalf("expected personal manifest to be excluded, got:\n%s", exclude)
+	}
+
+	if err := os.Remove(manifest.PersonalManifestPath(repo)); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	if err := SyncGitIgnore(repo, "", nil); err != nil {
+		t.Fatalf("sync error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(repo, ".git", "info", "exclude")); !os.IsNotExist(err) {
+		t.Fatalf("expected exclude file to be removed, got err %v", err)
+	}
+}
+
+func TestSyncGitIgnore_WorkspacePackagesKeepTheirOwnExcludes(t *testing.T) {
+	repo := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(repo, ".git"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	for _, pkg := range []string{"a", "b"} {
+		project := filepath.Join(repo, pkg)
+		state := &InstallState{Records: []InstallRecord{{Target: "cursor", Kind: KindSkill, Name: "tdd", Path: ".cursor/skills/tdd"}}}
+		if err := SaveInstallState(project, state); err != nil {
+			t.Fatalf("save state: %v", err)
+		}
+		if err := os.WriteFile(manifest.PersonalManifestPath(project), []byte("skills: []\n"), 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+
+	sync := func() string {
+		t.Helper()
+		for _, pkg := range []string{"a", "b"} {
+			if err := SyncGitIgnore(filepath.Join(repo, pkg), manifest.GeneratedCommit, func(InstallRecord) bool { return true }); err != nil {
+				t.Fatalf("sync %s: %v", pkg, err)
+			}
+		}
+		return readFileString(t, filepath.Join(repo, ".git", "info", "exclude"))
+	}
+
+	exclude := sync()
+	for _, want := range []string{"/a/vibes.local.yaml", "/a/.cursor/skills/tdd/", "/b/vibes.local.yaml", "/b/.cursor/skills/tdd/"} {
+		if !strings.Contains(exclude, want) {
+			t.Fatalf("expected %q in exclude, got:\n%s", want, exclude)
+		}
+	}
+	if again := sync(); again != exclude {
+		t.Fatalf("expected idempotent sync, got:\n%s", again)
+	}
+
+	// Cleaning one package leaves the other's entries in place.
+	if err := os.Remove(manifest.PersonalManifestPath(filepath.Join(repo, "a"))); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	if err := SaveInstallState(filepath.Join(repo, "a"), &InstallState{}); err != nil {
+		t.Fatalf("save state: %v", err)
+	}
+	exclude = sync()
+	if strings.Contains(exclude, "/a/") || !strings.Contains(exclude, "/b/.cursor/skills/tdd/") {
+		t.Fatalf("expected only b's entries, got:\n%s", exclude)
+	}
+}
@@ -58,8 +58,7 @@ func (i *Installer) Install(skillName string, manifestPath string) error {
 			Name: skillName,
 			Path: "./skills/" + skillName,
 		}
-		m.Skills = append(m.Skills, ref)
-		if err := manifest.SaveManifest(m, manifestPath); err != nil {
+		if err := addSkillEntry(manifestPath, ref); err != nil {
 			return fmt.Errorf("save manifest: %w", err)
 		}
 		return nil
@@ -77,8 +76,7 @@ func (i *Installer) Install(skillName string, manifestPath string) error {
 		return fmt.Errorf("skill not found: %s", skillName)
 	}
 
-	m.Skills = append(m.Skills, manifest.SkillRef{Name: skillName})
-	if err := manifest.SaveManifest(m, manifestPath); err != nil {
+	if err := addSkillEntry(manifestPath, manifest.SkillRef{Name: skillName}); err != nil {
 		return fmt.Errorf("save manifest: %w", err)
 	}
 	return nil
@@ -92,20 +90,31 @@ func (i *Installer) Remove(skillName string, manifestPath string) error {
 		return fmt.Errorf("load manifest: %w", err)
 	}
 
-	found := -1
-	for idx, s := range m.Skills {
+	found := false
+	for _, s := range m.Skills {
 		if s.Name == skillName {
-			found = idx
+			found = true
 			break
 		}
 	}
-	if found < 0 {
+	if !found {
 		return fmt.Errorf("skill not found in manifest: %s", skillName)
 	}
 
-	m.Skills = append(m.Skills[:found], m.Skills[found+1:]...)
-	if err := manifest.SaveManifest(m, manifestPath); err != nil {
+	err = manifest.EditManifest(manifestPath, func(d *manifest.Document) error {
+		d.Remove("skills", skillName)
+		return nil
+	})
+	if err != nil {
 		return fmt.Errorf("save manifest: %w", err)
 	}
 	return nil
 }
+
+// addSkillEntry appends ref to the manifest file, keeping its comments and
+// layout.
+func addSkillEntry(manifestPath string, ref manifest.SkillRef) error {
+	return manifest.EditManifest(manifestPath, func(d *manifest.Document) error {
+		return d.Add("skills", ref)
+	})
+}
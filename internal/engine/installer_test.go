@@ -339,3 +339,43 @@ skills:
 		t.Fatalf("expected 0 skills remaining, got %d", len(m.Skills))
 	}
 }
+
+func TestInstaller_KeepsManifestComments(t *testing.T) {
+	tmp := t.TempDir()
+	mfile := filepath.Join(tmp, "vibes.yaml")
+	content := `# Team manifest -- keep this header.
+
+targets: ["opencode"] # only opencode for now
+
+# Skills we rely on
+skills:
+  - name: conventional-commits
+`
+	if err := os.WriteFile(mfile, []byte(content), 0o644); err != nil {
+		t.Fatalf("write manifest: %v", err)
+	}
+
+	inst := NewInstaller([]registry.SkillSource{registry.NewEmbeddedRegistry()})
+	if err := inst.Install("code-review", mfile); err != nil {
+		t.Fatalf("install error: %v", err)
+	}
+	if err := inst.Remove("conventional-commits", mfile); err != nil {
+		t.Fatalf("remove error: %v", err)
+	}
+
+	data, err := os.ReadFile(mfile)
+	if err != nil {
+		t.Fatalf("read manifest: %v", err)
+	}
+	want := `# Team manifest -- keep this header.
+
+targets: ["opencode"] # only opencode for now
+
+# Skills we rely on
+skills:
+  - name: code-review
+`
+	if string(data) != want {
+		t.Fatalf("manifest after install/remove:\n%s\nwant:\n%s", data, want)
+	}
+}
@@ -0,0 +1,122 @@
+package engine
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// SearchDoc is a resource offered by a registry, as seen by Search.
+type SearchDoc struct {
+	Kind        string // "skills", "instructions" or "agents"
+	Name        string
+	Registry    string
+	Description string
+	Tags        []string
+	Body        string
+}
+
+// SearchResult is a document matching a query, with its relevance score.
+type SearchResult struct {
+	SearchDoc
+	Score float64
+}
+
+// BM25 parameters, and how much a term counts in each field relative to
+// the body.
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+
+	nameWeight        = 3
+	tagWeight         = 2
+	descriptionWeight = 2
+	bodyWeight        = 1
+)
+
+// Search ranks docs against query with BM25, counting matches in the name,
+// tags and description more than matches in the body. Documents matching no
+// query term are left out. Results are ordered by score, then kind and name.
+func Search(docs []SearchDoc, query string) []SearchResult {
+	terms := dedupTerms(tokenize(query))
+	if len(terms) == 0 || len(docs) == 0 {
+		return nil
+	}
+
+	freqs := make([]map[string]float64, len(docs))
+	lengths := make([]float64, len(docs))
+	docFreq := make(map[string]int)
+	var total float64
+	for i, d := range docs {
+		tf := make(map[string]float64)
+		add := func(text string, weight float64) {
+			for _, tok := range tokenize(text) {
+				tf[tok] += weight
+				lengths[i] += weight
+			}
+		}
+		add(d.Name, nameWeight)
+		add(strings.Join(d.Tags, " "), tagWeight)
+		add(d.Description, descriptionWeight)
+		add(d.Body, bodyWeight)
+		for tok := range tf {
+			docFreq[tok]++
+		}
+		freqs[i] = tf
+		total += lengths[i]
+	}
+	avgLen := total / float64(len(docs))
+	if avgLen == 0 {
+		avgLen = 1
+	}
+
+	n := float64(len(docs))
+	var results []SearchResult
+	for i, d := range docs {
+		var score float64
+		for _, term := range terms {
+			tf := freqs[i][term]
+			if tf == 0 {
+				continue
+			}
+			df := float64(docFreq[term])
+			idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+			score += idf * tf * (bm25K1 + 1) / (tf + bm25K1*(1-bm25B+bm25B*lengths[i]/avgLen))
+		}
+		if score > 0 {
+			results = append(results, SearchResult{SearchDoc: d, Score: score})
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.Name < b.Name
+	})
+	return results
+}
+
+// tokenize lowercases text and splits it into runs of letters and digits,
+// so "code-review" and "code_review" both yield "code" and "review".
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func dedupTerms(terms []string) []string {
+	seen := make(map[string]bool, len(terms))
+	out := terms[:0]
+	for _, t := range terms {
+		if !seen[t] {
+			seen[t] = true
+			out = append(out, t)
+		}
+	}
+	return out
+}
@@ -0,0 +1,59 @@
+package engine
+
+import (
+	"testing"
+)
+
+func searchNames(results []SearchResult) []string {
+	names := make([]string, len(results))
+	for i, r := range results {
+		names[i] = r.Kind + "/" + r.Name
+	}
+	return names
+}
+
+func TestSearch_RanksNameAndTagMatchesAboveBody(t *testing.T) {
+	docs := []SearchDoc{
+		{Kind: "skills", Name: "commit-helper", Description: "Writes messages", Body: "Mentions testing once in passing."},
+		{Kind: "skills", Name: "tdd", Description: "Test-driven development", Tags: []string{"testing"}, Body: "Write the test first."},
+		{Kind: "agents", Name: "testing-coach", Description: "Reviews your tests"},
+		{Kind: "instructions", Name: "go-style", Description: "Go formatting rules", Body: "Run gofmt."},
+	}
+
+	results := Search(docs, "testing")
+	got := searchNames(results)
+	if len(got) != 3 {
+		t.Fatalf("expected 3 matches, got %v", got)
+	}
+	if got[2] != "skills/commit-helper" {
+		t.Fatalf("expected the body-only match last, got %v", got)
+	}
+	for i := 1; i < len(results); i++ {
+		if results[i].Score > results[i-1].Score {
+			t.Fatalf("results not sorted by score: %v", got)
+		}
+	}
+}
+
+func TestSearch_MultipleTermsAndTokenizing(t *testing.T) {
+	docs := []SearchDoc{
+		{Kind: "skills", Name: "code-review", Description: "Review pull requests"},
+		{Kind: "skills", Name: "code-style", Description: "Formatting"},
+		{Kind: "skills", Name: "deploy", Description: "Ship it"},
+	}
+
+	got := searchNames(Search(docs, "Code Review"))
+	if len(got) != 2 || got[0] != "skills/code-review" {
+		t.Fatalf("expected code-review first, got %v", got)
+	}
+}
+
+func TestSearch_NoMatchesOrEmptyQuery(t *testing.T) {
+	docs := []SearchDoc{{Kind: "skills", Name: "tdd"}}
+	if got := Search(docs, "kubernetes"); len(got) != 0 {
+		t.Fatalf("expected no matches, got %v", searchNames(got))
+	}
+	if got := Search(docs, "  --  "); got != nil {
+		t.Fatalf("expected nil for an empty query, got %v", searchNames(got))
+	}
+}
@@ -0,0 +1,238 @@
+package engine
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// StateDir is the project-relative directory holding positive-vibes
+// bookkeeping files.
+const StateDir = ".positive-vibes"
+
+// StateFilename is the name of the install state file inside StateDir.
+const StateFilename = "state.json"
+
+// InstallState records every file and symlink positive-vibes has installed
+// into a project, so they can later be removed without guessing.
+type InstallState struct {
+	Records []InstallRecord `json:"records"`
+	// CreatedDirs lists project-relative directories that did not exist
+	// before positive-vibes installed into them (e.g. ".cursor/skills").
+	CreatedDirs []string `json:"created_dirs,omitempty"`
+}
+
+// InstallRecord describes one resource installed to one target.
+type InstallRecord struct {
+	Target string          `json:"target"`
+	Kind   ApplyOpKind     `json:"kind"`
+	Name   string          `json:"name"`
+	Path   string          `json:"path"` // resource destination, relative to the project
+	Files  []InstalledFile `json:"files"`
+}
+
+// InstalledFile is a single file or symlink written by an install.
+// Exactly one of SHA256 (regular file) or Link (symlink target) is set.
+type InstalledFile struct {
+	Path   string `json:"path"` // relative to the project
+	SHA256 string `json:"sha256,omitempty"`
+	Link   string `json:"link,omitempty"`
+}
+
+// StatePath returns the location of the install state file for projectDir.
+func StatePath(projectDir string) string {
+	return filepath.Join(projectDir, StateDir, StateFilename)
+}
+
+// LoadInstallState reads the install state for projectDir. A missing state
+// file yields an empty state.
+func LoadInstallState(projectDir string) (*InstallState, error) {
+	data, err := os.ReadFile(StatePath(projectDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &InstallState{}, nil
+		}
+		return nil, fmt.Errorf("read install state: %w", err)
+	}
+	var s InstallState
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("parse install state: %w", err)
+	}
+	return &s, nil
+}
+
+// SaveInstallState writes s for projectDir. An empty state removes the state
+// file (and StateDir, if nothing else is in it).
+func SaveInstallState(projectDir string, s *InstallState) error {
+	p := StatePath(projectDir)
+	if len(s.Records) == 0 && len(s.CreatedDirs) == 0 {
+		if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("remove install state: %w", err)
+		}
+		_ = os.Remove(filepath.Dir(p)) // only succeeds when empty
+		return nil
+	}
+
+	sort.Slice(s.Records, func(i, j int) bool {
+		a, b := s.Records[i], s.Records[j]
+		if a.Target != b.Target {
+			return a.Target < b.Target
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.Name < b.Name
+	})
+	sort.Strings(s.CreatedDirs)
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal install state: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return fmt.Errorf("create state dir: %w", err)
+	}
+	if err := os.WriteFile(p, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("write install state: %w", err)
+	}
+	return nil
+}
+
+// Put adds rec, replacing any existing record for the same target, kind and name.
+func (s *InstallState) Put(rec InstallRecord) {
+	for i, r := range s.Records {
+		if r.Target == rec.Target && r.Kind == rec.Kind && r.Name == rec.Name {
+			s.Records[i] = rec
+			return
+		}
+	}
+	s.Records = append(s.Records, rec)
+}
+
+// Find returns the record for target, kind and name, if any.
+func (s *InstallState) Find(targetName string, kind ApplyOpKind, name string) (InstallRecord, bool) {
+	for _, r := range s.Records {
+		if r.Target == targetName && r.Kind == kind && r.Name == name {
+			return r, true
+		}
+	}
+	return InstallRecord{}, false
+}
+
+// Delete drops the record for target, kind and name.
+func (s *InstallState) Delete(targetName string, kind ApplyOpKind, name string) {
+	out := s.Records[:0]
+	for _, r := range s.Records {
+		if r.Target == targetName && r.Kind == kind && r.Name == name {
+			continue
+		}
+		out = append(out, r)
+	}
+	s.Records = out
+}
+
+// AddCreatedDirs records project-relative directories created by an install.
+func (s *InstallState) AddCreatedDirs(dirs []string) {
+	for _, d := range dirs {
+		if !containsString(s.CreatedDirs, d) {
+			s.CreatedDirs = append(s.CreatedDirs, d)
+		}
+	}
+}
+
+// missingDirs returns the directories between projectDir (exclusive) and dir
+// (inclusive) that do not exist yet, relative to projectDir. Call it before
+// installing to learn which directories the install will create.
+func missingDirs(projectDir, dir string) []string {
+	var out []string
+	for {
+		rel, err := filepath.Rel(projectDir, dir)
+		if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return out
+		}
+		if _, err := os.Lstat(dir); err == nil {
+			return out
+		}
+		out = append(out, filepath.ToSlash(rel))
+		dir = filepath.Dir(dir)
+	}
+}
+
+// recordInstall describes what now exists at dest after installing a resource.
+func recordInstall(projectDir, targetName string, kind ApplyOpKind, name, dest string) (InstallRecord, error) {
+	rec := InstallRecord{Target: targetName, Kind: kind, Name: name}
+	rel, err := filepath.Rel(projectDir, dest)
+	if err != nil {
+		return rec, err
+	}
+	rec.Path = filepath.ToSlash(rel)
+
+	err = filepath.WalkDir(dest, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		f, err := describeFile(projectDir, p)
+		if err != nil {
+			return err
+		}
+		rec.Files = append(rec.Files, f)
+		return nil
+	})
+	return rec, err
+}
+
+func describeFile(projectDir, p string) (InstalledFile, error) {
+	rel, err := filepath.Rel(projectDir, p)
+	if err != nil {
+		return InstalledFile{}, err
+	}
+	f := InstalledFile{Path: filepath.ToSlash(rel)}
+	fi, err := os.Lstat(p)
+	if err != nil {
+		return f, err
+	}
+	if fi.Mode()&os.ModeSymlink != 0 {
+		f.Link, err = os.Readlink(p)
+		return f, err
+	}
+	f.SHA256, err = fileSHA256(p)
+	return f, err
+}
+
+func fileSHA256(p string) (string, error) {
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// unchanged reports whether f on disk still matches what was installed.
+// A file that no longer exists reports false with os.ErrNotExist.
+func (f InstalledFile) unchanged(projectDir string) (bool, error) {
+	p := filepath.Join(projectDir, filepath.FromSlash(f.Path))
+	cur, err := describeFile(projectDir, p)
+	if err != nil {
+		return false, err
+	}
+	return cur == f, nil
+}
+
+func containsString(items []string, v string) bool {
+	for _, it := range items {
+		if it == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,338 @@
+package manifest
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// parseDocument parses manifest YAML into a document node, for edits that
+// must keep comments and key order. Empty input yields an empty mapping.
+func parseDocument(data []byte) (*yaml.Node, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parse manifest: %w", err)
+	}
+	if doc.Kind == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+	if len(doc.Content) != 1 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("parse manifest: top level must be a mapping")
+	}
+	return &doc, nil
+}
+
+// encodeDocument renders doc with two-space indentation. yaml.v3 drops blank
+// lines, so they are put back before every line that kept its text from orig
+// (the source doc was parsed from) and had a blank line above it there. New
+// top-level sections get one too when orig separates its sections that way.
+func encodeDocument(doc *yaml.Node, orig []byte) ([]byte, error) {
+	origLines := strings.Split(string(orig), "\n")
+	root := doc.Content[0]
+	spacedSections := false
+	newKeys := make(map[string]bool)
+	for i := 0; i < len(root.Content); i += 2 {
+		k := root.Content[i]
+		if k.Line == 0 {
+			newKeys[k.Value] = true
+		} else if i > 0 && blankBefore(origLines, k.Line-1) {
+			spacedSections = true
+		}
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return nil, fmt.Errorf("encode manifest: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("encode manifest: %w", err)
+	}
+	lines := strings.Split(buf.String(), "\n")
+
+	spaced := matchedSpacing(origLines, lines)
+	if spacedSections && len(newKeys) > 0 {
+		var out yaml.Node
+		if err := yaml.Unmarshal(buf.Bytes(), &out); err != nil {
+			return nil, fmt.Errorf("encode manifest: %w", err)
+		}
+		keys := out.Content[0].Content
+		for i := 2; i < len(keys); i += 2 {
+			if newKeys[keys[i].Value] {
+				spaced[sectionStart(lines, keys[i].Line-1)] = true
+			}
+		}
+	}
+
+	var b strings.Builder
+	for i, l := range lines {
+		if spaced[i] && i > 0 && strings.TrimSpace(lines[i-1]) != "" {
+			b.WriteString("\n")
+		}
+		b.WriteString(l)
+		if i < len(lines)-1 {
+			b.WriteString("\n")
+		}
+	}
+	return []byte(b.String()), nil
+}
+
+// matchedSpacing aligns the non-blank lines of orig and out (ignoring
+// indentation) with a longest common subsequence and returns the indexes of
+// out lines whose counterpart in orig followed a blank line.
+func matchedSpacing(orig, out []string) map[int]bool {
+	type line struct {
+		idx  int
+		text string
+	}
+	nonBlank := func(ls []string) []line {
+		var r []line
+		for i, l := range ls {
+			if t := strings.TrimSpace(l); t != "" {
+				r = append(r, line{i, t})
+			}
+		}
+		return r
+	}
+	a, b := nonBlank(orig), nonBlank(out)
+
+	// lcs[i][j] is the LCS length of a[i:] and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i].text == b[j].text {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	spaced := make(map[int]bool)
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i].text == b[j].text:
+			if a[i].idx > 0 && strings.TrimSpace(orig[a[i].idx-1]) == "" {
+				spaced[b[j].idx] = true
+			}
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return spaced
+}
+
+// sectionStart returns the index of the first line of the comment block
+// directly above lines[idx], or idx if there is none.
+func sectionStart(lines []string, idx int) int {
+	for idx > 0 && strings.HasPrefix(strings.TrimSpace(lines[idx-1]), "#") {
+		idx--
+	}
+	return idx
+}
+
+// blankBefore reports whether the section starting at lines[idx] (including
+// its comment block) is preceded by a blank line.
+func blankBefore(lines []string, idx int) bool {
+	if idx >= len(lines) {
+		return false
+	}
+	start := sectionStart(lines, idx)
+	return start > 0 && strings.TrimSpace(lines[start-1]) == ""
+}
+
+// mappingValue returns the value node for key in mapping m, or nil.
+func mappingValue(m *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// setMappingValue sets key in mapping m to value, replacing an existing
+// value in place or inserting the pair at index (in pairs; clamped to the
+// end).
+func setMappingValue(m *yaml.Node, key string, value *yaml.Node, index int) {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			m.Content[i+1] = value
+			return
+		}
+	}
+	keyNode := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}
+	pos := index * 2
+	if pos < 0 || pos > len(m.Content) {
+		pos = len(m.Content)
+	}
+	m.Content = append(m.Content[:pos], append([]*yaml.Node{keyNode, value}, m.Content[pos:]...)...)
+}
+
+// Document is a manifest file opened for editing. Edits change its YAML node
+// tree directly, so comments, blank lines between sections and key order
+// survive a save; only the edited entries (and indentation, normalized to two
+// spaces) change.
+type Document struct {
+	path string
+	orig []byte
+	doc  *yaml.Node
+}
+
+// OpenDocument loads the manifest at path for editing. A missing file yields
+// an empty document that Save creates, stamped with CurrentVersion.
+func OpenDocument(path string) (*Document, error) {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("read manifest: %w", err)
+	}
+	doc, err := parseDocument(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		setMappingValue(doc.Content[0], "version", &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.Itoa(CurrentVersion)}, 0)
+	}
+	return &Document{path: path, orig: data, doc: doc}, nil
+}
+
+// EditManifest opens the manifest at path with OpenDocument, applies edit and
+// saves the result.
+func EditManifest(path string, edit func(d *Document) error) error {
+	d, err := OpenDocument(path)
+	if err != nil {
+		return err
+	}
+	if err := edit(d); err != nil {
+		return err
+	}
+	return d.Save()
+}
+
+// Add appends entry (a SkillRef, InstructionRef or AgentRef) to section
+// ("skills", "instructions" or "agents"), creating the section if needed. An
+// existing entry with the same name is replaced in place, keeping its
+// comments.
+func (d *Document) Add(section string, entry any) error {
+	var n yaml.Node
+	if err := n.Encode(entry); err != nil {
+		return fmt.Errorf("encode %s entry: %w", section, err)
+	}
+	name := mappingValue(&n, "name")
+	if name == nil {
+		return fmt.Errorf("encode %s entry: missing name", section)
+	}
+
+	root := d.doc.Content[0]
+	seq := mappingValue(root, section)
+	if seq == nil || isNull(seq) {
+		seq = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		if existing := mappingValue(root, section); existing != nil {
+			*existing = *seq
+			seq = existing
+		} else {
+			setMappingValue(root, section, seq, len(root.Content)/2)
+		}
+	}
+	if seq.Kind != yaml.SequenceNode {
+		return fmt.Errorf("%s: %s is not a list", d.path, section)
+	}
+	if len(seq.Content) == 0 {
+		seq.Style = 0 // "[]" becomes a block list
+	}
+
+	if i := entryIndex(seq, name.Value); i >= 0 {
+		keepComments(seq.Content[i], &n)
+		seq.Content[i] = &n
+		return nil
+	}
+	seq.Content = append(seq.Content, &n)
+	return nil
+}
+
+// Remove deletes the entry called name from section and reports whether it
+// was present. Comments trailing the removed entry move to the one before
+// it; a section left empty is kept as "[]".
+func (d *Document) Remove(section, name string) bool {
+	seq := mappingValue(d.doc.Content[0], section)
+	if seq == nil || seq.Kind != yaml.SequenceNode {
+		return false
+	}
+	i := entryIndex(seq, name)
+	if i < 0 {
+		return false
+	}
+	if foot := seq.Content[i].FootComment; foot != "" && i > 0 {
+		prev := seq.Content[i-1]
+		prev.FootComment = strings.TrimPrefix(prev.FootComment+"\n"+foot, "\n")
+	}
+	seq.Content = append(seq.Content[:i], seq.Content[i+1:]...)
+	if len(seq.Content) == 0 {
+		seq.Style = yaml.FlowStyle
+	}
+	return true
+}
+
+// Save writes the document back to the path it was opened from.
+func (d *Document) Save() error {
+	data, err := encodeDocument(d.doc, d.orig)
+	if err != nil {
+		return err
+	}
+	return rewriteFile(d.path, data)
+}
+
+// rewriteFile replaces the contents of a manifest file, keeping its
+// permissions.
+func rewriteFile(path string, data []byte) error {
+	mode := os.FileMode(0o644)
+	if fi, err := os.Stat(path); err == nil {
+		mode = fi.Mode().Perm()
+	}
+	if err := os.WriteFile(path, data, mode); err != nil {
+		return fmt.Errorf("write manifest: %w", err)
+	}
+	return nil
+}
+
+// keepComments copies the comments of entry old, and of its keys and values,
+// onto the matching parts of its replacement.
+func keepComments(old, repl *yaml.Node) {
+	repl.HeadComment, repl.LineComment, repl.FootComment = old.HeadComment, old.LineComment, old.FootComment
+	for i := 0; i+1 < len(repl.Content); i += 2 {
+		for j := 0; j+1 < len(old.Content); j += 2 {
+			if old.Content[j].Value != repl.Content[i].Value {
+				continue
+			}
+			for k := 0; k < 2; k++ {
+				o, r := old.Content[j+k], repl.Content[i+k]
+				r.HeadComment, r.LineComment, r.FootComment = o.HeadComment, o.LineComment, o.FootComment
+			}
+		}
+	}
+}
+
+// entryIndex returns the index of the entry called name in seq, or -1.
+func entryIndex(seq *yaml.Node, name string) int {
+	for i, item := range seq.Content {
+		if item.Kind != yaml.MappingNode {
+			continue
+		}
+		if n := mappingValue(item, "name"); n != nil && n.Value == name {
+			return i
+		}
+	}
+	return -1
+}
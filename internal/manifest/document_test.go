@@ -0,0 +1,132 @@
+package manifest
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite golden files in testdata")
+
+// TestDocument_Golden applies edits to the manifests in testdata/document and
+// compares the saved result with <case>.golden.yaml. Run with -update to
+// regenerate the golden files.
+func TestDocument_Golden(t *testing.T) {
+	cases := []struct {
+		name  string
+		input string
+		edit  func(t *testing.T, d *Document)
+	}{
+		{
+			name:  "add_skill",
+			input: "bootstrap.yaml",
+			edit: func(t *testing.T, d *Document) {
+				require.NoError(t, d.Add("skills", SkillRef{Name: "tdd", Path: "./skills/tdd"}))
+			},
+		},
+		{
+			name:  "remove_commented_instruction",
+			input: "bootstrap.yaml",
+			edit: func(t *testing.T, d *Document) {
+				assert.True(t, d.Remove("instructions", "coding-style"))
+			},
+		},
+		{
+			name:  "remove_all_skills",
+			input: "bootstrap.yaml",
+			edit: func(t *testing.T, d *Document) {
+				assert.True(t, d.Remove("skills", "conventional-commits"))
+				assert.True(t, d.Remove("skills", "code-review"))
+			},
+		},
+		{
+			name:  "add_agent_new_section",
+			input: "bootstrap.yaml",
+			edit: func(t *testing.T, d *Document) {
+				require.NoError(t, d.Add("agents", AgentRef{Name: "reviewer", Registry: "awesome-copilot", Path: "agents/reviewer.md"}))
+			},
+		},
+		{
+			name:  "replace_keeps_comment",
+			input: "bootstrap.yaml",
+			edit: func(t *testing.T, d *Document) {
+				require.NoError(t, d.Add("skills", SkillRef{Name: "conventional-commits", Version: "2.0"}))
+			},
+		},
+		{
+			name:  "remove_last_instruction",
+			input: "bootstrap.yaml",
+			edit: func(t *testing.T, d *Document) {
+				assert.True(t, d.Remove("instructions", "project-guide"))
+			},
+		},
+		{
+			name:  "remove_all_instructions",
+			input: "bootstrap.yaml",
+			edit: func(t *testing.T, d *Document) {
+				assert.True(t, d.Remove("instructions", "project-guide"))
+				assert.True(t, d.Remove("instructions", "coding-style"))
+			},
+		},
+		{
+			name:  "compact_add_and_remove",
+			input: "compact.yaml",
+			edit: func(t *testing.T, d *Document) {
+				assert.True(t, d.Remove("skills", "tdd"))
+				assert.False(t, d.Remove("skills", "missing"))
+				require.NoError(t, d.Add("instructions", InstructionRef{Name: "style", Content: "Use gofmt"}))
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			input, err := os.ReadFile(filepath.Join("testdata", "document", tc.input))
+			require.NoError(t, err)
+			path := filepath.Join(t.TempDir(), "vibes.yaml")
+			require.NoError(t, os.WriteFile(path, input, 0o644))
+
+			d, err := OpenDocument(path)
+			require.NoError(t, err)
+			tc.edit(t, d)
+			require.NoError(t, d.Save())
+
+			got, err := os.ReadFile(path)
+			require.NoError(t, err)
+			golden := filepath.Join("testdata", "document", tc.name+".golden.yaml")
+			if *updateGolden {
+				require.NoError(t, os.WriteFile(golden, got, 0o644))
+			}
+			want, err := os.ReadFile(golden)
+			require.NoError(t, err)
+			assert.Equal(t, string(want), string(got))
+
+			_, err = LoadManifestFromBytes(got)
+			assert.NoError(t, err, "edited manifest must still load")
+		})
+	}
+}
+
+func TestOpenDocument_MissingFileCreatesVersionedManifest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vibes.yaml")
+	d, err := OpenDocument(path)
+	require.NoError(t, err)
+	require.NoError(t, d.Add("skills", SkillRef{Name: "tdd"}))
+	require.NoError(t, d.Save())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "version: 2\nskills:\n  - name: tdd\n", string(data))
+}
+
+func TestDocument_AddRejectsNonListSection(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vibes.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("skills: tdd\n"), 0o644))
+	d, err := OpenDocument(path)
+	require.NoError(t, err)
+	assert.Error(t, d.Add("skills", SkillRef{Name: "x"}))
+}
@@ -0,0 +1,122 @@
+package manifest
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// LookupEnv resolves variables referenced as ${VAR} or ${VAR:-default} in
+// manifest values. The CLI may wrap it, e.g. to mask values for display.
+var LookupEnv = os.LookupEnv
+
+// varRef matches $${...} (an escaped reference), ${VAR} and ${VAR:-default}.
+var varRef = regexp.MustCompile(`\$\$\{|\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// UnsetVariable is a ${VAR} reference, without a default, to a variable that
+// is not set.
+type UnsetVariable struct {
+	Name string
+	// Path locates the value, e.g. "registries[0].url".
+	Path   string
+	Line   int
+	Column int
+}
+
+func (u UnsetVariable) Error() string {
+	return fmt.Sprintf("line %d: %s: environment variable %s is not set", u.Line, u.Path, u.Name)
+}
+
+// UnsetVariablesError reports every unset variable a manifest references.
+type UnsetVariablesError struct {
+	Vars []UnsetVariable
+}
+
+func (e *UnsetVariablesError) Error() string {
+	msgs := make([]string, len(e.Vars))
+	for i, v := range e.Vars {
+		msgs[i] = v.Error()
+	}
+	return strings.Join(msgs, "; ") + " (set it, or use ${VAR:-default})"
+}
+
+// interpolate expands variable references in s. Defaults apply when the
+// variable is unset or empty; "$${" yields a literal "${". It returns the
+// names of unset variables that have no default.
+func interpolate(s string) (string, []string) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+	var missing []string
+	out := varRef.ReplaceAllStringFunc(s, func(ref string) string {
+		if ref == "$${" {
+			return "${"
+		}
+		m := varRef.FindStringSubmatch(ref)
+		if v, ok := LookupEnv(m[1]); ok && (v != "" || m[2] == "") {
+			return v
+		}
+		if m[2] != "" {
+			return m[3]
+		}
+		missing = append(missing, m[1])
+		return ""
+	})
+	return out, missing
+}
+
+// interpolateDocument expands variable references in every string value of
+// doc in place, except inline instruction content, which is prose rather
+// than configuration. It returns the unset variables it found.
+func interpolateDocument(doc *yaml.Node) []UnsetVariable {
+	var unset []UnsetVariable
+	var walk func(n *yaml.Node, path string)
+	walk = func(n *yaml.Node, path string) {
+		switch n.Kind {
+		case yaml.DocumentNode:
+			for _, c := range n.Content {
+				walk(c, path)
+			}
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				key := n.Content[i].Value
+				if key == "content" {
+					continue
+				}
+				child := key
+				if path != "" {
+					child = path + "." + key
+				}
+				walk(n.Content[i+1], child)
+			}
+		case yaml.SequenceNode:
+			for i, c := range n.Content {
+				walk(c, fmt.Sprintf("%s[%d]", path, i))
+			}
+		case yaml.ScalarNode:
+			if n.ShortTag() != "!!str" {
+				return
+			}
+			expanded, missing := interpolate(n.Value)
+			for _, name := range missing {
+				unset = append(unset, UnsetVariable{Name: name, Path: path, Line: n.Line, Column: n.Column})
+			}
+			n.Value = expanded
+		}
+	}
+	walk(doc, "")
+	return unset
+}
+
+// UnsetVariables returns the variables referenced by manifest YAML, without
+// defaults, that are not set.
+func UnsetVariables(data []byte) ([]UnsetVariable, error) {
+	doc, err := parseDocument(data)
+	if err != nil {
+		return nil, err
+	}
+	return interpolateDocument(doc), nil
+}
@@ -0,0 +1,109 @@
+package manifest
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInterpolate(t *testing.T) {
+	t.Setenv("PV_TEST_HOST", "git.example.com")
+	t.Setenv("PV_TEST_EMPTY", "")
+
+	cases := []struct {
+		in      string
+		want    string
+		missing []string
+	}{
+		{in: "plain", want: "plain"},
+		{in: "https://${PV_TEST_HOST}/org", want: "https://git.example.com/org"},
+		{in: "${PV_TEST_HOST:-github.com}", want: "git.example.com"},
+		{in: "${PV_TEST_UNSET:-github.com}", want: "github.com"},
+		{in: "${PV_TEST_EMPTY:-fallback}", want: "fallback"},
+		{in: "${PV_TEST_EMPTY}", want: ""},
+		{in: "${PV_TEST_UNSET:-}", want: ""},
+		{in: "a${PV_TEST_UNSET}b", want: "ab", missing: []string{"PV_TEST_UNSET"}},
+		{in: "$${PV_TEST_HOST}", want: "${PV_TEST_HOST}"},
+		{in: "${not valid}", want: "${not valid}"},
+		{in: "$HOME", want: "$HOME"},
+	}
+	for _, tc := range cases {
+		got, missing := interpolate(tc.in)
+		assert.Equal(t, tc.want, got, tc.in)
+		assert.Equal(t, tc.missing, missing, tc.in)
+	}
+}
+
+func TestLoadManifestFromBytes_InterpolatesStringValues(t *testing.T) {
+	t.Setenv("PV_TEST_REF", "v2")
+
+	m, err := LoadManifestFromBytes([]byte(`registries:
+  - name: team
+    url: https://${PV_TEST_MIRROR:-github.com}/org/skills.git
+    ref: ${PV_TEST_REF}
+instructions:
+  - name: shell
+    content: "Quote ${VARS} in shell scripts"
+targets: [cursor]
+`))
+	require.NoError(t, err)
+	assert.Equal(t, "https://github.com/org/skills.git", m.Registries[0].URL)
+	assert.Equal(t, "v2", m.Registries[0].Ref)
+	assert.Equal(t, "Quote ${VARS} in shell scripts", m.Instructions[0].Content, "inline content is not interpolated")
+}
+
+func TestLoadManifestFromBytes_UnsetVariableIsError(t *testing.T) {
+	_, err := LoadManifestFromBytes([]byte("registries:\n  - name: team\n    url: ${PV_TEST_UNSET_URL}\n    ref: main\n"))
+	require.Error(t, err)
+
+	var unset *UnsetVariablesError
+	require.True(t, errors.As(err, &unset))
+	assert.Equal(t, []UnsetVariable{{Name: "PV_TEST_UNSET_URL", Path: "registries[0].url", Line: 3, Column: 10}}, unset.Vars)
+	assert.Contains(t, err.Error(), "environment variable PV_TEST_UNSET_URL is not set")
+}
+
+func TestLoadMergedManifest_InterpolatesEveryLayer(t *testing.T) {
+	t.Setenv("PV_TEST_GLOBAL_REF", "stable")
+	t.Setenv("PV_TEST_SKILL", "tdd")
+
+	dir := t.TempDir()
+	globalPath := filepath.Join(dir, "global.yaml")
+	require.NoError(t, os.WriteFile(globalPath, []byte("registries:\n  - name: team\n    url: https://example.com/team.git\n    ref: ${PV_TEST_GLOBAL_REF}\n"), 0o644))
+	project := filepath.Join(dir, "project")
+	writeManifestFile(t, project, "skills:\n  - name: ${PV_TEST_SKILL}\ntargets: [cursor]\n")
+
+	m, err := LoadMergedManifest(project, globalPath)
+	require.NoError(t, err)
+	assert.Equal(t, "stable", m.Registries[0].Ref)
+	assert.Equal(t, "tdd", m.Skills[0].Name)
+}
+
+func TestLoadManifestLayers_ReportsProjectLoadErrors(t *testing.T) {
+	project := t.TempDir()
+	writeManifestFile(t, project, "skills:\n  - name: ${PV_TEST_UNSET_SKILL}\n")
+
+	_, err := LoadManifestLayers(project, filepath.Join(project, "missing-global.yaml"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "PV_TEST_UNSET_SKILL")
+}
+
+func TestUnsetVariables(t *testing.T) {
+	t.Setenv("PV_TEST_SET", "x")
+	unset, err := UnsetVariables([]byte("skills:\n  - name: ${PV_TEST_SET}\n  - name: ${PV_TEST_A}-${PV_TEST_B:-b}\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []UnsetVariable{{Name: "PV_TEST_A", Path: "skills[1].name", Line: 3, Column: 11}}, unset)
+}
+
+func TestLookupEnv_CanBeReplaced(t *testing.T) {
+	orig := LookupEnv
+	LookupEnv = func(name string) (string, bool) { return "from-" + name, true }
+	t.Cleanup(func() { LookupEnv = orig })
+
+	m, err := LoadManifestFromBytes([]byte("skills:\n  - name: ${X}\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "from-X", m.Skills[0].Name)
+}
@@ -0,0 +1,418 @@
+package manifest
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Layer kinds, naming the top-level manifest a layer belongs to.
+const (
+	// LayerSystem is the organization-wide manifest (see SystemManifestPath).
+	LayerSystem    = "system"
+	LayerGlobal    = "global"
+	LayerWorkspace = "workspace"
+	LayerLocal     = "local"
+	// LayerPersonal is the uncommitted vibes.local.yaml next to the project
+	// manifest.
+	LayerPersonal = "personal"
+	// LayerProfile marks entries added by a profile (see ProfileLayers).
+	LayerProfile = "profile"
+)
+
+// Layer is one manifest file contributing to a merged manifest.
+type Layer struct {
+	// Source identifies the file: a filesystem path, or "registry:path" for
+	// a file inside a registry.
+	Source string
+	// Kind is the top-level manifest this layer belongs to (LayerSystem,
+	// LayerGlobal, LayerWorkspace, LayerLocal or LayerPersonal), including
+	// files it extends.
+	Kind string
+	// ExtendedBy is the Source of the manifest that pulled this layer in via
+	// extends, or empty for the top-level manifest itself.
+	ExtendedBy string
+	Manifest   *Manifest
+}
+
+// SystemConfigEnvVar overrides the location of the system manifest.
+const SystemConfigEnvVar = "PV_SYSTEM_CONFIG"
+
+// DefaultSystemManifestPath is where administrators install the
+// organization-wide manifest.
+const DefaultSystemManifestPath = "/etc/positive-vibes/vibes.yaml"
+
+// SystemManifestPath returns the system manifest path: $PV_SYSTEM_CONFIG, or
+// DefaultSystemManifestPath when it is unset.
+func SystemManifestPath() string {
+	if p := os.Getenv(SystemConfigEnvVar); p != "" {
+		return p
+	}
+	return DefaultSystemManifestPath
+}
+
+// RegistryFileFetcher reads a file, by path relative to the repository root,
+// from a registry. It resolves "registry:path" extends entries and is set by
+// the CLI; when nil, registry extends fail to load.
+var RegistryFileFetcher func(reg RegistryRef, relPath string) ([]byte, error)
+
+// LoadManifestLayers loads every manifest that applies to projectDir, lowest
+// priority first: the system manifest, the global manifest, the workspace
+// root manifest (if projectDir is a workspace package), the project manifest
+// and the personal manifest, each preceded by the manifests it extends.
+// Relative paths in each layer are resolved from the file it came from.
+// Missing manifests are skipped.
+func LoadManifestLayers(projectDir, globalPath string) ([]Layer, error) {
+	var tops []Layer
+
+	// Load system manifest (optional)
+	sys, err := LoadSystemManifest()
+	if err != nil {
+		return nil, err
+	}
+	if sys != nil {
+		tops = append(tops, Layer{Source: SystemManifestPath(), Kind: LayerSystem, Manifest: sys})
+	}
+
+	// Load global manifest (optional)
+	if data, err := os.ReadFile(globalPath); err == nil {
+		g, err := LoadManifestFromBytes(data)
+		if err != nil {
+			return nil, fmt.Errorf("parse global manifest: %w", err)
+		}
+		ResolveManifestPaths(g, filepath.Dir(globalPath))
+		tops = append(tops, Layer{Source: globalPath, Kind: LayerGlobal, Manifest: g})
+	}
+
+	// Load workspace root manifest (optional)
+	if rootDir, ok := FindWorkspaceRoot(projectDir); ok {
+		r, rPath, err := LoadManifestFromProject(rootDir)
+		if err != nil {
+			return nil, fmt.Errorf("load workspace root manifest: %w", err)
+		}
+		ResolveManifestPaths(r, filepath.Dir(rPath))
+		tops = append(tops, Layer{Source: rPath, Kind: LayerWorkspace, Manifest: r})
+	}
+
+	// Load project manifest (optional)
+	if pPath, ok := FindProjectManifest(projectDir); ok {
+		p, err := LoadManifest(pPath)
+		if err != nil {
+			return nil, fmt.Errorf("load project manifest: %w", err)
+		}
+		ResolveManifestPaths(p, filepath.Dir(pPath))
+		tops = append(tops, Layer{Source: pPath, Kind: LayerLocal, Manifest: p})
+	}
+
+	// Load personal manifest (optional)
+	lPath := PersonalManifestPath(projectDir)
+	if _, err := os.Stat(lPath); err == nil {
+		l, err := LoadManifest(lPath)
+		if err != nil {
+			return nil, fmt.Errorf("load personal manifest: %w", err)
+		}
+		ResolveManifestPaths(l, filepath.Dir(lPath))
+		tops = append(tops, Layer{Source: lPath, Kind: LayerPersonal, Manifest: l})
+	}
+
+	var layers []Layer
+	var visible []RegistryRef
+	for _, top := range tops {
+		expanded, err := expandExtends(top, visible, nil)
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, expanded...)
+		for _, l := range expanded {
+			if l.Manifest.Policy != nil && !policyLayers[l.Kind] {
+				return nil, fmt.Errorf("%s: policy is only allowed in the system or global config; move it there or remove it", l.Source)
+			}
+			visible = mergeRegistryRefs(visible, l.Manifest.Registries)
+		}
+	}
+	return layers, nil
+}
+
+// LoadSystemManifest loads the system manifest (see SystemManifestPath) with
+// its relative paths resolved, or returns nil if there is none at
+// DefaultSystemManifestPath. Since it may carry organization policy, a file
+// named by $PV_SYSTEM_CONFIG that is missing, or any system manifest that
+// can't be read, is an error rather than silently skipped.
+func LoadSystemManifest() (*Manifest, error) {
+	sysPath := SystemManifestPath()
+	data, err := os.ReadFile(sysPath)
+	if err != nil {
+		if os.IsNotExist(err) && os.Getenv(SystemConfigEnvVar) == "" {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read system manifest: %w", err)
+	}
+	m, err := LoadManifestFromBytes(data)
+	if err != nil {
+		return nil, fmt.Errorf("parse system manifest: %w", err)
+	}
+	ResolveManifestPaths(m, filepath.Dir(sysPath))
+	return m, nil
+}
+
+// MergeLayers folds layers, lowest priority first, with MergeManifests.
+func MergeLayers(layers []Layer) *Manifest {
+	var merged *Manifest
+	for _, l := range layers {
+		merged = MergeManifests(merged, l.Manifest)
+	}
+	return merged
+}
+
+// expandExtends returns the layers l extends (recursively, in order)
+// followed by l itself. visible holds the registries defined by lower
+// layers; stack holds the sources currently being expanded, for cycle
+// detection.
+func expandExtends(l Layer, visible []RegistryRef, stack []string) ([]Layer, error) {
+	for _, s := range stack {
+		if s == l.Source {
+			return nil, fmt.Errorf("extends cycle: %s -> %s", strings.Join(stack, " -> "), l.Source)
+		}
+	}
+	stack = append(stack, l.Source)
+
+	regs := mergeRegistryRefs(visible, l.Manifest.Registries)
+	var out []Layer
+	for _, ref := range l.Manifest.Extends {
+		child, err := loadExtendedLayer(l, ref, regs)
+		if err != nil {
+			return nil, err
+		}
+		expanded, err := expandExtends(child, regs, stack)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, expanded...)
+		for _, e := range expanded {
+			regs = mergeRegistryRefs(regs, e.Manifest.Registries)
+		}
+	}
+	return append(out, l), nil
+}
+
+// loadExtendedLayer loads the manifest named by ref in parent's extends list.
+// Relative file references inside a registry manifest stay in that registry.
+func loadExtendedLayer(parent Layer, ref string, regs []RegistryRef) (Layer, error) {
+	child := Layer{Kind: parent.Kind, ExtendedBy: parent.Source}
+
+	regName, regPath, isRegistry := splitRegistryRef(ref)
+	if !isRegistry {
+		if parentReg, parentPath, ok := splitRegistryRef(parent.Source); ok && !filepath.IsAbs(ref) {
+			regName, regPath, isRegistry = parentReg, path.Join(path.Dir(parentPath), filepath.ToSlash(ref)), true
+		}
+	}
+
+	if isRegistry {
+		child.Source = regName + ":" + regPath
+		var reg *RegistryRef
+		for i := range regs {
+			if regs[i].Name == regName && !regs[i].Disabled() {
+				reg = &regs[i]
+			}
+		}
+		if reg == nil {
+			return child, fmt.Errorf("%s: extends %s: registry %q is not configured", parent.Source, child.Source, regName)
+		}
+		if RegistryFileFetcher == nil {
+			return child, fmt.Errorf("%s: extends %s: registry files are not available", parent.Source, child.Source)
+		}
+		data, err := RegistryFileFetcher(*reg, regPath)
+		if err != nil {
+			return child, fmt.Errorf("%s: extends %s: %w", parent.Source, child.Source, err)
+		}
+		m, err := LoadManifestFromBytes(data)
+		if err != nil {
+			return child, fmt.Errorf("%s: extends %s: %w", parent.Source, child.Source, err)
+		}
+		child.Manifest = m
+		return child, nil
+	}
+
+	p := ref
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(filepath.Dir(parent.Source), p)
+	}
+	child.Source = filepath.Clean(p)
+	m, err := LoadManifest(child.Source)
+	if err != nil {
+		return child, fmt.Errorf("%s: extends %s: %w", parent.Source, ref, err)
+	}
+	ResolveManifestPaths(m, filepath.Dir(child.Source))
+	child.Manifest = m
+	return child, nil
+}
+
+// splitRegistryRef splits a "registry:path" reference. File paths, including
+// relative ones and Windows drive paths, are not registry references.
+func splitRegistryRef(ref string) (name, relPath string, ok bool) {
+	if filepath.IsAbs(ref) || strings.HasPrefix(ref, ".") {
+		return "", "", false
+	}
+	name, relPath, ok = strings.Cut(ref, ":")
+	if !ok || name == "" || relPath == "" || strings.ContainsAny(name, `/\`) {
+		return "", "", false
+	}
+	return name, relPath, true
+}
+
+// mergeRegistryRefs returns base with overlay's registries added or
+// replacing same-named entries that are not locked.
+func mergeRegistryRefs(base, overlay []RegistryRef) []RegistryRef {
+	out := append([]RegistryRef(nil), base...)
+	for _, r := range overlay {
+		replaced := false
+		for i := range out {
+			if out[i].Name == r.Name {
+				if !out[i].Locked {
+					out[i] = r
+				}
+				replaced = true
+			}
+		}
+		if !replaced {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// SourceKey identifies a mergeable manifest value for ValueSources, e.g.
+// SourceKey("skills", "tdd") or SourceKey("targets", "").
+func SourceKey(section, name string) string {
+	if name == "" {
+		return section
+	}
+	return section + "/" + name
+}
+
+// ValueSources reports, for every value in the merged manifest, the layer
+// it came from (the highest-priority layer defining it, or the layer that
+// locked it). Keys are built with SourceKey.
+func ValueSources(layers []Layer) map[string]Layer {
+	out := make(map[string]Layer)
+	locked := make(map[string]bool)
+	set := func(key string, l Layer, lock bool) {
+		if locked[key] {
+			return
+		}
+		out[key] = l
+		locked[key] = lock
+	}
+	for _, l := range layers {
+		m := l.Manifest
+		for _, r := range m.Registries {
+			set(SourceKey("registries", r.Name), l, r.Locked)
+		}
+		for _, s := range m.Skills {
+			set(SourceKey("skills", s.Name), l, s.Locked)
+		}
+		for _, i := range m.Instructions {
+			set(SourceKey("instructions", i.Name), l, i.Locked)
+		}
+		for _, a := range m.Agents {
+			set(SourceKey("agents", a.Name), l, a.Locked)
+		}
+		if len(m.Targets) > 0 {
+			out[SourceKey("targets", "")] = l
+		}
+		if m.Generated != "" {
+			out[SourceKey("generated", "")] = l
+		}
+	}
+	return out
+}
+
+// LockViolation is an attempt by a higher layer, or a profile, to change an
+// entry that a lower layer marks locked: true. Merging ignores the attempt.
+type LockViolation struct {
+	// Section is "registries", "skills", "instructions" or "agents".
+	Section string
+	Name    string
+	// LockedBy is the Source of the layer that locked the entry.
+	LockedBy string
+	// Source is the layer making the change, with " (profile NAME)"
+	// appended for profile entries.
+	Source string
+	// Action is "override", "disable" or "remove".
+	Action string
+}
+
+func (v LockViolation) Error() string {
+	return fmt.Sprintf("%s: cannot %s %s %q, locked by %s", v.Source, v.Action, v.Kind(), v.Name, v.LockedBy)
+}
+
+// Kind names the entry type in the singular, e.g. "registry".
+func (v LockViolation) Kind() string {
+	if v.Section == "registries" {
+		return "registry"
+	}
+	return strings.TrimSuffix(v.Section, "s")
+}
+
+// LockViolations reports every attempt in layers, lowest priority first, to
+// override, disable or remove a locked entry.
+func LockViolations(layers []Layer) []LockViolation {
+	lockedBy := make(map[string]string)
+	var out []LockViolation
+	check := func(source, section, name, action string, lock bool) {
+		key := SourceKey(section, name)
+		if by, ok := lockedBy[key]; ok {
+			out = append(out, LockViolation{Section: section, Name: name, LockedBy: by, Source: source, Action: action})
+			return
+		}
+		if lock {
+			lockedBy[key] = source
+		}
+	}
+	action := func(disabled bool) string {
+		if disabled {
+			return "disable"
+		}
+		return "override"
+	}
+	for _, l := range layers {
+		m := l.Manifest
+		for _, r := range m.Registries {
+			check(l.Source, "registries", r.Name, action(r.Disabled()), r.Locked)
+		}
+		for _, s := range m.Skills {
+			check(l.Source, "skills", s.Name, action(s.Disabled()), s.Locked)
+		}
+		for _, i := range m.Instructions {
+			check(l.Source, "instructions", i.Name, action(i.Disabled()), i.Locked)
+		}
+		for _, a := range m.Agents {
+			check(l.Source, "agents", a.Name, action(a.Disabled()), a.Locked)
+		}
+		for _, name := range m.ProfileNames() {
+			p := m.Profiles[name]
+			source := fmt.Sprintf("%s (profile %s)", l.Source, name)
+			for _, s := range p.Skills {
+				check(source, "skills", s.Name, "override", false)
+			}
+			for _, i := range p.Instructions {
+				check(source, "instructions", i.Name, "override", false)
+			}
+			for _, a := range p.Agents {
+				check(source, "agents", a.Name, "override", false)
+			}
+			for _, n := range p.Remove.Skills {
+				check(source, "skills", n, "remove", false)
+			}
+			for _, n := range p.Remove.Instructions {
+				check(source, "instructions", n, "remove", false)
+			}
+			for _, n := range p.Remove.Agents {
+				check(source, "agents", n, "remove", false)
+			}
+		}
+	}
+	return out
+}
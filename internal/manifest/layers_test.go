@@ -0,0 +1,269 @@
+package manifest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// withRegistryFiles serves registry extends from an in-memory map keyed by
+// "registry:path" for the duration of a test.
+func withRegistryFiles(t *testing.T, files map[string]string) {
+	t.Helper()
+	prev := RegistryFileFetcher
+	RegistryFileFetcher = func(reg RegistryRef, relPath string) ([]byte, error) {
+		data, ok := files[reg.Name+":"+relPath]
+		if !ok {
+			return nil, fmt.Errorf("file not found: %s", relPath)
+		}
+		return []byte(data), nil
+	}
+	t.Cleanup(func() { RegistryFileFetcher = prev })
+}
+
+func TestLoadMergedManifest_ExtendsLocalFile(t *testing.T) {
+	projectDir := t.TempDir()
+	sharedDir := filepath.Join(projectDir, "shared")
+	require.NoError(t, os.MkdirAll(sharedDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(sharedDir, "base.yaml"), []byte(`skills:
+  - name: base-skill
+  - name: overridden
+    path: ./skills/overridden
+agents:
+  - name: reviewer
+    path: ./agents/reviewer.md
+targets:
+  - cursor
+`), 0o644))
+	writeManifestFile(t, projectDir, `extends:
+  - ./shared/base.yaml
+skills:
+  - name: overridden
+  - name: project-skill
+`)
+
+	m, err := LoadMergedManifest(projectDir, filepath.Join(t.TempDir(), "vibes.yaml"))
+	require.NoError(t, err)
+
+	var names []string
+	for _, s := range m.Skills {
+		names = append(names, s.Name)
+	}
+	assert.Equal(t, []string{"base-skill", "overridden", "project-skill"}, names)
+	assert.Empty(t, m.Skills[1].Path, "the extending manifest overrides the base entry")
+	assert.Equal(t, []string{"cursor"}, m.Targets)
+	require.Len(t, m.Agents, 1)
+	assert.Equal(t, filepath.Join(sharedDir, "agents", "reviewer.md"), m.Agents[0].Path)
+	assert.Empty(t, m.Extends)
+}
+
+func TestLoadMergedManifest_ExtendsRegistryFile(t *testing.T) {
+	withRegistryFiles(t, map[string]string{
+		"company:manifests/base.yaml":     "extends:\n  - ./security.yaml\nskills:\n  - name: base-skill\n",
+		"company:manifests/security.yaml": "instructions:\n  - name: security\n    content: No secrets.\n",
+	})
+
+	projectDir := t.TempDir()
+	globalPath := filepath.Join(t.TempDir(), "vibes.yaml")
+	require.NoError(t, os.WriteFile(globalPath, []byte("registries:\n  - name: company\n    url: https://example.com/company.git\n    ref: latest\n"), 0o644))
+	writeManifestFile(t, projectDir, "extends:\n  - company:manifests/base.yaml\ntargets:\n  - opencode\n")
+
+	layers, err := LoadManifestLayers(projectDir, globalPath)
+	require.NoError(t, err)
+	var sources []string
+	for _, l := range layers {
+		sources = append(sources, l.Source)
+	}
+	assert.Equal(t, []string{
+		globalPath,
+		"company:manifests/security.yaml",
+		"company:manifests/base.yaml",
+		filepath.Join(projectDir, "vibes.yaml"),
+	}, sources)
+	assert.Equal(t, LayerLocal, layers[1].Kind)
+	assert.Equal(t, "company:manifests/base.yaml", layers[1].ExtendedBy)
+
+	m := MergeLayers(layers)
+	require.Len(t, m.Skills, 1)
+	require.Len(t, m.Instructions, 1)
+	assert.Equal(t, "security", m.Instructions[0].Name)
+}
+
+func TestLoadMergedManifest_ExtendsUnknownRegistry(t *testing.T) {
+	withRegistryFiles(t, nil)
+	projectDir := t.TempDir()
+	writeManifestFile(t, projectDir, "extends:\n  - nope:base.yaml\n")
+
+	_, err := LoadMergedManifest(projectDir, filepath.Join(t.TempDir(), "vibes.yaml"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `registry "nope" is not configured`)
+}
+
+func TestLoadMergedManifest_ExtendsCycle(t *testing.T) {
+	projectDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "a.yaml"), []byte("extends:\n  - ./b.yaml\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "b.yaml"), []byte("extends:\n  - ./a.yaml\n"), 0o644))
+	writeManifestFile(t, projectDir, "extends:\n  - ./a.yaml\n")
+
+	_, err := LoadMergedManifest(projectDir, filepath.Join(t.TempDir(), "vibes.yaml"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "extends cycle")
+	assert.Contains(t, err.Error(), "a.yaml -> "+filepath.Join(projectDir, "b.yaml"))
+}
+
+func TestLoadMergedManifest_PersonalLayerMergesLast(t *testing.T) {
+	projectDir := t.TempDir()
+	globalDir := t.TempDir()
+	globalPath := filepath.Join(globalDir, "vibes.yaml")
+	require.NoError(t, os.WriteFile(globalPath, []byte("skills:\n  - name: global-skill\ntargets:\n  - opencode\n"), 0o644))
+	writeManifestFile(t, projectDir, `skills:
+  - name: shared
+    path: ./skills/shared
+targets:
+  - cursor
+`)
+	require.NoError(t, os.WriteFile(PersonalManifestPath(projectDir), []byte(`skills:
+  - name: shared
+agents:
+  - name: mine
+    path: ./agents/mine.md
+`), 0o644))
+
+	layers, err := LoadManifestLayers(projectDir, globalPath)
+	require.NoError(t, err)
+	require.Len(t, layers, 3)
+	assert.Equal(t, LayerPersonal, layers[2].Kind)
+	assert.Equal(t, PersonalManifestPath(projectDir), layers[2].Source)
+
+	m := MergeLayers(layers)
+	require.Len(t, m.Skills, 2)
+	assert.Empty(t, m.Skills[1].Path, "the personal manifest overrides the project entry")
+	require.Len(t, m.Agents, 1)
+	assert.Equal(t, filepath.Join(projectDir, "agents", "mine.md"), m.Agents[0].Path)
+	assert.Equal(t, []string{"cursor"}, m.Targets)
+	assert.Equal(t, LayerPersonal, ValueSources(layers)[SourceKey("agents", "mine")].Kind)
+}
+
+func TestLoadMergedManifest_PersonalLayerParseError(t *testing.T) {
+	projectDir := t.TempDir()
+	require.NoError(t, os.WriteFile(PersonalManifestPath(projectDir), []byte("skills: [\n"), 0o644))
+
+	_, err := LoadMergedManifest(projectDir, filepath.Join(t.TempDir(), "vibes.yaml"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "personal manifest")
+}
+
+func TestLoadMergedManifest_SystemLayerLocksEntries(t *testing.T) {
+	projectDir := t.TempDir()
+	sysPath := filepath.Join(t.TempDir(), "vibes.yaml")
+	t.Setenv(SystemConfigEnvVar, sysPath)
+	require.NoError(t, os.WriteFile(sysPath, []byte(`registries:
+  - name: approved
+    url: https://example.com/approved.git
+    ref: v1
+    locked: true
+instructions:
+  - name: security
+    content: Never commit secrets.
+    locked: true
+  - name: style
+    content: Use tabs.
+`), 0o644))
+	globalPath := filepath.Join(t.TempDir(), "vibes.yaml")
+	require.NoError(t, os.WriteFile(globalPath, []byte(`registries:
+  - name: approved
+    url: https://example.com/fork.git
+    ref: main
+instructions:
+  - name: style
+    content: Use spaces.
+`), 0o644))
+	writeManifestFile(t, projectDir, `instructions:
+  - name: security
+    enabled: false
+targets:
+  - cursor
+profiles:
+  ci:
+    remove:
+      instructions: [security]
+`)
+
+	layers, err := LoadManifestLayers(projectDir, globalPath)
+	require.NoError(t, err)
+	require.Len(t, layers, 3)
+	assert.Equal(t, LayerSystem, layers[0].Kind)
+
+	m := MergeLayers(layers)
+	require.Len(t, m.Registries, 1)
+	assert.Equal(t, "https://example.com/approved.git", m.Registries[0].URL)
+	require.Len(t, m.Instructions, 2)
+	assert.Equal(t, "Never commit secrets.", m.Instructions[0].Content)
+	assert.Equal(t, "Use spaces.", m.Instructions[1].Content, "unlocked entries can still be overridden")
+	assert.Equal(t, sysPath, ValueSources(layers)[SourceKey("registries", "approved")].Source)
+
+	var msgs []string
+	for _, v := range LockViolations(layers) {
+		msgs = append(msgs, v.Error())
+	}
+	assert.Equal(t, []string{
+		globalPath + `: cannot override registry "approved", locked by ` + sysPath,
+		filepath.Join(projectDir, "vibes.yaml") + `: cannot disable instruction "security", locked by ` + sysPath,
+		filepath.Join(projectDir, "vibes.yaml") + ` (profile ci): cannot remove instruction "security", locked by ` + sysPath,
+	}, msgs)
+}
+
+func TestSplitRegistryRef(t *testing.T) {
+	name, p, ok := splitRegistryRef("company:manifests/base.yaml")
+	assert.True(t, ok)
+	assert.Equal(t, "company", name)
+	assert.Equal(t, "manifests/base.yaml", p)
+
+	for _, ref := range []string{"./base.yaml", "base.yaml", "/abs/base.yaml", "../x:y.yaml"} {
+		_, _, ok := splitRegistryRef(ref)
+		assert.False(t, ok, ref)
+	}
+}
+
+func TestValueSources(t *testing.T) {
+	base := Layer{Source: "company:base.yaml", Kind: LayerLocal, ExtendedBy: "vibes.yaml", Manifest: &Manifest{
+		Skills:  []SkillRef{{Name: "a"}, {Name: "b"}},
+		Targets: []string{"cursor"},
+	}}
+	local := Layer{Source: "vibes.yaml", Kind: LayerLocal, Manifest: &Manifest{
+		Skills: []SkillRef{{Name: "b"}},
+	}}
+
+	src := ValueSources([]Layer{base, local})
+	assert.Equal(t, "company:base.yaml", src[SourceKey("skills", "a")].Source)
+	assert.Equal(t, "vibes.yaml", src[SourceKey("skills", "b")].Source)
+	assert.Equal(t, "company:base.yaml", src[SourceKey("targets", "")].Source)
+	_, ok := src[SourceKey("generated", "")]
+	assert.False(t, ok)
+}
+
+func TestLoadSystemManifest_ExplicitPathMustExist(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "vibes.yaml")
+	t.Setenv(SystemConfigEnvVar, missing)
+	_, err := LoadSystemManifest()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "read system manifest")
+
+	_, err = LoadMergedManifest(t.TempDir(), filepath.Join(t.TempDir(), "vibes.yaml"))
+	require.Error(t, err, "a missing explicit system config must not be skipped")
+}
+
+func TestLoadSystemManifest_UnreadableIsAnError(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("root can read any file")
+	}
+	sysPath := filepath.Join(t.TempDir(), "vibes.yaml")
+	require.NoError(t, os.WriteFile(sysPath, []byte("skills: []\n"), 0o000))
+	t.Setenv(SystemConfigEnvVar, sysPath)
+	_, err := LoadSystemManifest()
+	require.Error(t, err)
+}
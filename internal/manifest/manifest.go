@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"sort"
+	"strings"
 
 	yaml "gopkg.in/yaml.v3"
 )
@@ -13,16 +15,66 @@ import (
 // vibes.yaml is preferred; vibes.yml is the legacy fallback.
 var ManifestFilenames = []string{"vibes.yaml", "vibes.yml"}
 
+// PersonalManifestFilename is the uncommitted, per-developer manifest that
+// sits next to the project manifest and is merged on top of it.
+const PersonalManifestFilename = "vibes.local.yaml"
+
 // ValidTargets are the supported target tool identifiers.
 var ValidTargets = []string{"vscode-copilot", "opencode", "cursor"}
 
+// Values for Manifest.Generated.
+const (
+	// GeneratedCommit keeps generated files visible to git so they can be
+	// committed; only personal resources are excluded.
+	GeneratedCommit = "commit"
+	// GeneratedIgnore lists every generated file in a managed .gitignore block.
+	GeneratedIgnore = "ignore"
+)
+
+// Values for RegistryRef.Type.
+const (
+	// RegistryTypeGit clones a git repository.
+	RegistryTypeGit = "git"
+	// RegistryTypeArchive downloads and extracts a .tar.gz or .zip file.
+	RegistryTypeArchive = "archive"
+	// RegistryTypeDir reads a directory on disk in place.
+	RegistryTypeDir = "dir"
+)
+
+// RegistryTypes lists the valid values for RegistryRef.Type.
+var RegistryTypes = []string{RegistryTypeGit, RegistryTypeArchive, RegistryTypeDir}
+
+var (
+	sha256Pattern = regexp.MustCompile(`^[0-9a-fA-F]{64}$`)
+	envVarName    = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
 // Manifest represents a vibes.yaml file.
 type Manifest struct {
+	// Version is the manifest format version (see CurrentVersion). Older
+	// manifests are upgraded when loaded and by `config migrate`.
+	Version      int              `yaml:"version,omitempty"`
 	Registries   []RegistryRef    `yaml:"registries,omitempty"`
 	Skills       []SkillRef       `yaml:"skills"`
 	Instructions []InstructionRef `yaml:"instructions,omitempty"`
 	Agents       []AgentRef       `yaml:"agents,omitempty"`
-	Targets      []string         `yaml:"targets"`
+	Targets      []string         `yaml:"targets" jsonschema:"enum=targets"`
+	// Generated controls how apply maintains git ignore entries for the
+	// files it installs: "commit", "ignore", or empty to leave git alone.
+	Generated string `yaml:"generated,omitempty" jsonschema:"enum=generated"`
+	// Workspace lists glob patterns, relative to this manifest, matching
+	// package directories that inherit from it (e.g. "services/*").
+	Workspace []string `yaml:"workspace,omitempty"`
+	// Extends lists manifests this one builds on, merged beneath it. Entries
+	// are file paths (relative to this manifest) or "registry:path" files
+	// inside a configured registry.
+	Extends []string `yaml:"extends,omitempty"`
+	// Policy restricts where resources may come from; only the system and
+	// global manifests may set it.
+	Policy *Policy `yaml:"policy,omitempty"`
+	// Profiles are named adjustments to the manifest, selected at apply time
+	// (see WithProfiles).
+	Profiles map[string]Profile `yaml:"profiles,omitempty"`
 }
 
 // OverrideDiagnostics describes names where local config overrides global config.
@@ -31,6 +83,17 @@ type OverrideDiagnostics struct {
 	Skills       []string
 	Instructions []string
 	Agents       []string
+	// Suppressed lists global entries that local config disables with
+	// enabled: false. They are not counted as overrides.
+	Suppressed SuppressedEntries
+}
+
+// SuppressedEntries names entries disabled with enabled: false.
+type SuppressedEntries struct {
+	Registries   []string
+	Skills       []string
+	Instructions []string
+	Agents       []string
 }
 
 // RiskyOverrideDiagnostics describes overrides that change how an entry is sourced.
@@ -47,6 +110,7 @@ func ComputeOverrideDiagnostics(global, local *Manifest) OverrideDiagnostics {
 		return OverrideDiagnostics{}
 	}
 
+	global = global.WithoutDisabled()
 	globalRegs := make(map[string]bool)
 	for _, r := range global.Registries {
 		globalRegs[r.Name] = true
@@ -66,22 +130,42 @@ func ComputeOverrideDiagnostics(global, local *Manifest) OverrideDiagnostics {
 
 	d := OverrideDiagnostics{}
 	for _, r := range local.Registries {
-		if globalRegs[r.Name] {
+		if !globalRegs[r.Name] {
+			continue
+		}
+		if r.Disabled() {
+			d.Suppressed.Registries = append(d.Suppressed.Registries, r.Name)
+		} else {
 			d.Registries = append(d.Registries, r.Name)
 		}
 	}
 	for _, s := range local.Skills {
-		if globalSkills[s.Name] {
+		if !globalSkills[s.Name] {
+			continue
+		}
+		if s.Disabled() {
+			d.Suppressed.Skills = append(d.Suppressed.Skills, s.Name)
+		} else {
 			d.Skills = append(d.Skills, s.Name)
 		}
 	}
 	for _, i := range local.Instructions {
-		if globalInst[i.Name] {
+		if !globalInst[i.Name] {
+			continue
+		}
+		if i.Disabled() {
+			d.Suppressed.Instructions = append(d.Suppressed.Instructions, i.Name)
+		} else {
 			d.Instructions = append(d.Instructions, i.Name)
 		}
 	}
 	for _, a := range local.Agents {
-		if globalAgents[a.Name] {
+		if !globalAgents[a.Name] {
+			continue
+		}
+		if a.Disabled() {
+			d.Suppressed.Agents = append(d.Suppressed.Agents, a.Name)
+		} else {
 			d.Agents = append(d.Agents, a.Name)
 		}
 	}
@@ -90,6 +174,10 @@ func ComputeOverrideDiagnostics(global, local *Manifest) OverrideDiagnostics {
 	sort.Strings(d.Skills)
 	sort.Strings(d.Instructions)
 	sort.Strings(d.Agents)
+	sort.Strings(d.Suppressed.Registries)
+	sort.Strings(d.Suppressed.Skills)
+	sort.Strings(d.Suppressed.Instructions)
+	sort.Strings(d.Suppressed.Agents)
 	return d
 }
 
@@ -97,10 +185,13 @@ func ComputeOverrideDiagnostics(global, local *Manifest) OverrideDiagnostics {
 // - Skills: registry/embedded-style -> path-style (or inverse)
 // - Instructions: content -> path (or inverse)
 // - Agents: registry -> path (or inverse)
+//
+// Disabled entries are suppressions, not overrides, and are ignored.
 func ComputeRiskyOverrideDiagnostics(global, local *Manifest) RiskyOverrideDiagnostics {
 	if global == nil || local == nil {
 		return RiskyOverrideDiagnostics{}
 	}
+	global, local = global.WithoutDisabled(), local.WithoutDisabled()
 
 	globalSkills := make(map[string]SkillRef)
 	for _, s := range global.Skills {
@@ -166,34 +257,141 @@ func ComputeRiskyOverrideDiagnostics(global, local *Manifest) RiskyOverrideDiagn
 
 // SkillRef is a reference to a skill in the manifest.
 type SkillRef struct {
-	Name     string `yaml:"name"`
+	Name     string `yaml:"name" jsonschema:"required"`
 	Registry string `yaml:"registry,omitempty"`
 	Path     string `yaml:"path,omitempty"`
 	Version  string `yaml:"version,omitempty"`
+	// Enabled set to false suppresses an inherited skill of the same name.
+	Enabled *bool `yaml:"enabled,omitempty"`
+	// Locked stops higher layers from overriding or disabling the entry.
+	Locked bool `yaml:"locked,omitempty"`
 }
 
+// Disabled reports whether the entry is a suppression (enabled: false).
+func (s SkillRef) Disabled() bool { return isDisabled(s.Enabled) }
+
 // InstructionRef is a reference to an instruction in the manifest.
 type InstructionRef struct {
-	Name     string `yaml:"name"`
+	Name     string `yaml:"name" jsonschema:"required"`
 	Registry string `yaml:"registry,omitempty"`
 	Content  string `yaml:"content,omitempty"`
 	Path     string `yaml:"path,omitempty"`
 	ApplyTo  string `yaml:"apply_to,omitempty"`
+	// Enabled set to false suppresses an inherited instruction of the same
+	// name.
+	Enabled *bool `yaml:"enabled,omitempty"`
+	// Locked stops higher layers from overriding or disabling the entry.
+	Locked bool `yaml:"locked,omitempty"`
 }
 
+// Disabled reports whether the entry is a suppression (enabled: false).
+func (i InstructionRef) Disabled() bool { return isDisabled(i.Enabled) }
+
 // AgentRef is a reference to an agent in the manifest.
 type AgentRef struct {
-	Name     string `yaml:"name"`
-	Path     string `yaml:"path,omitempty"`
+	Name     string `yaml:"name" jsonschema:"required"`
+	Path     string `yaml:"path,omitempty" jsonschema:"required-when-enabled"`
 	Registry string `yaml:"registry,omitempty"`
+	// Enabled set to false suppresses an inherited agent of the same name.
+	Enabled *bool `yaml:"enabled,omitempty"`
+	// Locked stops higher layers from overriding or disabling the entry.
+	Locked bool `yaml:"locked,omitempty"`
 }
 
-// RegistryRef points to a remote git repository of skills.
+// Disabled reports whether the entry is a suppression (enabled: false).
+func (a AgentRef) Disabled() bool { return isDisabled(a.Enabled) }
+
+// RegistryRef points to a registry of skills, instructions and agents: a git
+// repository, an archive or a local directory (see SourceType).
 type RegistryRef struct {
-	Name  string            `yaml:"name"`
-	URL   string            `yaml:"url"`
-	Ref   string            `yaml:"ref"`
-	Paths map[string]string `yaml:"paths,omitempty"` // e.g. {"skills": "skills/", "instructions": "instructions/", "agents": "agents/"}
+	Name string `yaml:"name" jsonschema:"required"`
+	URL  string `yaml:"url" jsonschema:"required-when-enabled"`
+	// Ref is required for git registries and archives without sha256; see
+	// NeedsRef.
+	Ref   string            `yaml:"ref,omitempty"`
+	Paths map[string]string `yaml:"paths,omitempty" jsonschema:"keys=registry-paths"` // e.g. {"skills": "skills/", "instructions": "instructions/", "agents": "agents/"}
+	// Type selects how the registry is fetched; see SourceType.
+	Type string `yaml:"type,omitempty" jsonschema:"enum=registry-types"`
+	// SHA256 is the expected checksum of an archive registry's download.
+	SHA256 string `yaml:"sha256,omitempty"`
+	// Auth configures credentials for private git registries.
+	Auth *RegistryAuth `yaml:"auth,omitempty"`
+	// Enabled set to false suppresses an inherited registry of the same name.
+	Enabled *bool `yaml:"enabled,omitempty"`
+	// Locked stops higher layers from overriding or disabling the entry.
+	Locked bool `yaml:"locked,omitempty"`
+}
+
+// RegistryAuth configures credentials for a git registry. Secrets are never
+// written in the manifest: tokens are read from the environment, and key
+// files are referenced by path. Without it, HTTPS registries fall back to
+// ~/.netrc and git's credential helpers, and SSH registries to the SSH agent.
+type RegistryAuth struct {
+	// TokenEnv names the environment variable holding an HTTPS access token.
+	TokenEnv string `yaml:"token_env,omitempty"`
+	// Username is sent with the token; defaults to "x-access-token".
+	Username string `yaml:"username,omitempty"`
+	// SSHKey is a private key file for SSH URLs, used instead of the agent.
+	SSHKey string `yaml:"ssh_key,omitempty"`
+	// KnownHosts is the known_hosts file used to verify SSH host keys.
+	KnownHosts string `yaml:"known_hosts,omitempty"`
+}
+
+// Disabled reports whether the entry is a suppression (enabled: false).
+func (r RegistryRef) Disabled() bool { return isDisabled(r.Enabled) }
+
+// NeedsRef reports whether the registry must name a ref. Directories are read
+// as they are, and an archive with a sha256 is already pinned by its checksum.
+func (r RegistryRef) NeedsRef() bool {
+	switch r.SourceType() {
+	case RegistryTypeDir:
+		return false
+	case RegistryTypeArchive:
+		return r.SHA256 == ""
+	}
+	return true
+}
+
+func isDisabled(enabled *bool) bool {
+	return enabled != nil && !*enabled
+}
+
+// SourceType returns Type, or when it is unset: RegistryTypeDir for file://
+// URLs and absolute paths that are not git repositories, RegistryTypeArchive
+// for URLs ending in .tar.gz, .tgz or .zip, and RegistryTypeGit otherwise.
+func (r RegistryRef) SourceType() string {
+	if r.Type != "" {
+		return r.Type
+	}
+	if (strings.HasPrefix(r.URL, "file://") || filepath.IsAbs(r.URL)) && !isGitRepo(r.DirPath()) {
+		return RegistryTypeDir
+	}
+	u, _, _ := strings.Cut(r.URL, "?")
+	for _, ext := range []string{".tar.gz", ".tgz", ".zip"} {
+		if strings.HasSuffix(strings.ToLower(u), ext) {
+			return RegistryTypeArchive
+		}
+	}
+	return RegistryTypeGit
+}
+
+// DirPath returns the directory a dir registry reads from: its URL without
+// a file:// prefix.
+func (r RegistryRef) DirPath() string {
+	return strings.TrimPrefix(r.URL, "file://")
+}
+
+// isGitRepo reports whether dir is a git working tree or a bare repository.
+func isGitRepo(dir string) bool {
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+		return true
+	}
+	head, err := os.Stat(filepath.Join(dir, "HEAD"))
+	if err != nil || head.IsDir() {
+		return false
+	}
+	objects, err := os.Stat(filepath.Join(dir, "objects"))
+	return err == nil && objects.IsDir()
 }
 
 // SkillsPath returns the configured path for skills in this registry,
@@ -223,27 +421,55 @@ func (r RegistryRef) AgentsPath() string {
 	return "."
 }
 
-// LoadManifest reads and parses a vibes.yaml file from the given path.
+// LoadManifest reads and parses a vibes.yaml file from the given path,
+// warning through Warnf when it uses an outdated format version.
 func LoadManifest(path string) (*Manifest, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("read manifest: %w", err)
 	}
-	return LoadManifestFromBytes(data)
+	m, from, err := loadManifestBytes(data)
+	if err != nil {
+		return nil, err
+	}
+	if from < CurrentVersion {
+		warnOutdated(path, from)
+	}
+	return m, nil
 }
 
-// LoadManifestFromBytes parses vibes.yaml content from bytes.
+// LoadManifestFromBytes parses vibes.yaml content from bytes. Older format
+// versions are migrated in memory and ${VAR} references are expanded; an
+// unset variable without a default is an *UnsetVariablesError.
 func LoadManifestFromBytes(data []byte) (*Manifest, error) {
+	m, _, err := loadManifestBytes(data)
+	return m, err
+}
+
+// loadManifestBytes parses and migrates manifest content, returning the
+// version it was written in.
+func loadManifestBytes(data []byte) (*Manifest, int, error) {
+	doc, err := parseDocument(data)
+	if err != nil {
+		return nil, 0, err
+	}
+	from, _, err := migrateDocument(doc)
+	if err != nil {
+		return nil, 0, fmt.Errorf("parse manifest: %w", err)
+	}
+	if unset := interpolateDocument(doc); len(unset) > 0 {
+		return nil, 0, fmt.Errorf("parse manifest: %w", &UnsetVariablesError{Vars: unset})
+	}
 	var m Manifest
-	if err := yaml.Unmarshal(data, &m); err != nil {
-		return nil, fmt.Errorf("parse manifest: %w", err)
+	if err := doc.Decode(&m); err != nil {
+		return nil, 0, fmt.Errorf("parse manifest: %w", err)
 	}
-	return &m, nil
+	return &m, from, nil
 }
 
 // SaveManifest writes the manifest to the given path as YAML.
 func SaveManifest(m *Manifest, path string) error {
-	data, err := yaml.Marshal(m)
+	data, err := yaml.Marshal(stampVersion(m))
 	if err != nil {
 		return fmt.Errorf("marshal manifest: %w", err)
 	}
@@ -268,15 +494,84 @@ func (m *Manifest) Validate() error {
 			return fmt.Errorf("invalid target: %s", t)
 		}
 	}
+	if m.Generated != "" && m.Generated != GeneratedCommit && m.Generated != GeneratedIgnore {
+		return fmt.Errorf("invalid generated mode %q (use %q or %q)", m.Generated, GeneratedCommit, GeneratedIgnore)
+	}
+	for _, name := range m.ProfileNames() {
+		p := m.Profiles[name]
+		for i, s := range p.Skills {
+			if s.Name == "" {
+				return fmt.Errorf("profile %q: skill[%d]: name is required", name, i)
+			}
+		}
+		for i, inst := range p.Instructions {
+			if inst.Name == "" {
+				return fmt.Errorf("profile %q: instruction[%d]: name is required", name, i)
+			}
+		}
+		for i, a := range p.Agents {
+			if a.Name == "" {
+				return fmt.Errorf("profile %q: agent[%d]: name is required", name, i)
+			}
+		}
+		for _, t := range p.Targets {
+			if !isValidTarget(t) {
+				return fmt.Errorf("profile %q: invalid target: %s", name, t)
+			}
+		}
+	}
+	if m.Policy != nil {
+		if err := m.Policy.validate(); err != nil {
+			return err
+		}
+	}
+	for i, e := range m.Extends {
+		if strings.TrimSpace(e) == "" {
+			return fmt.Errorf("extends[%d]: path is required", i)
+		}
+	}
+	for _, p := range m.Workspace {
+		if _, err := filepath.Match(p, ""); err != nil {
+			return fmt.Errorf("invalid workspace pattern %q: %w", p, err)
+		}
+	}
 	for _, r := range m.Registries {
-		if r.Ref == "" {
+		if r.Disabled() {
+			continue
+		}
+		if r.Ref == "" && r.NeedsRef() {
 			return fmt.Errorf("registry %q must specify a ref (use \"latest\" to track the default branch)", r.Name)
 		}
+		if r.Type != "" && !containsValue(RegistryTypes, r.Type) {
+			return fmt.Errorf("registry %q: invalid type %q (use one of: %s)", r.Name, r.Type, strings.Join(RegistryTypes, ", "))
+		}
+		if a := r.Auth; a != nil {
+			if r.SourceType() != RegistryTypeGit {
+				return fmt.Errorf("registry %q: auth is only supported for git registries", r.Name)
+			}
+			if a.TokenEnv != "" && !envVarName.MatchString(a.TokenEnv) {
+				return fmt.Errorf("registry %q: auth.token_env %q is not a valid environment variable name", r.Name, a.TokenEnv)
+			}
+			if a.TokenEnv != "" && a.SSHKey != "" {
+				return fmt.Errorf("registry %q: auth.token_env (HTTPS) and auth.ssh_key (SSH) are mutually exclusive", r.Name)
+			}
+		}
+		if r.SHA256 != "" {
+			if r.SourceType() != RegistryTypeArchive {
+				return fmt.Errorf("registry %q: sha256 is only supported for archive registries", r.Name)
+			}
+			if !sha256Pattern.MatchString(r.SHA256) {
+				return fmt.Errorf("registry %q: sha256 must be 64 hex characters", r.Name)
+			}
+		}
 	}
 	for i, s := range m.Skills {
 		if s.Name == "" {
 			return fmt.Errorf("skill[%d]: name is required", i)
 		}
+		if s.Disabled() {
+			continue
+		}
 		if s.Registry != "" && s.Path == "" {
 			return fmt.Errorf("skill %q: path is required when registry is set", s.Name)
 		}
@@ -285,6 +580,9 @@ func (m *Manifest) Validate() error {
 		if inst.Name == "" {
 			return fmt.Errorf("instruction[%d]: name is required", i)
 		}
+		if inst.Disabled() {
+			continue
+		}
 		if inst.Content != "" && inst.Path != "" {
 			return fmt.Errorf("instruction %q: content and path are mutually exclusive", inst.Name)
 		}
@@ -304,7 +602,7 @@ func (m *Manifest) Validate() error {
 		if agent.Name == "" {
 			return fmt.Errorf("agent[%d]: name is required", i)
 		}
-		if agent.Path == "" {
+		if agent.Path == "" && !agent.Disabled() {
 			return fmt.Errorf("agent %q: path is required", agent.Name)
 		}
 	}
@@ -323,24 +621,40 @@ func isValidTarget(t string) bool {
 // LoadManifestFromProject searches a project directory for vibes.yaml (preferred)
 // or vibes.yml (legacy fallback). Returns the parsed manifest and the path that was loaded.
 func LoadManifestFromProject(projectDir string) (*Manifest, string, error) {
+	p, ok := FindProjectManifest(projectDir)
+	if !ok {
+		return nil, "", fmt.Errorf("no manifest found in %s (looked for %v)", projectDir, ManifestFilenames)
+	}
+	m, err := LoadManifest(p)
+	if err != nil {
+		return nil, "", err
+	}
+	return m, p, nil
+}
+
+// PersonalManifestPath returns the path of the personal manifest for
+// projectDir, whether or not it exists.
+func PersonalManifestPath(projectDir string) string {
+	return filepath.Join(projectDir, PersonalManifestFilename)
+}
+
+// FindProjectManifest returns the path of the manifest in projectDir, trying
+// ManifestFilenames in order, without loading it.
+func FindProjectManifest(projectDir string) (string, bool) {
 	for _, name := range ManifestFilenames {
 		p := filepath.Join(projectDir, name)
 		if _, err := os.Stat(p); err == nil {
-			m, err := LoadManifest(p)
-			if err != nil {
-				return nil, "", err
-			}
-			return m, p, nil
+			return p, true
 		}
 	}
-	return nil, "", fmt.Errorf("no manifest found in %s (looked for %v)", projectDir, ManifestFilenames)
+	return "", false
 }
 
 // SaveManifestWithComments writes a manifest to the given path, prepending
 // a comment header string. The header should already contain '#' prefixed lines.
 // An empty header is allowed (equivalent to SaveManifest).
 func SaveManifestWithComments(m *Manifest, path string, header string) error {
-	yamlData, err := yaml.Marshal(m)
+	yamlData, err := yaml.Marshal(stampVersion(m))
 	if err != nil {
 		return fmt.Errorf("marshal manifest: %w", err)
 	}
@@ -351,104 +665,146 @@ func SaveManifestWithComments(m *Manifest, path string, header string) error {
 	return nil
 }
 
+// stampVersion returns m, or a copy marked with CurrentVersion if it has no
+// version, so saved manifests never read as outdated.
+func stampVersion(m *Manifest) *Manifest {
+	if m.Version != 0 {
+		return m
+	}
+	out := *m
+	out.Version = CurrentVersion
+	return &out
+}
+
 // LoadMergedManifest loads a global manifest (from globalPath) and a project
 // manifest (from projectDir), merging them with project values taking priority.
+// When projectDir is a package of a workspace (see FindWorkspaceRoot), the
+// workspace root manifest is merged between the two. The personal manifest
+// (PersonalManifestFilename) in projectDir, if any, is merged last. Manifests
+// named in extends are merged beneath the manifest that extends them.
 //
-// Merge rules:
+// Merge rules (applied layer by layer, see MergeManifests):
 //   - Registries: merged by Name; project overrides global for same name
 //   - Skills: merged by Name; project overrides global for same name
 //   - Instructions: merged by Name; project overrides global for same name
 //   - Agents: merged by Name; project overrides global for same name
 //   - Targets: project targets override global (no merge)
+//   - Generated: project value overrides global when set
+//   - Policy: combined so that higher layers can only tighten it
+//
+// An entry marked enabled: false suppresses the same-named entry inherited
+// from lower layers and is itself left out of the result.
+// An entry marked locked: true can't be overridden or suppressed by higher
+// layers; their same-named entries are ignored (see LockViolations).
 //
 // Returns error only if neither global nor project manifest exists.
 func LoadMergedManifest(projectDir string, globalPath string) (*Manifest, error) {
-	var global, project *Manifest
-
-	// Load global manifest (optional)
-	if data, err := os.ReadFile(globalPath); err == nil {
-		g, err := LoadManifestFromBytes(data)
-		if err != nil {
-			return nil, fmt.Errorf("parse global manifest: %w", err)
-		}
-		ResolveManifestPaths(g, filepath.Dir(globalPath))
-		global = g
-	}
-
-	// Load project manifest (optional)
-	if p, pPath, err := LoadManifestFromProject(projectDir); err == nil {
-		ResolveManifestPaths(p, filepath.Dir(pPath))
-		project = p
+	layers, err := LoadManifestLayers(projectDir, globalPath)
+	if err != nil {
+		return nil, err
 	}
-
-	if global == nil && project == nil {
+	if len(layers) == 0 {
 		return nil, fmt.Errorf("no manifest found: checked %s and %s", globalPath, projectDir)
 	}
+	return MergeLayers(layers), nil
+}
 
-	// If only one exists, return it directly
-	if global == nil {
-		return project, nil
+// MergeManifests overlays overlay on base following the LoadMergedManifest
+// rules and returns the result. Either may be nil, in which case the other
+// is returned without its disabled entries. Workspace patterns are never
+// inherited.
+func MergeManifests(base, overlay *Manifest) *Manifest {
+	if base == nil {
+		return overlay.WithoutDisabled()
 	}
-	if project == nil {
-		return global, nil
+	if overlay == nil {
+		return base.WithoutDisabled()
 	}
 
-	// Merge: start from global, overlay project
-	merged := &Manifest{}
+	merged := &Manifest{Workspace: overlay.Workspace, Policy: mergePolicies(base.Policy, overlay.Policy)}
+
+	// Profiles: merged by name, overlay replaces a same-named profile
+	if len(base.Profiles)+len(overlay.Profiles) > 0 {
+		merged.Profiles = make(map[string]Profile)
+		for name, p := range base.Profiles {
+			merged.Profiles[name] = p
+		}
+		for name, p := range overlay.Profiles {
+			merged.Profiles[name] = p
+		}
+	}
 
-	// Registries: merge by Name, project wins
+	// Registries: merge by Name, overlay wins
 	regMap := make(map[string]RegistryRef)
 	var regOrder []string
-	for _, r := range global.Registries {
+	for _, r := range base.Registries {
 		regMap[r.Name] = r
 		regOrder = append(regOrder, r.Name)
 	}
-	for _, r := range project.Registries {
-		if _, exists := regMap[r.Name]; !exists {
+	for _, r := range overlay.Registries {
+		cur, exists := regMap[r.Name]
+		if exists && cur.Locked {
+			continue // locked by a lower layer
+		}
+		if !exists {
 			regOrder = append(regOrder, r.Name)
 		}
-		regMap[r.Name] = r // project overrides
+		regMap[r.Name] = r // overlay overrides
 	}
 	for _, name := range regOrder {
 		merged.Registries = append(merged.Registries, regMap[name])
 	}
 
-	// Skills: merge by Name, project wins
+	// Skills: merge by Name, overlay wins
 	skillMap := make(map[string]SkillRef)
 	var skillOrder []string
-	for _, s := range global.Skills {
+	for _, s := range base.Skills {
 		skillMap[s.Name] = s
 		skillOrder = append(skillOrder, s.Name)
 	}
-	for _, s := range project.Skills {
-		if _, exists := skillMap[s.Name]; !exists {
+	for _, s := range overlay.Skills {
+		cur, exists := skillMap[s.Name]
+		if exists && cur.Locked {
+			continue // locked by a lower layer
+		}
+		if !exists {
 			skillOrder = append(skillOrder, s.Name)
 		}
-		skillMap[s.Name] = s // project overrides
+		skillMap[s.Name] = s // overlay overrides
 	}
 	for _, name := range skillOrder {
 		merged.Skills = append(merged.Skills, skillMap[name])
 	}
 
-	// Targets: project overrides entirely
-	if len(project.Targets) > 0 {
-		merged.Targets = project.Targets
+	// Targets: overlay overrides entirely
+	if len(overlay.Targets) > 0 {
+		merged.Targets = overlay.Targets
 	} else {
-		merged.Targets = global.Targets
+		merged.Targets = base.Targets
 	}
 
-	// Instructions: merge by Name, project wins
+	// Generated: overlay overrides when set
+	merged.Generated = base.Generated
+	if overlay.Generated != "" {
+		merged.Generated = overlay.Generated
+	}
+
+	// Instructions: merge by Name, overlay wins
 	instMap := make(map[string]InstructionRef)
 	var instOrder []string
-	for _, inst := range global.Instructions {
+	for _, inst := range base.Instructions {
 		instMap[inst.Name] = inst
 		instOrder = append(instOrder, inst.Name)
 	}
-	for _, inst := range project.Instructions {
-		if _, exists := instMap[inst.Name]; !exists {
+	for _, inst := range overlay.Instructions {
+		cur, exists := instMap[inst.Name]
+		if exists && cur.Locked {
+			continue // locked by a lower layer
+		}
+		if !exists {
 			instOrder = append(instOrder, inst.Name)
 		}
-		instMap[inst.Name] = inst // project overrides
+		instMap[inst.Name] = inst // overlay overrides
 	}
 	for _, name := range instOrder {
 		merged.Instructions = append(merged.Instructions, instMap[name])
@@ -457,18 +813,22 @@ func LoadMergedManifest(projectDir string, globalPath string) (*Manifest, error)
 		merged.Instructions = nil
 	}
 
-	// Agents: merge by Name, project wins
+	// Agents: merge by Name, overlay wins
 	agentMap := make(map[string]AgentRef)
 	var agentOrder []string
-	for _, a := range global.Agents {
+	for _, a := range base.Agents {
 		agentMap[a.Name] = a
 		agentOrder = append(agentOrder, a.Name)
 	}
-	for _, a := range project.Agents {
-		if _, exists := agentMap[a.Name]; !exists {
+	for _, a := range overlay.Agents {
+		cur, exists := agentMap[a.Name]
+		if exists && cur.Locked {
+			continue // locked by a lower layer
+		}
+		if !exists {
 			agentOrder = append(agentOrder, a.Name)
 		}
-		agentMap[a.Name] = a // project overrides
+		agentMap[a.Name] = a // overlay overrides
 	}
 	for _, name := range agentOrder {
 		merged.Agents = append(merged.Agents, agentMap[name])
@@ -477,7 +837,46 @@ func LoadMergedManifest(projectDir string, globalPath string) (*Manifest, error)
 		merged.Agents = nil
 	}
 
-	return merged, nil
+	return merged.WithoutDisabled()
+}
+
+// WithoutDisabled returns m, or a copy of it without the entries marked
+// enabled: false when it has any.
+func (m *Manifest) WithoutDisabled() *Manifest {
+	if m == nil {
+		return nil
+	}
+	regs := keepEnabled(m.Registries, RegistryRef.Disabled)
+	skills := keepEnabled(m.Skills, SkillRef.Disabled)
+	insts := keepEnabled(m.Instructions, InstructionRef.Disabled)
+	agents := keepEnabled(m.Agents, AgentRef.Disabled)
+	if len(regs) == len(m.Registries) && len(skills) == len(m.Skills) &&
+		len(insts) == len(m.Instructions) && len(agents) == len(m.Agents) {
+		return m
+	}
+	out := *m
+	out.Registries, out.Skills, out.Instructions, out.Agents = regs, skills, insts, agents
+	return &out
+}
+
+// keepEnabled returns the items that are not disabled, or nil if none are.
+func keepEnabled[T any](items []T, disabled func(T) bool) []T {
+	var out []T
+	for _, it := range items {
+		if !disabled(it) {
+			out = append(out, it)
+		}
+	}
+	return out
+}
+
+// resolveAuthPath resolves a relative key or known_hosts path against
+// baseDir. Paths starting with "~" are left for the registry to expand.
+func resolveAuthPath(p, baseDir string) string {
+	if p == "" || filepath.IsAbs(p) || strings.HasPrefix(p, "~") {
+		return p
+	}
+	return filepath.Join(baseDir, p)
 }
 
 // ResolveManifestPaths converts relative paths inside a manifest to absolute
@@ -501,4 +900,21 @@ func ResolveManifestPaths(m *Manifest, baseDir string) {
 			m.Agents[i].Path = filepath.Join(baseDir, m.Agents[i].Path)
 		}
 	}
+	for i, r := range m.Registries {
+		if a := r.Auth; a != nil {
+			resolved := *a
+			resolved.SSHKey = resolveAuthPath(a.SSHKey, baseDir)
+			resolved.KnownHosts = resolveAuthPath(a.KnownHosts, baseDir)
+			m.Registries[i].Auth = &resolved
+		}
+		if r.SourceType() == RegistryTypeDir && r.URL != "" && !strings.HasPrefix(r.URL, "file://") && !filepath.IsAbs(r.URL) {
+			m.Registries[i].URL = filepath.Join(baseDir, r.URL)
+		}
+	}
+	for name, p := range m.Profiles {
+		pm := &Manifest{Skills: p.Skills, Instructions: p.Instructions, Agents: p.Agents}
+		ResolveManifestPaths(pm, baseDir)
+		p.Skills, p.Instructions, p.Agents = pm.Skills, pm.Instructions, pm.Agents
+		m.Profiles[name] = p
+	}
 }
@@ -3,6 +3,7 @@ package manifest
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -122,6 +123,20 @@ func TestValidate_AgentOnly_Valid(t *testing.T) {
 	require.NoError(t, m.Validate())
 }
 
+func TestValidate_GeneratedMode(t *testing.T) {
+	m := &Manifest{
+		Skills:    []SkillRef{{Name: "s"}},
+		Targets:   []string{"opencode"},
+		Generated: GeneratedIgnore,
+	}
+	require.NoError(t, m.Validate())
+
+	m.Generated = "sometimes"
+	err := m.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid generated mode")
+}
+
 func TestValidate_SkillRegistryRequiresPath(t *testing.T) {
 	m := &Manifest{
 		Skills:  []SkillRef{{Name: "s", Registry: "team"}},
@@ -183,6 +198,74 @@ func TestValidate_RegistryWithRef(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestValidate_RefOptionalForDirAndChecksummedArchive(t *testing.T) {
+	valid := strings.Repeat("a", 64)
+	for _, tt := range []struct {
+		reg     RegistryRef
+		wantErr bool
+	}{
+		{RegistryRef{Name: "r", URL: "../shared", Type: "dir"}, false},
+		{RegistryRef{Name: "r", URL: "https://example.com/skills.tgz", SHA256: valid}, false},
+		{RegistryRef{Name: "r", URL: "https://example.com/skills.tgz"}, true},
+		{RegistryRef{Name: "r", URL: "/srv/skills", Type: "git"}, true},
+	} {
+		m := &Manifest{Registries: []RegistryRef{tt.reg}, Skills: []SkillRef{{Name: "x"}}, Targets: []string{"opencode"}}
+		if err := m.Validate(); tt.wantErr {
+			assert.ErrorContains(t, err, "must specify a ref", tt.reg.URL)
+		} else {
+			assert.NoError(t, err, tt.reg.URL)
+		}
+	}
+}
+
+func TestRegistryRef_SourceType(t *testing.T) {
+	assert.Equal(t, RegistryTypeGit, RegistryRef{URL: "https://github.com/org/skills"}.SourceType())
+	assert.Equal(t, RegistryTypeArchive, RegistryRef{URL: "https://artifacts.example.com/skills-1.0.tar.gz"}.SourceType())
+	assert.Equal(t, RegistryTypeArchive, RegistryRef{URL: "https://artifacts.example.com/skills.ZIP?token=x"}.SourceType())
+	assert.Equal(t, RegistryTypeArchive, RegistryRef{URL: "https://artifacts.example.com/download/42", Type: "archive"}.SourceType())
+	assert.Equal(t, RegistryTypeDir, RegistryRef{URL: "file:///mnt/skills"}.SourceType())
+	assert.Equal(t, RegistryTypeDir, RegistryRef{URL: "/mnt/skills"}.SourceType())
+	assert.Equal(t, RegistryTypeGit, RegistryRef{URL: "/srv/git/skills.git", Type: "git"}.SourceType())
+
+	// Local git repositories keep being cloned at their ref unless the
+	// entry asks for type: dir.
+	work := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(work, ".git"), 0o755))
+	bare := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(bare, "HEAD"), []byte("ref: refs/heads/main\n"), 0o644))
+	require.NoError(t, os.Mkdir(filepath.Join(bare, "objects"), 0o755))
+	assert.Equal(t, RegistryTypeGit, RegistryRef{URL: work}.SourceType())
+	assert.Equal(t, RegistryTypeGit, RegistryRef{URL: "file://" + bare}.SourceType())
+	assert.Equal(t, RegistryTypeDir, RegistryRef{URL: work, Type: "dir"}.SourceType())
+}
+
+func TestValidate_RegistryTypeAndChecksum(t *testing.T) {
+	valid := strings.Repeat("a", 64)
+	tests := []struct {
+		reg     RegistryRef
+		wantErr string
+	}{
+		{RegistryRef{Name: "r", URL: "https://example.com/skills.tgz", Ref: "v1", SHA256: valid}, ""},
+		{RegistryRef{Name: "r", URL: "https://example.com/skills", Ref: "v1", Type: "svn"}, "invalid type"},
+		{RegistryRef{Name: "r", URL: "https://example.com/skills", Ref: "v1", SHA256: valid}, "only supported for archive"},
+		{RegistryRef{Name: "r", URL: "https://example.com/skills.zip", Ref: "v1", SHA256: "abc"}, "64 hex characters"},
+		{RegistryRef{Name: "r", URL: "https://ghe.example.com/org/skills", Ref: "v1", Auth: &RegistryAuth{TokenEnv: "GH_TOKEN"}}, ""},
+		{RegistryRef{Name: "r", URL: "https://ghe.example.com/org/skills", Ref: "v1", Auth: &RegistryAuth{TokenEnv: "GH-TOKEN"}}, "not a valid environment variable"},
+		{RegistryRef{Name: "r", URL: "git@ghe.example.com:org/skills", Ref: "v1", Auth: &RegistryAuth{TokenEnv: "T", SSHKey: "id"}}, "mutually exclusive"},
+		{RegistryRef{Name: "r", URL: "https://example.com/skills.tgz", Ref: "v1", Auth: &RegistryAuth{TokenEnv: "T"}}, "only supported for git"},
+	}
+	for _, tt := range tests {
+		m := &Manifest{Registries: []RegistryRef{tt.reg}, Skills: []SkillRef{{Name: "x"}}, Targets: []string{"opencode"}}
+		err := m.Validate()
+		if tt.wantErr == "" {
+			assert.NoError(t, err)
+			continue
+		}
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), tt.wantErr)
+	}
+}
+
 func TestLoadManifest_RefField(t *testing.T) {
 	yamlStr := `registries:
   - name: pinned
@@ -410,6 +493,23 @@ instructions:
 	assert.Equal(t, "global instruction", m.Instructions[0].Content)
 }
 
+func TestLoadMergedManifest_GeneratedProjectOverridesGlobal(t *testing.T) {
+	projectDir := t.TempDir()
+	globalPath := filepath.Join(t.TempDir(), "vibes.yaml")
+
+	require.NoError(t, os.WriteFile(globalPath, []byte("generated: ignore\nskills:\n  - name: a\ntargets:\n  - opencode\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "vibes.yaml"), []byte("skills:\n  - name: b\n"), 0o644))
+
+	m, err := LoadMergedManifest(projectDir, globalPath)
+	require.NoError(t, err)
+	assert.Equal(t, GeneratedIgnore, m.Generated)
+
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "vibes.yaml"), []byte("generated: commit\nskills:\n  - name: b\n"), 0o644))
+	m, err = LoadMergedManifest(projectDir, globalPath)
+	require.NoError(t, err)
+	assert.Equal(t, GeneratedCommit, m.Generated)
+}
+
 func TestLoadMergedManifest_MergesRegistriesByName(t *testing.T) {
 	projectDir := t.TempDir()
 	globalDir := t.TempDir()
@@ -615,6 +715,79 @@ agents:
 	assert.Equal(t, filepath.Join(projectDir, "project-agent.md"), agentMap["project-only-agent"].Path)
 }
 
+func TestLoadMergedManifest_ProjectSuppressesGlobalEntries(t *testing.T) {
+	projectDir := t.TempDir()
+	globalDir := t.TempDir()
+
+	globalContent := `registries:
+  - name: team
+    url: https://example.com/team.git
+    ref: latest
+skills:
+  - name: tdd
+  - name: debugging
+instructions:
+  - name: style
+    content: be nice
+agents:
+  - name: reviewer
+    path: ./reviewer.md
+targets:
+  - opencode
+`
+	projectContent := `registries:
+  - name: team
+    enabled: false
+skills:
+  - name: tdd
+    enabled: false
+  - name: local-skill
+    path: ./skills/local
+instructions:
+  - name: style
+    enabled: false
+agents:
+  - name: reviewer
+    enabled: false
+`
+	globalPath := filepath.Join(globalDir, "vibes.yaml")
+	require.NoError(t, os.WriteFile(globalPath, []byte(globalContent), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "vibes.yaml"), []byte(projectContent), 0o644))
+
+	m, err := LoadMergedManifest(projectDir, globalPath)
+	require.NoError(t, err)
+
+	assert.Empty(t, m.Registries)
+	require.Len(t, m.Skills, 2)
+	assert.Equal(t, "debugging", m.Skills[0].Name)
+	assert.Equal(t, "local-skill", m.Skills[1].Name)
+	assert.Nil(t, m.Instructions)
+	assert.Nil(t, m.Agents)
+	require.NoError(t, m.Validate())
+}
+
+func TestMergeManifests_DropsDisabledEntriesWithoutBase(t *testing.T) {
+	off := false
+	m := MergeManifests(nil, &Manifest{Skills: []SkillRef{{Name: "a"}, {Name: "b", Enabled: &off}}})
+	require.Len(t, m.Skills, 1)
+	assert.Equal(t, "a", m.Skills[0].Name)
+}
+
+func TestValidate_DisabledEntriesNeedOnlyName(t *testing.T) {
+	off := false
+	m := &Manifest{
+		Registries:   []RegistryRef{{Name: "r", Enabled: &off}},
+		Skills:       []SkillRef{{Name: "x"}, {Name: "y", Registry: "r", Enabled: &off}},
+		Instructions: []InstructionRef{{Name: "i", Enabled: &off}},
+		Agents:       []AgentRef{{Name: "a", Enabled: &off}},
+		Targets:      []string{"opencode"},
+	}
+	require.NoError(t, m.Validate())
+
+	m.Agents = append(m.Agents, AgentRef{Name: "", Enabled: &off})
+	require.Error(t, m.Validate())
+}
+
 func TestLoadMergedManifest_NeitherExists(t *testing.T) {
 	projectDir := t.TempDir()
 	globalPath := filepath.Join(t.TempDir(), "vibes.yml")
@@ -696,6 +869,52 @@ func TestResolveManifestPaths_ConvertsRelativeToAbsolute(t *testing.T) {
 	assert.Equal(t, filepath.Join(base, "agents", "a.md"), m.Agents[0].Path)
 }
 
+func TestResolveManifestPaths_Profiles(t *testing.T) {
+	base := t.TempDir()
+	m := &Manifest{Profiles: map[string]Profile{"backend": {
+		Skills:       []SkillRef{{Name: "s", Path: "./skills/s"}},
+		Instructions: []InstructionRef{{Name: "i", Path: "./instructions/i.md"}},
+		Agents:       []AgentRef{{Name: "a", Path: "./agents/a.md"}},
+	}}}
+
+	ResolveManifestPaths(m, base)
+
+	p := m.Profiles["backend"]
+	assert.Equal(t, filepath.Join(base, "skills", "s"), p.Skills[0].Path)
+	assert.Equal(t, filepath.Join(base, "instructions", "i.md"), p.Instructions[0].Path)
+	assert.Equal(t, filepath.Join(base, "agents", "a.md"), p.Agents[0].Path)
+}
+
+func TestResolveManifestPaths_DirRegistries(t *testing.T) {
+	base := t.TempDir()
+	m := &Manifest{
+		Registries: []RegistryRef{
+			{Name: "sibling", URL: "../shared-skills", Type: RegistryTypeDir},
+			{Name: "mount", URL: "file:///mnt/skills"},
+			{Name: "remote", URL: "https://github.com/org/skills"},
+		},
+	}
+
+	ResolveManifestPaths(m, base)
+
+	assert.Equal(t, filepath.Join(filepath.Dir(base), "shared-skills"), m.Registries[0].URL)
+	assert.Equal(t, "file:///mnt/skills", m.Registries[1].URL)
+	assert.Equal(t, "/mnt/skills", m.Registries[1].DirPath())
+	assert.Equal(t, "https://github.com/org/skills", m.Registries[2].URL)
+}
+
+func TestResolveManifestPaths_AuthFiles(t *testing.T) {
+	base := t.TempDir()
+	shared := &RegistryAuth{SSHKey: "keys/deploy", KnownHosts: "~/.ssh/known_hosts"}
+	m := &Manifest{Registries: []RegistryRef{{Name: "private", URL: "git@ghe.example.com:org/skills", Auth: shared}}}
+
+	ResolveManifestPaths(m, base)
+
+	assert.Equal(t, filepath.Join(base, "keys", "deploy"), m.Registries[0].Auth.SSHKey)
+	assert.Equal(t, "~/.ssh/known_hosts", m.Registries[0].Auth.KnownHosts)
+	assert.Equal(t, "keys/deploy", shared.SSHKey, "the original auth block must not be modified")
+}
+
 // --- InstructionRef validation tests ---
 
 func TestValidate_InstructionRef_Valid(t *testing.T) {
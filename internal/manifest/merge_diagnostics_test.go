@@ -31,6 +31,39 @@ func TestComputeOverrideDiagnostics(t *testing.T) {
 	assert.Equal(t, []string{"shared-agent"}, d.Agents)
 }
 
+func TestComputeOverrideDiagnostics_ReportsSuppressions(t *testing.T) {
+	off := false
+	global := &Manifest{
+		Registries:   []RegistryRef{{Name: "team"}},
+		Skills:       []SkillRef{{Name: "tdd"}, {Name: "kept"}},
+		Instructions: []InstructionRef{{Name: "style", Content: "g"}},
+		Agents:       []AgentRef{{Name: "reviewer", Path: "./r.md"}},
+	}
+	local := &Manifest{
+		Registries:   []RegistryRef{{Name: "team", Enabled: &off}},
+		Skills:       []SkillRef{{Name: "tdd", Enabled: &off}, {Name: "kept"}, {Name: "unknown", Enabled: &off}},
+		Instructions: []InstructionRef{{Name: "style", Enabled: &off}},
+		Agents:       []AgentRef{{Name: "reviewer", Enabled: &off}},
+	}
+
+	d := ComputeOverrideDiagnostics(global, local)
+
+	assert.Empty(t, d.Registries)
+	assert.Equal(t, []string{"kept"}, d.Skills)
+	assert.Empty(t, d.Instructions)
+	assert.Empty(t, d.Agents)
+	assert.Equal(t, SuppressedEntries{
+		Registries:   []string{"team"},
+		Skills:       []string{"tdd"},
+		Instructions: []string{"style"},
+		Agents:       []string{"reviewer"},
+	}, d.Suppressed)
+
+	risky := ComputeRiskyOverrideDiagnostics(global, local)
+	assert.Empty(t, risky.Instructions)
+	assert.Empty(t, risky.Agents)
+}
+
 func TestComputeRiskyOverrideDiagnostics(t *testing.T) {
 	global := &Manifest{
 		Skills: []SkillRef{{Name: "same-shape", Path: "./a"}, {Name: "risky-skill"}},
@@ -0,0 +1,209 @@
+package manifest
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// CurrentVersion is the manifest format version this build reads and
+// writes. Manifests without a version field are version 1 when some
+// migration would change their content, and current otherwise.
+const CurrentVersion = 2
+
+// Migration upgrades a manifest document from version From to From+1.
+// Migrations edit the YAML node tree so comments and key order survive, and
+// report whether they changed anything.
+type Migration struct {
+	From        int
+	Description string
+	Apply       func(root *yaml.Node) (changed bool, err error)
+}
+
+// Migrations lists every upgrade step, oldest first. Each CurrentVersion
+// bump adds one.
+var Migrations = []Migration{
+	{From: 1, Description: "convert plain-string instructions to {name, content} entries", Apply: migrateInstructionObjects},
+}
+
+// MigrationResult describes the upgrade of one manifest.
+type MigrationResult struct {
+	From, To int
+	Applied  []Migration
+	// Data is the upgraded manifest; it equals the input when nothing was
+	// applied.
+	Data []byte
+}
+
+// Warnf reports non-fatal problems found while loading manifests, such as
+// outdated versions. The CLI may replace it; by default it writes to stderr.
+var Warnf = func(format string, args ...any) {
+	fmt.Fprintf(os.Stderr, "warning: "+format+"\n", args...)
+}
+
+var warnedOutdated sync.Map
+
+// warnOutdated reports an outdated manifest once per path per process.
+func warnOutdated(path string, version int) {
+	if _, seen := warnedOutdated.LoadOrStore(path, true); seen {
+		return
+	}
+	Warnf("%s uses manifest version %d (current is %d); run 'positive-vibes config migrate' to upgrade it", path, version, CurrentVersion)
+}
+
+// documentVersion reads the version field of a parsed manifest.
+func documentVersion(root *yaml.Node) (int, error) {
+	v := mappingValue(root, "version")
+	if v == nil || isNull(v) {
+		return 1, nil
+	}
+	n, err := strconv.Atoi(v.Value)
+	if err != nil || v.Kind != yaml.ScalarNode || n < 1 {
+		return 0, fmt.Errorf("version must be a positive integer, got %q", v.Value)
+	}
+	if n > CurrentVersion {
+		return 0, fmt.Errorf("manifest version %d is newer than this positive-vibes supports (%d); upgrade positive-vibes", n, CurrentVersion)
+	}
+	return n, nil
+}
+
+// migrateDocument upgrades doc in place to CurrentVersion and returns the
+// version it started at and the migrations that changed it. A document no
+// migration changes is already current, whatever its version field says,
+// and is left untouched.
+func migrateDocument(doc *yaml.Node) (int, []Migration, error) {
+	root := doc.Content[0]
+	from, err := documentVersion(root)
+	if err != nil {
+		return 0, nil, err
+	}
+	var applied []Migration
+	for _, m := range Migrations {
+		if m.From < from {
+			continue
+		}
+		changed, err := m.Apply(root)
+		if err != nil {
+			return from, applied, fmt.Errorf("migrate manifest from version %d: %w", m.From, err)
+		}
+		if changed {
+			applied = append(applied, m)
+		}
+	}
+	if len(applied) == 0 {
+		return CurrentVersion, nil, nil
+	}
+	setMappingValue(root, "version", &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.Itoa(CurrentVersion)}, 0)
+	return from, applied, nil
+}
+
+// MigrateBytes upgrades manifest YAML to CurrentVersion, keeping comments
+// and key order.
+func MigrateBytes(data []byte) (*MigrationResult, error) {
+	doc, err := parseDocument(data)
+	if err != nil {
+		return nil, err
+	}
+	from, applied, err := migrateDocument(doc)
+	if err != nil {
+		return nil, err
+	}
+	res := &MigrationResult{From: from, To: CurrentVersion, Applied: applied, Data: data}
+	if len(applied) == 0 {
+		res.To = from
+		return res, nil
+	}
+	if res.Data, err = encodeDocument(doc, data); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// MigrateFile upgrades the manifest at path in place. Unless dryRun is set,
+// the file is rewritten when any migration applies.
+func MigrateFile(path string, dryRun bool) (*MigrationResult, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read manifest: %w", err)
+	}
+	res, err := MigrateBytes(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(res.Applied) == 0 || dryRun {
+		return res, nil
+	}
+	if err := rewriteFile(path, res.Data); err != nil {
+		return nil, err
+	}
+	warnedOutdated.Delete(path)
+	return res, nil
+}
+
+// migrateInstructionObjects (version 1 -> 2) turns instructions written as
+// plain strings into {name, content} entries, naming them after their text.
+func migrateInstructionObjects(root *yaml.Node) (bool, error) {
+	seq := mappingValue(root, "instructions")
+	if seq == nil || seq.Kind != yaml.SequenceNode {
+		return false, nil
+	}
+	changed := false
+	used := make(map[string]bool)
+	for _, item := range seq.Content {
+		if item.Kind == yaml.MappingNode {
+			if name := mappingValue(item, "name"); name != nil {
+				used[name.Value] = true
+			}
+		}
+	}
+	for i, item := range seq.Content {
+		if item.Kind != yaml.ScalarNode || isNull(item) {
+			continue
+		}
+		name := instructionSlug(item.Value)
+		if name == "" {
+			name = "instruction"
+		}
+		base := name
+		for n := 2; used[name]; n++ {
+			name = fmt.Sprintf("%s-%d", base, n)
+		}
+		used[name] = true
+		changed = true
+
+		content := *item
+		content.HeadComment, content.LineComment, content.FootComment = "", "", ""
+		seq.Content[i] = &yaml.Node{
+			Kind:        yaml.MappingNode,
+			Tag:         "!!map",
+			Line:        item.Line,
+			Column:      item.Column,
+			HeadComment: item.HeadComment,
+			LineComment: item.LineComment,
+			FootComment: item.FootComment,
+			Content: []*yaml.Node{
+				{Kind: yaml.ScalarNode, Tag: "!!str", Value: "name"},
+				{Kind: yaml.ScalarNode, Tag: "!!str", Value: name},
+				{Kind: yaml.ScalarNode, Tag: "!!str", Value: "content"},
+				&content,
+			},
+		}
+	}
+	return changed, nil
+}
+
+var slugSeparators = regexp.MustCompile(`[^a-z0-9]+`)
+
+// instructionSlug derives a short kebab-case name from instruction text.
+func instructionSlug(text string) string {
+	words := strings.Fields(slugSeparators.ReplaceAllString(strings.ToLower(text), " "))
+	if len(words) > 5 {
+		words = words[:5]
+	}
+	return strings.Join(words, "-")
+}
@@ -0,0 +1,162 @@
+package manifest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrations_AreContiguous(t *testing.T) {
+	for i, m := range Migrations {
+		assert.Equal(t, i+1, m.From, "migration %d", i)
+		assert.NotEmpty(t, m.Description)
+	}
+	assert.Equal(t, CurrentVersion, len(Migrations)+1)
+}
+
+func TestMigrateBytes_V1InstructionStrings(t *testing.T) {
+	in := `# Project config
+
+# Skills we use
+skills:
+  - name: tdd # core
+
+instructions:
+  - Always use TypeScript for frontend code
+  # kept with its entry
+  - "Prefer small functions"
+  - name: prefer-small-functions
+    content: Taken
+
+targets:
+  - cursor
+`
+	want := `# Project config
+
+version: 2
+
+# Skills we use
+skills:
+  - name: tdd # core
+
+instructions:
+  - name: always-use-typescript-for-frontend
+    content: Always use TypeScript for frontend code
+  # kept with its entry
+  - name: prefer-small-functions-2
+    content: "Prefer small functions"
+  - name: prefer-small-functions
+    content: Taken
+
+targets:
+  - cursor
+`
+	res, err := MigrateBytes([]byte(in))
+	require.NoError(t, err)
+	assert.Equal(t, 1, res.From)
+	assert.Equal(t, CurrentVersion, res.To)
+	require.Len(t, res.Applied, 1)
+	assert.Equal(t, want, string(res.Data))
+
+	again, err := MigrateBytes(res.Data)
+	require.NoError(t, err)
+	assert.Empty(t, again.Applied)
+	assert.Equal(t, res.Data, again.Data)
+}
+
+func TestMigrateBytes_LeavesUnchangedContentAlone(t *testing.T) {
+	in := "skills:\n  - name: tdd\ntargets: [cursor]\n"
+	res, err := MigrateBytes([]byte(in))
+	require.NoError(t, err)
+	assert.Empty(t, res.Applied)
+	assert.Equal(t, CurrentVersion, res.From, "a manifest no migration changes is current")
+	assert.Equal(t, in, string(res.Data))
+}
+
+func TestMigrateBytes_RejectsNewerVersion(t *testing.T) {
+	_, err := MigrateBytes([]byte(fmt.Sprintf("version: %d\n", CurrentVersion+1)))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "newer than this positive-vibes supports")
+}
+
+func TestMigrateBytes_RejectsInvalidVersion(t *testing.T) {
+	_, err := MigrateBytes([]byte("version: two\n"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "positive integer")
+}
+
+func TestLoadManifestFromBytes_UpgradesLegacyInstructions(t *testing.T) {
+	m, err := LoadManifestFromBytes([]byte("instructions:\n  - Use tabs\ntargets: [cursor]\n"))
+	require.NoError(t, err)
+	assert.Equal(t, CurrentVersion, m.Version)
+	assert.Equal(t, []InstructionRef{{Name: "use-tabs", Content: "Use tabs"}}, m.Instructions)
+}
+
+func TestLoadManifest_WarnsOnceForOutdatedVersion(t *testing.T) {
+	var warnings []string
+	orig := Warnf
+	Warnf = func(format string, args ...any) { warnings = append(warnings, fmt.Sprintf(format, args...)) }
+	t.Cleanup(func() { Warnf = orig })
+
+	dir := t.TempDir()
+	old := filepath.Join(dir, "old.yaml")
+	current := filepath.Join(dir, "current.yaml")
+	unversioned := filepath.Join(dir, "unversioned.yaml")
+	require.NoError(t, os.WriteFile(old, []byte("instructions:\n  - Use tabs\n"), 0o644))
+	require.NoError(t, os.WriteFile(current, []byte(fmt.Sprintf("version: %d\nskills:\n  - name: tdd\n", CurrentVersion)), 0o644))
+	require.NoError(t, os.WriteFile(unversioned, []byte("skills:\n  - name: tdd\n"), 0o644))
+
+	for i := 0; i < 2; i++ {
+		for _, p := range []string{old, current, unversioned} {
+			_, err := LoadManifest(p)
+			require.NoError(t, err)
+		}
+	}
+	require.Len(t, warnings, 1)
+	assert.Contains(t, warnings[0], old)
+	assert.Contains(t, warnings[0], "config migrate")
+}
+
+func TestMigrateFile_RewritesInPlace(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vibes.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("# keep me\ninstructions:\n  - Use tabs\n"), 0o600))
+
+	dry, err := MigrateFile(path, true)
+	require.NoError(t, err)
+	require.Len(t, dry.Applied, 1)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "# keep me\ninstructions:\n  - Use tabs\n", string(data), "dry run must not write")
+
+	_, err = MigrateFile(path, false)
+	require.NoError(t, err)
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "# keep me")
+	assert.Contains(t, string(data), "version: 2")
+	assert.Contains(t, string(data), "- name: use-tabs")
+
+	fi, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), fi.Mode().Perm())
+}
+
+func TestSaveManifest_WritesCurrentVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vibes.yaml")
+	require.NoError(t, SaveManifest(&Manifest{Skills: []SkillRef{{Name: "tdd"}}, Targets: []string{"cursor"}}, path))
+
+	m, err := LoadManifest(path)
+	require.NoError(t, err)
+	assert.Equal(t, CurrentVersion, m.Version)
+}
+
+func TestValidateSchema_AcceptsLegacyInstructions(t *testing.T) {
+	assert.Empty(t, ValidateSchema([]byte("instructions:\n  - Use tabs\n")))
+	errs := ValidateSchema([]byte(fmt.Sprintf("version: %d\n", CurrentVersion+1)))
+	require.Len(t, errs, 1)
+	assert.Equal(t, "version", errs[0].Path)
+}
@@ -0,0 +1,165 @@
+package manifest
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// Policy restricts where resources may come from. It is only allowed in the
+// system and global manifests, so projects can't loosen it.
+type Policy struct {
+	// AllowedRegistries lists registry URL patterns (path.Match syntax, e.g.
+	// "https://github.com/myorg/*"). When set, every registry URL must match
+	// one of them.
+	AllowedRegistries []string `yaml:"allowed_registries,omitempty"`
+	// DeniedSkills lists skill names that may not be installed or applied.
+	DeniedSkills []string `yaml:"denied_skills,omitempty"`
+	// RequirePinnedRefs rejects registries that track "latest".
+	RequirePinnedRefs bool `yaml:"require_pinned_refs,omitempty"`
+	// DenyInlineInstructions rejects instructions written as inline content.
+	DenyInlineInstructions bool `yaml:"deny_inline_instructions,omitempty"`
+}
+
+// policyLayers are the layer kinds allowed to define a policy.
+var policyLayers = map[string]bool{LayerSystem: true, LayerGlobal: true}
+
+// mergePolicies combines the policies of two layers so that the result is at
+// least as strict as base: denied skills are combined, either layer can turn
+// on the boolean rules, and overlay can only set AllowedRegistries when base
+// leaves it open.
+func mergePolicies(base, overlay *Policy) *Policy {
+	if base == nil {
+		return overlay
+	}
+	if overlay == nil {
+		return base
+	}
+	out := *base
+	if len(out.AllowedRegistries) == 0 {
+		out.AllowedRegistries = overlay.AllowedRegistries
+	}
+	out.DeniedSkills = upsertByName(append([]string(nil), base.DeniedSkills...), overlay.DeniedSkills, func(s string) string { return s }, nil)
+	out.RequirePinnedRefs = base.RequirePinnedRefs || overlay.RequirePinnedRefs
+	out.DenyInlineInstructions = base.DenyInlineInstructions || overlay.DenyInlineInstructions
+	return &out
+}
+
+// validate checks the policy's URL patterns.
+func (p *Policy) validate() error {
+	for _, pat := range p.AllowedRegistries {
+		if _, err := path.Match(pat, ""); err != nil {
+			return fmt.Errorf("policy: invalid allowed_registries pattern %q: %w", pat, err)
+		}
+	}
+	return nil
+}
+
+// PolicyViolation is a manifest entry the policy rejects.
+type PolicyViolation struct {
+	// Field names the entry, e.g. "registries/team" (see SourceKey).
+	Field string
+	// Message explains the violation and how to resolve it.
+	Message string
+}
+
+func (v PolicyViolation) Error() string {
+	return v.Field + ": " + v.Message
+}
+
+// PolicyError reports every policy violation in a manifest.
+type PolicyError struct {
+	Violations []PolicyViolation
+}
+
+func (e *PolicyError) Error() string {
+	lines := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		lines[i] = "  - " + v.Error()
+	}
+	return fmt.Sprintf("%d policy violation(s):\n%s", len(e.Violations), strings.Join(lines, "\n"))
+}
+
+// CheckRegistry reports whether policy allows the registry. A nil policy
+// allows everything.
+func (p *Policy) CheckRegistry(r RegistryRef) []PolicyViolation {
+	if p == nil {
+		return nil
+	}
+	field := SourceKey("registries", r.Name)
+	var out []PolicyViolation
+	if len(p.AllowedRegistries) > 0 && !matchesAny(p.AllowedRegistries, r.URL) {
+		out = append(out, PolicyViolation{Field: field, Message: fmt.Sprintf("url %q is not an approved registry (allowed: %s); use an approved registry or ask your administrator to allow it", withoutUserinfo(r.URL), strings.Join(p.AllowedRegistries, ", "))})
+	}
+	if p.RequirePinnedRefs && r.NeedsRef() && (r.Ref == "" || r.Ref == "latest") {
+		out = append(out, PolicyViolation{Field: field, Message: "policy requires a pinned ref; set ref to a tag or commit SHA instead of \"latest\""})
+	}
+	return out
+}
+
+// CheckSkill reports whether policy allows the skill.
+func (p *Policy) CheckSkill(s SkillRef) []PolicyViolation {
+	if p == nil || !containsValue(p.DeniedSkills, s.Name) {
+		return nil
+	}
+	return []PolicyViolation{{Field: SourceKey("skills", s.Name), Message: fmt.Sprintf("skill is denied by policy; remove it with 'positive-vibes remove skills %s'", s.Name)}}
+}
+
+// CheckInstruction reports whether policy allows the instruction.
+func (p *Policy) CheckInstruction(i InstructionRef) []PolicyViolation {
+	if p == nil || !p.DenyInlineInstructions || i.Content == "" {
+		return nil
+	}
+	return []PolicyViolation{{Field: SourceKey("instructions", i.Name), Message: "policy disallows inline content; move the text to a file and reference it with path"}}
+}
+
+// CheckPolicy returns the entries of m that m.Policy rejects, or nil when m
+// has no policy. Disabled entries are ignored.
+func (m *Manifest) CheckPolicy() []PolicyViolation {
+	p := m.Policy
+	if p == nil {
+		return nil
+	}
+	m = m.WithoutDisabled()
+	var out []PolicyViolation
+	for _, r := range m.Registries {
+		out = append(out, p.CheckRegistry(r)...)
+	}
+	for _, s := range m.Skills {
+		out = append(out, p.CheckSkill(s)...)
+	}
+	for _, i := range m.Instructions {
+		out = append(out, p.CheckInstruction(i)...)
+	}
+	return out
+}
+
+// EnforcePolicy returns a *PolicyError listing every entry of m that
+// m.Policy rejects, or nil.
+func (m *Manifest) EnforcePolicy() error {
+	if v := m.CheckPolicy(); len(v) > 0 {
+		return &PolicyError{Violations: v}
+	}
+	return nil
+}
+
+// withoutUserinfo strips credentials from a registry URL so policy messages
+// never print a token.
+func withoutUserinfo(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.User == nil {
+		return rawURL
+	}
+	u.User = nil
+	return u.String()
+}
+
+func matchesAny(patterns []string, s string) bool {
+	for _, pat := range patterns {
+		if ok, _ := path.Match(pat, s); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,156 @@
+package manifest
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ProfileEnvVar selects profiles (comma-separated) when --profile is not given.
+const ProfileEnvVar = "PV_PROFILE"
+
+// Profile adjusts the base manifest for one audience (e.g. "frontend", "ci").
+// Entries are added (or override same-named base entries) and names listed
+// under Remove are dropped. Locked base entries are left as they are.
+type Profile struct {
+	Skills       []SkillRef       `yaml:"skills,omitempty"`
+	Instructions []InstructionRef `yaml:"instructions,omitempty"`
+	Agents       []AgentRef       `yaml:"agents,omitempty"`
+	Targets      []string         `yaml:"targets,omitempty" jsonschema:"enum=targets"`
+	Remove       ProfileRemovals  `yaml:"remove,omitempty"`
+}
+
+// ProfileRemovals lists base entries, by name, that a profile drops.
+type ProfileRemovals struct {
+	Skills       []string `yaml:"skills,omitempty"`
+	Instructions []string `yaml:"instructions,omitempty"`
+	Agents       []string `yaml:"agents,omitempty"`
+	Targets      []string `yaml:"targets,omitempty" jsonschema:"enum=targets"`
+}
+
+// ParseProfileList splits a comma-separated profile list, as accepted by
+// PV_PROFILE, dropping empty entries.
+func ParseProfileList(s string) []string {
+	var out []string
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// ProfileNames returns the profiles m defines, sorted.
+func (m *Manifest) ProfileNames() []string {
+	names := make([]string, 0, len(m.Profiles))
+	for name := range m.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// WithProfiles returns a copy of m with the named profiles applied in order,
+// so later profiles see (and can undo) the changes of earlier ones. The
+// result defines no profiles of its own.
+func (m *Manifest) WithProfiles(names []string) (*Manifest, error) {
+	out := *m
+	out.Profiles = nil
+	out.Skills = append([]SkillRef(nil), m.Skills...)
+	out.Instructions = append([]InstructionRef(nil), m.Instructions...)
+	out.Agents = append([]AgentRef(nil), m.Agents...)
+	out.Targets = append([]string(nil), m.Targets...)
+
+	for _, name := range names {
+		p, ok := m.Profiles[name]
+		if !ok {
+			available := "none defined"
+			if len(m.Profiles) > 0 {
+				available = "available: " + strings.Join(m.ProfileNames(), ", ")
+			}
+			return nil, fmt.Errorf("unknown profile %q (%s)", name, available)
+		}
+
+		out.Skills = removeByName(out.Skills, p.Remove.Skills, func(s SkillRef) string { return s.Name }, func(s SkillRef) bool { return s.Locked })
+		out.Instructions = removeByName(out.Instructions, p.Remove.Instructions, func(i InstructionRef) string { return i.Name }, func(i InstructionRef) bool { return i.Locked })
+		out.Agents = removeByName(out.Agents, p.Remove.Agents, func(a AgentRef) string { return a.Name }, func(a AgentRef) bool { return a.Locked })
+		out.Targets = removeByName(out.Targets, p.Remove.Targets, func(t string) string { return t }, nil)
+
+		out.Skills = upsertByName(out.Skills, p.Skills, func(s SkillRef) string { return s.Name }, func(s SkillRef) bool { return s.Locked })
+		out.Instructions = upsertByName(out.Instructions, p.Instructions, func(i InstructionRef) string { return i.Name }, func(i InstructionRef) bool { return i.Locked })
+		out.Agents = upsertByName(out.Agents, p.Agents, func(a AgentRef) string { return a.Name }, func(a AgentRef) bool { return a.Locked })
+		out.Targets = upsertByName(out.Targets, p.Targets, func(t string) string { return t }, nil)
+	}
+
+	if len(out.Instructions) == 0 {
+		out.Instructions = nil
+	}
+	if len(out.Agents) == 0 {
+		out.Agents = nil
+	}
+	return out.WithoutDisabled(), nil
+}
+
+// removeByName drops the items with the given names, except those locked
+// reports as locked (locked may be nil).
+func removeByName[T any](items []T, names []string, name func(T) string, locked func(T) bool) []T {
+	if len(names) == 0 {
+		return items
+	}
+	drop := make(map[string]bool, len(names))
+	for _, n := range names {
+		drop[n] = true
+	}
+	var out []T
+	for _, it := range items {
+		if !drop[name(it)] || (locked != nil && locked(it)) {
+			out = append(out, it)
+		}
+	}
+	return out
+}
+
+// upsertByName replaces same-named items with adds, leaving items locked
+// reports as locked (locked may be nil) unchanged, and appends the rest.
+func upsertByName[T any](items, adds []T, name func(T) string, locked func(T) bool) []T {
+	for _, add := range adds {
+		replaced := false
+		for i := range items {
+			if name(items[i]) == name(add) {
+				if locked == nil || !locked(items[i]) {
+					items[i] = add
+				}
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			items = append(items, add)
+		}
+	}
+	return items
+}
+
+// ProfileLayers describes the entries the named profiles add as layers of
+// kind LayerProfile, for attributing values with ValueSources. Unknown names
+// are skipped.
+func (m *Manifest) ProfileLayers(names []string) []Layer {
+	var layers []Layer
+	for _, name := range names {
+		p, ok := m.Profiles[name]
+		if !ok {
+			continue
+		}
+		layers = append(layers, Layer{
+			Source: name,
+			Kind:   LayerProfile,
+			Manifest: &Manifest{
+				Skills:       p.Skills,
+				Instructions: p.Instructions,
+				Agents:       p.Agents,
+				Targets:      p.Targets,
+			},
+		})
+	}
+	return layers
+}
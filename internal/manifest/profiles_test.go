@@ -0,0 +1,109 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const profilesManifest = `skills:
+  - name: conventional-commits
+  - name: code-review
+agents:
+  - name: reviewer
+    path: ./agents/reviewer.md
+targets:
+  - vscode-copilot
+profiles:
+  frontend:
+    agents:
+      - name: ui-designer
+        path: ./agents/ui.md
+    targets:
+      - cursor
+  ci:
+    remove:
+      skills:
+        - code-review
+      agents:
+        - reviewer
+        - ui-designer
+`
+
+func TestWithProfiles_ComposeInOrder(t *testing.T) {
+	dir := t.TempDir()
+	writeManifestFile(t, dir, profilesManifest)
+	m, err := LoadMergedManifest(dir, filepath.Join(t.TempDir(), "vibes.yaml"))
+	require.NoError(t, err)
+
+	fe, err := m.WithProfiles([]string{"frontend"})
+	require.NoError(t, err)
+	require.Len(t, fe.Agents, 2)
+	assert.Equal(t, filepath.Join(dir, "agents", "ui.md"), fe.Agents[1].Path)
+	assert.Equal(t, []string{"vscode-copilot", "cursor"}, fe.Targets)
+	assert.Nil(t, fe.Profiles)
+
+	both, err := m.WithProfiles([]string{"frontend", "ci"})
+	require.NoError(t, err)
+	assert.Len(t, both.Skills, 1)
+	assert.Nil(t, both.Agents)
+	assert.Equal(t, []string{"vscode-copilot", "cursor"}, both.Targets)
+
+	// The base manifest is left untouched.
+	assert.Len(t, m.Agents, 1)
+	assert.Len(t, m.Skills, 2)
+}
+
+func TestWithProfiles_KeepsLockedEntries(t *testing.T) {
+	m := &Manifest{
+		Skills: []SkillRef{{Name: "baseline", Locked: true}, {Name: "other"}},
+		Profiles: map[string]Profile{
+			"ci": {
+				Skills: []SkillRef{{Name: "baseline", Path: "./mine"}},
+				Remove: ProfileRemovals{Skills: []string{"baseline", "other"}},
+			},
+		},
+	}
+	out, err := m.WithProfiles([]string{"ci"})
+	require.NoError(t, err)
+	assert.Equal(t, []SkillRef{{Name: "baseline", Locked: true}}, out.Skills)
+}
+
+func TestWithProfiles_UnknownProfile(t *testing.T) {
+	m := &Manifest{Profiles: map[string]Profile{"ci": {}, "backend": {}}}
+	_, err := m.WithProfiles([]string{"mobile"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown profile "mobile" (available: backend, ci)`)
+}
+
+func TestLoadMergedManifest_ProjectProfileReplacesGlobal(t *testing.T) {
+	projectDir := t.TempDir()
+	globalPath := filepath.Join(t.TempDir(), "vibes.yaml")
+	require.NoError(t, os.WriteFile(globalPath, []byte("profiles:\n  ci:\n    targets: [cursor]\n  mine:\n    targets: [opencode]\n"), 0o644))
+	writeManifestFile(t, projectDir, "skills:\n  - name: a\nprofiles:\n  ci:\n    remove:\n      skills: [a]\n")
+
+	m, err := LoadMergedManifest(projectDir, globalPath)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ci", "mine"}, m.ProfileNames())
+	assert.Empty(t, m.Profiles["ci"].Targets)
+	assert.Equal(t, []string{"a"}, m.Profiles["ci"].Remove.Skills)
+}
+
+func TestValidate_ProfileTargets(t *testing.T) {
+	m := &Manifest{
+		Skills:   []SkillRef{{Name: "s"}},
+		Targets:  []string{"opencode"},
+		Profiles: map[string]Profile{"bad": {Targets: []string{"notepad"}}},
+	}
+	err := m.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `profile "bad": invalid target: notepad`)
+}
+
+func TestParseProfileList(t *testing.T) {
+	assert.Equal(t, []string{"frontend", "ci"}, ParseProfileList(" frontend, ,ci "))
+	assert.Nil(t, ParseProfileList(""))
+}
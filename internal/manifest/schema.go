@@ -0,0 +1,330 @@
+package manifest
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// JSONSchema is the subset of JSON Schema (draft-07) used to describe
+// vibes.yaml.
+type JSONSchema struct {
+	Schema     string                 `json:"$schema,omitempty"`
+	Title      string                 `json:"title,omitempty"`
+	Ref        string                 `json:"$ref,omitempty"`
+	Type       string                 `json:"type,omitempty"`
+	Properties map[string]*JSONSchema `json:"properties,omitempty"`
+	Required   []string               `json:"required,omitempty"`
+	// AdditionalProperties is false for closed objects or a *JSONSchema
+	// describing the values of free-form keys.
+	AdditionalProperties any         `json:"additionalProperties,omitempty"`
+	Items                *JSONSchema `json:"items,omitempty"`
+	Enum                 []string    `json:"enum,omitempty"`
+	MinLength            int         `json:"minLength,omitempty"`
+	Const                any         `json:"const,omitempty"`
+	// If selects Then or Else to apply to the same value.
+	If          *JSONSchema            `json:"if,omitempty"`
+	Then        *JSONSchema            `json:"then,omitempty"`
+	Else        *JSONSchema            `json:"else,omitempty"`
+	Definitions map[string]*JSONSchema `json:"definitions,omitempty"`
+}
+
+// schemaValueSets are the value lists referenced by `jsonschema:"enum=..."`
+// and `jsonschema:"keys=..."` struct tags.
+var schemaValueSets = map[string][]string{
+	"targets":        ValidTargets,
+	"generated":      {GeneratedCommit, GeneratedIgnore},
+	"registry-paths": {"skills", "instructions", "agents"},
+	"registry-types": RegistryTypes,
+}
+
+// Schema generates the JSON Schema for vibes.yaml from the Manifest type.
+// Field names come from yaml tags; `jsonschema` tags add constraints:
+// "required", "required-when-enabled" (unless the entry sets enabled: false),
+// "enum=<set>" and "keys=<set>" (see schemaValueSets).
+func Schema() *JSONSchema {
+	defs := make(map[string]*JSONSchema)
+	root := structSchema(reflect.TypeOf(Manifest{}), defs)
+	root.Schema = "http://json-schema.org/draft-07/schema#"
+	root.Title = "positive-vibes manifest (vibes.yaml)"
+	root.Definitions = defs
+	return root
+}
+
+// SchemaJSON returns Schema as indented JSON.
+func SchemaJSON() ([]byte, error) {
+	data, err := json.MarshalIndent(Schema(), "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("marshal schema: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+func typeSchema(t reflect.Type, defs map[string]*JSONSchema) *JSONSchema {
+	switch t.Kind() {
+	case reflect.String:
+		return &JSONSchema{Type: "string"}
+	case reflect.Bool:
+		return &JSONSchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &JSONSchema{Type: "integer"}
+	case reflect.Slice:
+		return &JSONSchema{Type: "array", Items: typeSchema(t.Elem(), defs)}
+	case reflect.Map:
+		return &JSONSchema{Type: "object", AdditionalProperties: typeSchema(t.Elem(), defs)}
+	case reflect.Pointer:
+		return typeSchema(t.Elem(), defs)
+	case reflect.Struct:
+		if _, ok := defs[t.Name()]; !ok {
+			defs[t.Name()] = nil // reserve the name for recursive types
+			defs[t.Name()] = structSchema(t, defs)
+		}
+		return &JSONSchema{Ref: "#/definitions/" + t.Name()}
+	}
+	return &JSONSchema{}
+}
+
+func structSchema(t reflect.Type, defs map[string]*JSONSchema) *JSONSchema {
+	s := &JSONSchema{Type: "object", Properties: map[string]*JSONSchema{}, AdditionalProperties: false}
+	var whenEnabled []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		if name == "-" || !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		prop := typeSchema(f.Type, defs)
+		for _, opt := range strings.Split(f.Tag.Get("jsonschema"), ",") {
+			key, val, _ := strings.Cut(opt, "=")
+			switch key {
+			case "required", "required-when-enabled":
+				if key == "required" {
+					s.Required = append(s.Required, name)
+				} else {
+					whenEnabled = append(whenEnabled, name)
+				}
+				if prop.Type == "string" {
+					prop.MinLength = 1
+				}
+			case "enum":
+				if prop.Items != nil {
+					prop.Items.Enum = schemaValueSets[val]
+				} else {
+					prop.Enum = schemaValueSets[val]
+				}
+			case "keys":
+				prop.Properties = map[string]*JSONSchema{}
+				for _, k := range schemaValueSets[val] {
+					prop.Properties[k] = prop.AdditionalProperties.(*JSONSchema)
+				}
+				prop.AdditionalProperties = false
+			}
+		}
+		s.Properties[name] = prop
+	}
+	if len(whenEnabled) > 0 {
+		s.If = &JSONSchema{
+			Type:       "object",
+			Properties: map[string]*JSONSchema{"enabled": {Const: false}},
+			Required:   []string{"enabled"},
+		}
+		s.Else = &JSONSchema{Type: "object", Required: whenEnabled}
+	}
+	return s
+}
+
+// SchemaError is a schema violation found in a manifest, positioned at the
+// offending YAML node.
+type SchemaError struct {
+	// Path locates the value, e.g. "skills[0].name"; empty for the document.
+	Path    string
+	Line    int
+	Column  int // 0 when only the line is known
+	Message string
+}
+
+func (e SchemaError) Error() string {
+	msg := e.Message
+	if e.Path != "" {
+		msg = e.Path + ": " + msg
+	}
+	if e.Column == 0 {
+		return fmt.Sprintf("line %d: %s", e.Line, msg)
+	}
+	return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, msg)
+}
+
+var yamlLineErr = regexp.MustCompile(`^yaml: line (\d+): (.*)$`)
+
+// ValidateSchema checks manifest YAML against Schema and returns the
+// violations in document order. YAML syntax errors are reported as a single
+// error. Null values are treated as absent, matching how they unmarshal, and
+// older format versions are migrated before checking.
+func ValidateSchema(data []byte) []SchemaError {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		msg := strings.TrimPrefix(err.Error(), "yaml: ")
+		line := 0
+		if m := yamlLineErr.FindStringSubmatch(err.Error()); m != nil {
+			line, _ = strconv.Atoi(m[1])
+			msg = m[2]
+		}
+		return []SchemaError{{Line: line, Message: msg}}
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+		return nil
+	}
+
+	schema := Schema()
+	v := &schemaValidator{defs: schema.Definitions}
+	root := doc.Content[0]
+	if root.Kind == yaml.MappingNode {
+		// Check older formats as they load: upgraded to the current version.
+		if _, _, err := migrateDocument(&doc); err != nil {
+			if ver := mappingValue(root, "version"); ver != nil && nodeType(ver) == "integer" {
+				v.fail(ver, "version", "%v", err)
+			}
+		}
+	}
+	v.check(schema, root, "")
+	sort.SliceStable(v.errs, func(i, j int) bool {
+		if v.errs[i].Line != v.errs[j].Line {
+			return v.errs[i].Line < v.errs[j].Line
+		}
+		return v.errs[i].Column < v.errs[j].Column
+	})
+	return v.errs
+}
+
+type schemaValidator struct {
+	defs map[string]*JSONSchema
+	errs []SchemaError
+}
+
+func (v *schemaValidator) fail(n *yaml.Node, path, format string, args ...any) {
+	v.errs = append(v.errs, SchemaError{Path: path, Line: n.Line, Column: n.Column, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *schemaValidator) check(s *JSONSchema, n *yaml.Node, path string) {
+	if s.Ref != "" {
+		s = v.defs[strings.TrimPrefix(s.Ref, "#/definitions/")]
+	}
+	if n.Kind == yaml.AliasNode && n.Alias != nil {
+		n = n.Alias
+	}
+	if isNull(n) {
+		return
+	}
+	if s.Const != nil {
+		var got any
+		if err := n.Decode(&got); err != nil || !reflect.DeepEqual(got, s.Const) {
+			v.fail(n, path, "must be %v", s.Const)
+			return
+		}
+	}
+	if got := nodeType(n); s.Type != "" && got != s.Type && !(s.Type == "string" && isNumber(got)) {
+		v.fail(n, path, "expected %s, got %s", s.Type, got)
+		return
+	}
+
+	switch s.Type {
+	case "object":
+		seen := make(map[string]bool)
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			k, val := n.Content[i], n.Content[i+1]
+			seen[k.Value] = !isNull(val)
+			childPath := k.Value
+			if path != "" {
+				childPath = path + "." + k.Value
+			}
+			if prop, ok := s.Properties[k.Value]; ok {
+				v.check(prop, val, childPath)
+			} else if extra, ok := s.AdditionalProperties.(*JSONSchema); ok {
+				v.check(extra, val, childPath)
+			} else if s.AdditionalProperties == false {
+				v.fail(k, childPath, "unknown property %q", k.Value)
+			}
+		}
+		for _, req := range s.Required {
+			if !seen[req] {
+				v.fail(n, path, "missing required property %q", req)
+			}
+		}
+		if s.If != nil {
+			branch := s.Else
+			if v.matches(s.If, n, path) {
+				branch = s.Then
+			}
+			if branch != nil {
+				v.check(branch, n, path)
+			}
+		}
+	case "array":
+		for i, item := range n.Content {
+			v.check(s.Items, item, fmt.Sprintf("%s[%d]", path, i))
+		}
+	case "string":
+		if len(n.Value) < s.MinLength {
+			v.fail(n, path, "must not be empty")
+		}
+		if len(s.Enum) > 0 && !containsValue(s.Enum, n.Value) && !strings.Contains(n.Value, "${") {
+			v.fail(n, path, "%q is not one of: %s", n.Value, strings.Join(s.Enum, ", "))
+		}
+	}
+}
+
+// matches reports whether n satisfies s, without recording violations.
+func (v *schemaValidator) matches(s *JSONSchema, n *yaml.Node, path string) bool {
+	sub := &schemaValidator{defs: v.defs}
+	sub.check(s, n, path)
+	return len(sub.errs) == 0
+}
+
+func isNull(n *yaml.Node) bool {
+	return n.Kind == yaml.ScalarNode && n.ShortTag() == "!!null"
+}
+
+// nodeType names a node's JSON Schema type.
+func nodeType(n *yaml.Node) string {
+	switch n.Kind {
+	case yaml.MappingNode:
+		return "object"
+	case yaml.SequenceNode:
+		return "array"
+	}
+	switch n.ShortTag() {
+	case "!!int":
+		return "integer"
+	case "!!float":
+		return "number"
+	case "!!bool":
+		return "boolean"
+	}
+	return "string"
+}
+
+// isNumber reports whether a nodeType is numeric. An unquoted number such as
+// "ref: 1.2" still decodes into a string field, so it is accepted where the
+// schema expects a string.
+func isNumber(typ string) bool {
+	return typ == "integer" || typ == "number"
+}
+
+func containsValue(values []string, v string) bool {
+	for _, s := range values {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
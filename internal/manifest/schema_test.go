@@ -0,0 +1,151 @@
+package manifest
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchema_CoversEveryManifestField(t *testing.T) {
+	s := Schema()
+	typ := reflect.TypeOf(Manifest{})
+	for i := 0; i < typ.NumField(); i++ {
+		name, _, _ := strings.Cut(typ.Field(i).Tag.Get("yaml"), ",")
+		assert.Contains(t, s.Properties, name)
+	}
+	assert.Equal(t, false, s.AdditionalProperties)
+	assert.Equal(t, ValidTargets, s.Properties["targets"].Items.Enum)
+
+	reg := s.Definitions["RegistryRef"]
+	require.NotNil(t, reg)
+	assert.ElementsMatch(t, []string{"name"}, reg.Required)
+	require.NotNil(t, reg.Else)
+	assert.ElementsMatch(t, []string{"url"}, reg.Else.Required)
+	assert.Contains(t, reg.Properties["paths"].Properties, "skills")
+	assert.Contains(t, s.Definitions, "Profile")
+}
+
+func TestSchemaJSON_IsValidJSON(t *testing.T) {
+	data, err := SchemaJSON()
+	require.NoError(t, err)
+
+	var decoded map[string]any
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, "http://json-schema.org/draft-07/schema#", decoded["$schema"])
+	assert.Contains(t, decoded, "definitions")
+}
+
+func TestValidateSchema_ValidManifest(t *testing.T) {
+	assert.Empty(t, ValidateSchema([]byte(exampleYAML)))
+}
+
+func TestValidateSchema_DisabledEntriesNeedOnlyName(t *testing.T) {
+	errs := ValidateSchema([]byte(`registries:
+  - name: team
+    enabled: false
+  - name: other
+    ref: latest
+agents:
+  - name: reviewer
+    enabled: false
+  - name: planner
+    enabled: true
+`))
+	var msgs []string
+	for _, e := range errs {
+		msgs = append(msgs, e.Error())
+	}
+	assert.Equal(t, []string{
+		`line 4, column 5: registries[1]: missing required property "url"`,
+		`line 9, column 5: agents[1]: missing required property "path"`,
+	}, msgs)
+}
+
+func TestValidateSchema_EmptyDocument(t *testing.T) {
+	assert.Empty(t, ValidateSchema(nil))
+	assert.Empty(t, ValidateSchema([]byte("skills:\ntargets:\n")))
+}
+
+func TestValidateSchema_ReportsPositions(t *testing.T) {
+	errs := ValidateSchema([]byte(`skills:
+  - name: tdd
+    registri: x
+  - path: ./local
+targets: [cursor, vscode]
+generated: maybe
+registries:
+  - name: r
+    url: https://example.com/r.git
+    ref: [1]
+    paths: {skill: x}
+`))
+
+	got := make([]string, len(errs))
+	for i, e := range errs {
+		got[i] = e.Error()
+	}
+	assert.Equal(t, []string{
+		`line 3, column 5: skills[0].registri: unknown property "registri"`,
+		`line 4, column 5: skills[1]: missing required property "name"`,
+		`line 5, column 19: targets[1]: "vscode" is not one of: vscode-copilot, opencode, cursor`,
+		`line 6, column 12: generated: "maybe" is not one of: commit, ignore`,
+		`line 10, column 10: registries[0].ref: expected string, got array`,
+		`line 11, column 13: registries[0].paths.skill: unknown property "skill"`,
+	}, got)
+}
+
+func TestValidateSchema_NumbersAreStrings(t *testing.T) {
+	data := []byte(`registries:
+  - name: a
+    url: https://example.com/a.git
+    ref: 1.2
+  - name: b
+    url: https://example.com/b.git
+    ref: 2024
+`)
+	assert.Empty(t, ValidateSchema(data), "unquoted numeric refs decode as strings")
+
+	m, err := LoadManifestFromBytes(data)
+	require.NoError(t, err)
+	assert.Equal(t, "1.2", m.Registries[0].Ref)
+	assert.Equal(t, "2024", m.Registries[1].Ref)
+}
+
+func TestValidateSchema_WrongContainerType(t *testing.T) {
+	errs := ValidateSchema([]byte("skills:\n  name: tdd\ntargets: cursor\n"))
+	require.Len(t, errs, 2)
+	assert.Equal(t, SchemaError{Path: "skills", Line: 2, Column: 3, Message: "expected array, got object"}, errs[0])
+	assert.Equal(t, SchemaError{Path: "targets", Line: 3, Column: 10, Message: "expected array, got string"}, errs[1])
+}
+
+func TestValidateSchema_ChecksProfiles(t *testing.T) {
+	errs := ValidateSchema([]byte(`profiles:
+  ci:
+    agents:
+      - name: reviewer
+    remove:
+      skill: [tdd]
+`))
+	require.Len(t, errs, 2)
+	assert.Equal(t, `profiles.ci.agents[0]: missing required property "path"`, errs[0].Path+": "+errs[0].Message)
+	assert.Equal(t, "profiles.ci.remove.skill", errs[1].Path)
+}
+
+func TestValidateSchema_EmptyRequiredValue(t *testing.T) {
+	errs := ValidateSchema([]byte("skills:\n  - name: \"\"\n  - name:\n"))
+	require.Len(t, errs, 2)
+	assert.Equal(t, "must not be empty", errs[0].Message)
+	assert.Equal(t, `missing required property "name"`, errs[1].Message)
+}
+
+func TestValidateSchema_SyntaxErrorHasLine(t *testing.T) {
+	errs := ValidateSchema([]byte("skills:\n  - name: tdd\ntargets: [cursor\n"))
+	require.Len(t, errs, 1)
+	assert.Greater(t, errs[0].Line, 0)
+	assert.Equal(t, 0, errs[0].Column)
+	assert.NotContains(t, errs[0].Message, "yaml:")
+}
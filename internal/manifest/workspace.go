@@ -0,0 +1,78 @@
+package manifest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// FindWorkspaceRoot looks for the nearest manifest above projectDir and
+// reports its directory if one of its workspace patterns matches projectDir.
+// Only the nearest ancestor manifest is considered, so unrelated manifests
+// further up never adopt a project.
+func FindWorkspaceRoot(projectDir string) (string, bool) {
+	abs, err := filepath.Abs(projectDir)
+	if err != nil {
+		return "", false
+	}
+	for dir := filepath.Dir(abs); ; dir = filepath.Dir(dir) {
+		if m, _, err := LoadManifestFromProject(dir); err == nil {
+			rel, err := filepath.Rel(dir, abs)
+			if err != nil {
+				return "", false
+			}
+			if matchesWorkspace(m.Workspace, filepath.ToSlash(rel)) {
+				return dir, true
+			}
+			return "", false
+		}
+		if filepath.Dir(dir) == dir {
+			return "", false
+		}
+	}
+}
+
+func matchesWorkspace(patterns []string, rel string) bool {
+	for _, p := range patterns {
+		if ok, err := filepath.Match(filepath.ToSlash(filepath.Clean(p)), rel); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+// WorkspacePackages expands the workspace patterns of the manifest in
+// rootDir and returns the matching package directories that contain a
+// manifest, sorted. Packages are absolute paths. A package whose manifest
+// fails to load is still returned, so callers loading it report the error
+// instead of silently skipping the package.
+func WorkspacePackages(rootDir string, m *Manifest) ([]string, error) {
+	root, err := filepath.Abs(rootDir)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	var pkgs []string
+	for _, pattern := range m.Workspace {
+		matches, err := filepath.Glob(filepath.Join(root, filepath.FromSlash(pattern)))
+		if err != nil {
+			return nil, fmt.Errorf("workspace pattern %q: %w", pattern, err)
+		}
+		for _, dir := range matches {
+			if seen[dir] || dir == root {
+				continue
+			}
+			if fi, err := os.Stat(dir); err != nil || !fi.IsDir() {
+				continue
+			}
+			if _, ok := FindProjectManifest(dir); !ok {
+				continue
+			}
+			seen[dir] = true
+			pkgs = append(pkgs, dir)
+		}
+	}
+	sort.Strings(pkgs)
+	return pkgs, nil
+}
@@ -0,0 +1,122 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeManifestFile(t *testing.T, dir, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(dir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "vibes.yaml"), []byte(content), 0o644))
+}
+
+func setupWorkspace(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	writeManifestFile(t, root, `workspace:
+  - services/*
+skills:
+  - name: shared-skill
+  - name: overridden
+instructions:
+  - name: root-inst
+    path: ./docs/root.md
+targets:
+  - opencode
+`)
+	writeManifestFile(t, filepath.Join(root, "services", "api"), `skills:
+  - name: overridden
+    path: ./skills/overridden
+  - name: api-only
+targets:
+  - cursor
+`)
+	writeManifestFile(t, filepath.Join(root, "services", "web"), "agents:\n  - name: web-agent\n    path: ./agents/web.md\n")
+	// A directory without a manifest is not a package.
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "services", "docs"), 0o755))
+	return root
+}
+
+func TestFindWorkspaceRoot(t *testing.T) {
+	root := setupWorkspace(t)
+
+	got, ok := FindWorkspaceRoot(filepath.Join(root, "services", "api"))
+	require.True(t, ok)
+	assert.Equal(t, root, got)
+
+	_, ok = FindWorkspaceRoot(root)
+	assert.False(t, ok)
+
+	writeManifestFile(t, filepath.Join(root, "tools", "cli"), "skills:\n  - name: x\n")
+	_, ok = FindWorkspaceRoot(filepath.Join(root, "tools", "cli"))
+	assert.False(t, ok, "directories outside the workspace patterns are not packages")
+}
+
+func TestWorkspacePackages(t *testing.T) {
+	root := setupWorkspace(t)
+	m, _, err := LoadManifestFromProject(root)
+	require.NoError(t, err)
+
+	pkgs, err := WorkspacePackages(root, m)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(root, "services", "api"),
+		filepath.Join(root, "services", "web"),
+	}, pkgs)
+}
+
+func TestWorkspacePackages_KeepsPackagesThatFailToLoad(t *testing.T) {
+	root := setupWorkspace(t)
+	broken := filepath.Join(root, "services", "broken")
+	writeManifestFile(t, broken, "skills: [unclosed\n")
+	m, _, err := LoadManifestFromProject(root)
+	require.NoError(t, err)
+
+	pkgs, err := WorkspacePackages(root, m)
+	require.NoError(t, err)
+	assert.Contains(t, pkgs, broken, "a broken package is reported, not skipped")
+	_, _, err = LoadManifestFromProject(broken)
+	assert.Error(t, err)
+}
+
+func TestLoadMergedManifest_WorkspacePackageInheritsRoot(t *testing.T) {
+	root := setupWorkspace(t)
+	globalPath := filepath.Join(t.TempDir(), "vibes.yaml")
+	require.NoError(t, os.WriteFile(globalPath, []byte("skills:\n  - name: global-skill\n"), 0o644))
+
+	api := filepath.Join(root, "services", "api")
+	m, err := LoadMergedManifest(api, globalPath)
+	require.NoError(t, err)
+
+	var names []string
+	for _, s := range m.Skills {
+		names = append(names, s.Name)
+	}
+	assert.Equal(t, []string{"global-skill", "shared-skill", "overridden", "api-only"}, names)
+	assert.Equal(t, filepath.Join(api, "skills", "overridden"), m.Skills[2].Path)
+	assert.Equal(t, []string{"cursor"}, m.Targets)
+	require.Len(t, m.Instructions, 1)
+	assert.Equal(t, filepath.Join(root, "docs", "root.md"), m.Instructions[0].Path)
+	assert.Empty(t, m.Workspace, "workspace patterns are not inherited")
+
+	web, err := LoadMergedManifest(filepath.Join(root, "services", "web"), globalPath)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"opencode"}, web.Targets)
+	require.Len(t, web.Agents, 1)
+}
+
+func TestValidate_WorkspacePattern(t *testing.T) {
+	m := &Manifest{
+		Skills:    []SkillRef{{Name: "s"}},
+		Targets:   []string{"opencode"},
+		Workspace: []string{"services/["},
+	}
+	err := m.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid workspace pattern")
+}
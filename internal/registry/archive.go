@@ -0,0 +1,354 @@
+package registry
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/chaz8081/positive-vibes/pkg/schema"
+)
+
+// ArchiveRegistry fetches skills from a .tar.gz or .zip file served over
+// HTTP, e.g. a release tarball on an artifact server. The archive is
+// downloaded on first use and extracted into CachePath; skills and resources
+// are read from the configured paths inside it.
+type ArchiveRegistry struct {
+	RegistryName     string
+	URL              string
+	SHA256           string // expected checksum of the download; empty skips verification
+	CachePath        string // e.g., ~/.positive-vibes/cache/<name>-<key>/ (see CacheKey)
+	LegacyCachePath  string // pre-CacheKey location of this registry's cache, adopted if it matches
+	SkillsPath       string // subdirectory inside the archive where skills live; defaults to "."
+	InstructionsPath string // base path for instructions in the archive; defaults to "."
+	AgentsPath       string // base path for agents in the archive; defaults to "."
+	Ref              string // "latest" re-downloads on Refresh; anything else is fetched once
+	Client           *http.Client
+}
+
+// Inside CachePath, the extracted files live in archiveTreeDir and
+// archiveSourceFile records the URL and checksum they came from.
+const (
+	archiveTreeDir    = "tree"
+	archiveSourceFile = "source"
+)
+
+func (r *ArchiveRegistry) Name() string { return r.RegistryName }
+
+// CacheDir returns the directory holding the extracted archive.
+func (r *ArchiveRegistry) CacheDir() string { return r.CachePath }
+
+func (r *ArchiveRegistry) tree() dirTree {
+	return dirTree{
+		registryName:     r.RegistryName,
+		root:             filepath.Join(r.CachePath, archiveTreeDir),
+		skillsPath:       r.SkillsPath,
+		instructionsPath: r.InstructionsPath,
+		agentsPath:       r.AgentsPath,
+	}
+}
+
+func (r *ArchiveRegistry) source() string {
+	return r.URL + "\n" + strings.ToLower(r.SHA256) + "\n"
+}
+
+// ensureCache downloads the archive unless CachePath already holds an
+// extraction of the same URL and checksum.
+func (r *ArchiveRegistry) ensureCache() error {
+	r.adoptLegacyCache()
+	data, err := os.ReadFile(filepath.Join(r.CachePath, archiveSourceFile))
+	if err == nil && string(data) == r.source() {
+		return nil
+	}
+	return r.download()
+}
+
+// adoptLegacyCache moves a cache made before caches were keyed by URL and
+// ref from LegacyCachePath to CachePath, when it holds the same download.
+func (r *ArchiveRegistry) adoptLegacyCache() {
+	legacy := r.LegacyCachePath
+	if legacy == "" || legacy == r.CachePath || exists(r.CachePath) {
+		return
+	}
+	data, err := os.ReadFile(filepath.Join(legacy, archiveSourceFile))
+	if err != nil || string(data) != r.source() {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(r.CachePath), 0o755); err != nil {
+		return
+	}
+	_ = os.Rename(legacy, r.CachePath)
+}
+
+// download fetches, verifies and extracts the archive, then swaps it into
+// CachePath. The previous extraction is left in place if any step fails.
+func (r *ArchiveRegistry) download() error {
+	client := r.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Get(r.URL)
+	if err != nil {
+		return fmt.Errorf("download %s: %w", RedactURL(r.URL), err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("download %s: %s", RedactURL(r.URL), resp.Status)
+	}
+
+	parent := filepath.Dir(r.CachePath)
+	if err := os.MkdirAll(parent, 0o755); err != nil {
+		return fmt.Errorf("create cache dir: %w", err)
+	}
+	tmp, err := os.MkdirTemp(parent, filepath.Base(r.CachePath)+".download-*")
+	if err != nil {
+		return fmt.Errorf("create cache dir: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	archivePath := filepath.Join(tmp, "archive")
+	f, err := os.Create(archivePath)
+	if err != nil {
+		return fmt.Errorf("download %s: %w", RedactURL(r.URL), err)
+	}
+	h := sha256.New()
+	_, err = io.Copy(io.MultiWriter(f, h), resp.Body)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("download %s: %w", RedactURL(r.URL), err)
+	}
+	if got := hex.EncodeToString(h.Sum(nil)); r.SHA256 != "" && !strings.EqualFold(got, r.SHA256) {
+		return fmt.Errorf("registry %q: sha256 mismatch for %s: got %s, want %s", r.RegistryName, RedactURL(r.URL), got, strings.ToLower(r.SHA256))
+	}
+
+	out := filepath.Join(tmp, "cache")
+	if err := r.extract(archivePath, filepath.Join(out, archiveTreeDir)); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(out, archiveSourceFile), []byte(r.source()), 0o644); err != nil {
+		return fmt.Errorf("write cache: %w", err)
+	}
+	if err := os.RemoveAll(r.CachePath); err != nil {
+		return fmt.Errorf("replace cache: %w", err)
+	}
+	if err := os.Rename(out, r.CachePath); err != nil {
+		return fmt.Errorf("replace cache: %w", err)
+	}
+	return nil
+}
+
+// extract unpacks the archive at path into dir, detecting the format from
+// its leading bytes.
+func (r *ArchiveRegistry) extract(path, dir string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	magic, _ := bufio.NewReader(f).Peek(4)
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	switch {
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		err = extractTarGz(f, dir)
+	case bytes.HasPrefix(magic, []byte("PK\x03\x04")):
+		err = extractZip(path, dir)
+	default:
+		return fmt.Errorf("registry %q: %s is not a .tar.gz or .zip archive", r.RegistryName, RedactURL(r.URL))
+	}
+	if err != nil {
+		return fmt.Errorf("registry %q: extract %s: %w", r.RegistryName, RedactURL(r.URL), err)
+	}
+	return nil
+}
+
+// archiveEntryPath resolves an archive entry name inside dir, rejecting
+// names that would escape it.
+func archiveEntryPath(dir, name string) (string, error) {
+	clean := filepath.Clean(filepath.FromSlash(name))
+	if filepath.IsAbs(clean) || filepath.VolumeName(clean) != "" || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("unsafe path %q in archive", name)
+	}
+	return filepath.Join(dir, clean), nil
+}
+
+// writeArchiveFile creates path with the contents of src. Entries are made
+// readable and writable by the owner whatever mode the archive records.
+func writeArchiveFile(path string, mode os.FileMode, src io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode.Perm()|0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, src); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// extractTarGz unpacks directories and regular files. Links and special
+// files are skipped so nothing can point outside dir.
+func extractTarGz(src io.Reader, dir string) error {
+	gz, err := gzip.NewReader(src)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if hdr.Typeflag != tar.TypeDir && hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		path, err := archiveEntryPath(dir, hdr.Name)
+		if err != nil {
+			return err
+		}
+		if hdr.Typeflag == tar.TypeDir {
+			if err := os.MkdirAll(path, 0o755); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := writeArchiveFile(path, hdr.FileInfo().Mode(), tr); err != nil {
+			return err
+		}
+	}
+}
+
+// extractZip unpacks directories and regular files, like extractTarGz.
+func extractZip(path, dir string) error {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+	for _, f := range zr.File {
+		mode := f.Mode()
+		if !mode.IsDir() && !mode.IsRegular() {
+			continue
+		}
+		dest, err := archiveEntryPath(dir, f.Name)
+		if err != nil {
+			return err
+		}
+		if mode.IsDir() {
+			if err := os.MkdirAll(dest, 0o755); err != nil {
+				return err
+			}
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return err
+		}
+		err = writeArchiveFile(dest, mode, rc)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Fetch retrieves a skill by name.
+// It returns the parsed Skill and the path to the skill's source directory on disk.
+func (r *ArchiveRegistry) Fetch(name string) (*schema.Skill, string, error) {
+	if err := r.ensureCache(); err != nil {
+		return nil, "", err
+	}
+	return r.tree().fetch(name)
+}
+
+// List returns all available skill names (directories containing a SKILL.md).
+func (r *ArchiveRegistry) List() ([]string, error) {
+	if err := r.ensureCache(); err != nil {
+		return nil, err
+	}
+	return r.tree().list()
+}
+
+// FetchFile retrieves raw file bytes from a skill directory in the archive.
+func (r *ArchiveRegistry) FetchFile(skillName, relPath string) ([]byte, error) {
+	if err := r.ensureCache(); err != nil {
+		return nil, err
+	}
+	return r.tree().fetchFile(skillName, relPath)
+}
+
+// ListFiles returns the names of files directly within a subdirectory of a
+// skill directory, or an empty slice if the directory does not exist.
+func (r *ArchiveRegistry) ListFiles(skillName, relDir string) ([]string, error) {
+	if err := r.ensureCache(); err != nil {
+		return nil, err
+	}
+	return r.tree().listFiles(skillName, relDir)
+}
+
+// FetchResourceFile retrieves raw file bytes from a resource base directory.
+// kind must be one of: "skills", "instructions", "agents".
+func (r *ArchiveRegistry) FetchResourceFile(kind, relPath string) ([]byte, error) {
+	if err := r.ensureCache(); err != nil {
+		return nil, err
+	}
+	return r.tree().fetchResourceFile(kind, relPath)
+}
+
+// FetchRootFile retrieves raw file bytes by path relative to the archive
+// root. Paths may not escape the archive.
+func (r *ArchiveRegistry) FetchRootFile(relPath string) ([]byte, error) {
+	if err := r.ensureCache(); err != nil {
+		return nil, err
+	}
+	return r.tree().fetchRootFile(relPath)
+}
+
+// ListResourceFiles recursively lists files under the configured base path for
+// the requested resource kind. Returned paths are relative to that base path.
+func (r *ArchiveRegistry) ListResourceFiles(kind string) ([]string, error) {
+	if err := r.ensureCache(); err != nil {
+		return nil, err
+	}
+	return r.tree().listResourceFiles(kind)
+}
+
+// Refresh downloads the archive again when Ref is "latest" (or empty). For
+// pinned refs it only downloads if the cache is missing or was extracted
+// from a different URL or checksum.
+func (r *ArchiveRegistry) Refresh() error {
+	if r.Ref != "" && r.Ref != RefLatest {
+		return r.ensureCache()
+	}
+	return r.download()
+}
+
+// Index returns the registry's index file (see IndexFilenames), or nil when
+// it has none.
+func (r *ArchiveRegistry) Index() (*Index, error) {
+	if err := r.ensureCache(); err != nil {
+		return nil, err
+	}
+	return r.tree().index()
+}
@@ -0,0 +1,278 @@
+package registry
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+const archiveSkill = "---\nname: tdd\ndescription: Test-driven development\n---\nWrite the test first.\n"
+
+// tarGzArchive builds a .tar.gz holding files; entries whose content starts
+// with "->" become symlinks to the rest of the string.
+func tarGzArchive(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		hdr := &tar.Header{Name: name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg}
+		if target, ok := strings.CutPrefix(content, "->"); ok {
+			hdr = &tar.Header{Name: name, Linkname: target, Typeflag: tar.TypeSymlink}
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatalf("write header: %v", err)
+		}
+		if hdr.Typeflag == tar.TypeReg {
+			if _, err := tw.Write([]byte(content)); err != nil {
+				t.Fatalf("write file: %v", err)
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("close tar: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("close gzip: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func zipArchive(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("create %s: %v", name, err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("close zip: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// serveArchive serves data at every path and counts the downloads.
+func serveArchive(t *testing.T, data []byte) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		hits.Add(1)
+		w.Write(data)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &hits
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestArchiveRegistry_TarGzServesConfiguredPaths(t *testing.T) {
+	data := tarGzArchive(t, map[string]string{
+		"pkg/skills/tdd/SKILL.md":         archiveSkill,
+		"pkg/skills/tdd/agents/review.md": "review agent",
+		"pkg/instructions/style.md":       "Use tabs.",
+		"pkg/agents/helper.md":            "helper agent",
+	})
+	srv, _ := serveArchive(t, data)
+
+	r := &ArchiveRegistry{
+		RegistryName:     "release",
+		URL:              srv.URL + "/skills.tar.gz",
+		SHA256:           sha256Hex(data),
+		CachePath:        filepath.Join(t.TempDir(), "release"),
+		SkillsPath:       "pkg/skills",
+		InstructionsPath: "pkg/instructions",
+		AgentsPath:       "pkg/agents",
+		Ref:              "v1.0.0",
+	}
+
+	names, err := r.List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if !reflect.DeepEqual(names, []string{"tdd"}) {
+		t.Fatalf("expected [tdd], got %v", names)
+	}
+	sk, dir, err := r.Fetch("tdd")
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if sk.Name != "tdd" || !strings.HasPrefix(dir, r.CachePath) {
+		t.Fatalf("unexpected skill %q at %s", sk.Name, dir)
+	}
+	files, err := r.ListFiles("tdd", "agents")
+	if err != nil || !reflect.DeepEqual(files, []string{"review.md"}) {
+		t.Fatalf("ListFiles: %v, %v", files, err)
+	}
+	inst, err := r.FetchResourceFile("instructions", "style.md")
+	if err != nil || string(inst) != "Use tabs." {
+		t.Fatalf("FetchResourceFile: %q, %v", inst, err)
+	}
+	agents, err := r.ListResourceFiles("agents")
+	if err != nil || !reflect.DeepEqual(agents, []string{"helper.md"}) {
+		t.Fatalf("ListResourceFiles: %v, %v", agents, err)
+	}
+}
+
+func TestArchiveRegistry_Zip(t *testing.T) {
+	data := zipArchive(t, map[string]string{"tdd/SKILL.md": archiveSkill})
+	srv, _ := serveArchive(t, data)
+
+	r := &ArchiveRegistry{RegistryName: "zipped", URL: srv.URL + "/skills.zip", CachePath: filepath.Join(t.TempDir(), "zipped"), Ref: "v1"}
+	if _, _, err := r.Fetch("tdd"); err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+}
+
+func TestArchiveRegistry_SHA256Mismatch(t *testing.T) {
+	srv, _ := serveArchive(t, tarGzArchive(t, map[string]string{"tdd/SKILL.md": archiveSkill}))
+
+	r := &ArchiveRegistry{
+		RegistryName: "release",
+		URL:          srv.URL + "/skills.tar.gz",
+		SHA256:       strings.Repeat("0", 64),
+		CachePath:    filepath.Join(t.TempDir(), "release"),
+		Ref:          "v1",
+	}
+	_, err := r.List()
+	if err == nil || !strings.Contains(err.Error(), "sha256 mismatch") {
+		t.Fatalf("expected sha256 mismatch, got %v", err)
+	}
+	if _, statErr := os.Stat(r.CachePath); !os.IsNotExist(statErr) {
+		t.Fatalf("expected no cache after failed verification, stat err %v", statErr)
+	}
+}
+
+func TestArchiveRegistry_RejectsPathTraversal(t *testing.T) {
+	base := t.TempDir()
+	srv, _ := serveArchive(t, tarGzArchive(t, map[string]string{
+		"tdd/SKILL.md":   archiveSkill,
+		"../../evil.txt": "owned",
+	}))
+
+	r := &ArchiveRegistry{RegistryName: "evil", URL: srv.URL + "/skills.tar.gz", CachePath: filepath.Join(base, "cache", "evil"), Ref: "v1"}
+	_, err := r.List()
+	if err == nil || !strings.Contains(err.Error(), "unsafe path") {
+		t.Fatalf("expected unsafe path error, got %v", err)
+	}
+	if _, statErr := os.Stat(filepath.Join(base, "evil.txt")); !os.IsNotExist(statErr) {
+		t.Fatalf("file escaped the cache: %v", statErr)
+	}
+}
+
+func TestArchiveRegistry_SkipsSymlinks(t *testing.T) {
+	srv, _ := serveArchive(t, tarGzArchive(t, map[string]string{
+		"tdd/SKILL.md":  archiveSkill,
+		"tdd/passwd.md": "->/etc/passwd",
+	}))
+
+	r := &ArchiveRegistry{RegistryName: "links", URL: srv.URL + "/skills.tar.gz", CachePath: filepath.Join(t.TempDir(), "links"), Ref: "v1"}
+	files, err := r.ListFiles("tdd", ".")
+	if err != nil {
+		t.Fatalf("ListFiles: %v", err)
+	}
+	if !reflect.DeepEqual(files, []string{"SKILL.md"}) {
+		t.Fatalf("expected symlink to be skipped, got %v", files)
+	}
+}
+
+func TestArchiveRegistry_NotAnArchive(t *testing.T) {
+	srv, _ := serveArchive(t, []byte("<html>login</html>"))
+
+	r := &ArchiveRegistry{RegistryName: "html", URL: srv.URL + "/skills.tar.gz", CachePath: filepath.Join(t.TempDir(), "html"), Ref: "v1"}
+	if _, err := r.List(); err == nil || !strings.Contains(err.Error(), "not a .tar.gz or .zip archive") {
+		t.Fatalf("expected format error, got %v", err)
+	}
+}
+
+func TestArchiveRegistry_HTTPError(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	r := &ArchiveRegistry{RegistryName: "missing", URL: srv.URL + "/skills.tar.gz", CachePath: filepath.Join(t.TempDir(), "missing"), Ref: "v1"}
+	if _, err := r.List(); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Fatalf("expected 404 error, got %v", err)
+	}
+}
+
+func TestArchiveRegistry_CachesAndRefreshesLatest(t *testing.T) {
+	srv, hits := serveArchive(t, tarGzArchive(t, map[string]string{"tdd/SKILL.md": archiveSkill}))
+	cache := filepath.Join(t.TempDir(), "release")
+
+	pinned := &ArchiveRegistry{RegistryName: "release", URL: srv.URL + "/skills.tar.gz", CachePath: cache, Ref: "v1"}
+	for i := 0; i < 2; i++ {
+		if _, err := pinned.List(); err != nil {
+			t.Fatalf("List: %v", err)
+		}
+	}
+	if err := pinned.Refresh(); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if got := hits.Load(); got != 1 {
+		t.Fatalf("expected one download for a pinned archive, got %d", got)
+	}
+
+	latest := &ArchiveRegistry{RegistryName: "release", URL: srv.URL + "/skills.tar.gz", CachePath: cache, Ref: RefLatest}
+	if err := latest.Refresh(); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if got := hits.Load(); got != 2 {
+		t.Fatalf("expected refresh to download again, got %d downloads", got)
+	}
+
+	moved := &ArchiveRegistry{RegistryName: "release", URL: srv.URL + "/v2/skills.tar.gz", CachePath: cache, Ref: "v2"}
+	if _, err := moved.List(); err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if got := hits.Load(); got != 3 {
+		t.Fatalf("expected a new URL to download again, got %d downloads", got)
+	}
+}
+
+func TestArchiveRegistry_AdoptsMatchingLegacyCache(t *testing.T) {
+	srv, hits := serveArchive(t, tarGzArchive(t, map[string]string{"tdd/SKILL.md": archiveSkill}))
+	root := t.TempDir()
+	legacy := filepath.Join(root, "release")
+	url := srv.URL + "/skills.tar.gz"
+
+	old := &ArchiveRegistry{RegistryName: "release", URL: url, CachePath: legacy, Ref: "v1"}
+	if _, err := old.List(); err != nil {
+		t.Fatalf("List: %v", err)
+	}
+
+	other := &ArchiveRegistry{RegistryName: "release", URL: srv.URL + "/v2/skills.tar.gz", CachePath: filepath.Join(root, CacheKey("release", srv.URL+"/v2/skills.tar.gz", "v2")), LegacyCachePath: legacy, Ref: "v2"}
+	other.adoptLegacyCache()
+	if exists(other.CachePath) || !exists(legacy) {
+		t.Fatal("expected a legacy cache of another URL to be left alone")
+	}
+
+	reg := &ArchiveRegistry{RegistryName: "release", URL: url, CachePath: filepath.Join(root, CacheKey("release", url, "v1")), LegacyCachePath: legacy, Ref: "v1"}
+	if _, err := reg.List(); err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if got := hits.Load(); got != 1 {
+		t.Fatalf("expected the legacy cache to be reused, got %d downloads", got)
+	}
+	if exists(legacy) || !exists(filepath.Join(reg.CachePath, archiveSourceFile)) {
+		t.Fatal("expected the legacy cache to be moved")
+	}
+}
@@ -0,0 +1,401 @@
+package registry
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// Kinds of registry cache reported by InspectCache.
+const (
+	CacheKindGit      = "git"
+	CacheKindArchive  = "archive"
+	CacheKindDownload = "download" // leftover temp dir of an interrupted archive download or re-clone
+	CacheKindStore    = "store"    // object store shared by the clones of one repository
+	CacheKindUnknown  = "unknown"
+)
+
+// refreshMarker, inside a clone's .git directory, holds the time of its
+// last successful clone or refresh.
+const refreshMarker = "positive-vibes-refreshed"
+
+// CacheInfo describes one registry cache directory.
+type CacheInfo struct {
+	Name        string
+	Path        string
+	Kind        string
+	Size        int64 // bytes on disk
+	URL         string
+	Branch      string // git: checked-out branch; empty when detached
+	Commit      string // git: checked-out commit
+	Shallow     bool
+	LastRefresh time.Time // zero when unknown
+	Store       string    // git: the object store the clone borrows objects from, if any
+}
+
+// ObjectStoreDir is the directory, inside the cache root, that holds one
+// bare object store per git repository (see RepoKey).
+const ObjectStoreDir = "objects"
+
+// CacheKey returns the cache directory name of a registry: its name
+// followed by a hash of its normalized URL and ref, e.g. "team-3f2a9c01b4e7".
+// Registries that share a name but not a source (in different projects,
+// say) never share a cache; the name only keeps the directory readable.
+func CacheKey(name, rawURL, ref string) string {
+	if ref == "" {
+		ref = RefLatest
+	}
+	sum := sha256.Sum256([]byte(NormalizeURL(rawURL) + "\n" + ref))
+	return safeCacheName(name) + "-" + hex.EncodeToString(sum[:])[:12]
+}
+
+// CacheRegistryName returns the registry name part of a cache directory
+// named by CacheKey, or "" for any other name.
+func CacheRegistryName(cacheName string) string {
+	i := strings.LastIndex(cacheName, "-")
+	if i <= 0 || len(cacheName)-i-1 != 12 {
+		return ""
+	}
+	if _, err := hex.DecodeString(cacheName[i+1:]); err != nil {
+		return ""
+	}
+	return cacheName[:i]
+}
+
+// CacheOf reports whether the cache directory named cacheName belongs to the
+// registry called registryName, for any URL or ref: a CacheKey of that name,
+// or the directory caches were kept in before CacheKey.
+func CacheOf(cacheName, registryName string) bool {
+	return cacheName == registryName || CacheRegistryName(cacheName) == safeCacheName(registryName)
+}
+
+// RepoKey returns the directory name, under ObjectStoreDir, of the object
+// store shared by every ref of the repository at rawURL.
+func RepoKey(rawURL string) string {
+	sum := sha256.Sum256([]byte(NormalizeURL(rawURL)))
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// NormalizeURL reduces the spellings of one repository's URL to a single
+// form: the scheme, credentials, default port, a trailing slash and a
+// ".git" suffix are dropped, the host is lowercased, and scp-style SSH URLs
+// (git@host:org/repo) become host/org/repo. Local paths are made absolute.
+func NormalizeURL(rawURL string) string {
+	s := strings.TrimSpace(rawURL)
+	if !strings.Contains(s, "://") {
+		// scp-style user@host:path, as opposed to a local path.
+		if at := strings.Index(s, "@"); at >= 0 {
+			if host, p, ok := strings.Cut(s[at+1:], ":"); ok && !strings.Contains(host, "/") {
+				return normalizeRepoPath(strings.ToLower(host), p)
+			}
+		}
+		if abs, err := filepath.Abs(s); err == nil {
+			s = abs
+		}
+		return strings.TrimSuffix(filepath.ToSlash(filepath.Clean(s)), "/")
+	}
+
+	u, err := url.Parse(s)
+	if err != nil {
+		return s
+	}
+	if u.Scheme == "file" {
+		return NormalizeURL(u.Path)
+	}
+	host := strings.ToLower(u.Hostname())
+	switch port := u.Port(); {
+	case port == "":
+	case port == "443" && u.Scheme == "https", port == "80" && u.Scheme == "http", port == "22" && u.Scheme == "ssh":
+	default:
+		host += ":" + port
+	}
+	return normalizeRepoPath(host, u.Path)
+}
+
+func normalizeRepoPath(host, p string) string {
+	p = strings.Trim(p, "/")
+	p = strings.TrimSuffix(p, ".git")
+	return host + "/" + p
+}
+
+// safeCacheName keeps the letters, digits, dots, dashes and underscores of
+// a registry name, so any name makes a valid directory name.
+func safeCacheName(name string) string {
+	b := []byte(name)
+	for i, c := range b {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '.' || c == '-' || c == '_') {
+			b[i] = '_'
+		}
+	}
+	if s := strings.TrimLeft(string(b), "."); s != "" {
+		return s
+	}
+	return "registry"
+}
+
+// markRefreshed records that the clone in dir was just cloned or refreshed.
+func markRefreshed(dir string) {
+	_ = os.WriteFile(filepath.Join(dir, ".git", refreshMarker), []byte(time.Now().UTC().Format(time.RFC3339)+"\n"), 0o644)
+}
+
+// ListCaches inspects every cache directory under root, sorted by name,
+// including the object stores under ObjectStoreDir, which are named
+// "objects/<key>". A missing root has no caches.
+func ListCaches(root string) ([]CacheInfo, error) {
+	entries, err := os.ReadDir(root)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var out []CacheInfo
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		dir := filepath.Join(root, e.Name())
+		if e.Name() == ObjectStoreDir && cacheKind(dir) == CacheKindUnknown {
+			stores, err := os.ReadDir(dir)
+			if err != nil {
+				return nil, err
+			}
+			for _, s := range stores {
+				if s.IsDir() {
+					out = append(out, InspectCache(filepath.Join(dir, s.Name())))
+				}
+			}
+			continue
+		}
+		out = append(out, InspectCache(dir))
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, nil
+}
+
+// InspectCache reads what it can about the cache in dir. It never fails:
+// fields it can't read are left empty, and VerifyCache explains why.
+func InspectCache(dir string) CacheInfo {
+	info := CacheInfo{Name: filepath.Base(dir), Path: dir, Kind: cacheKind(dir), Size: dirSize(dir)}
+	switch info.Kind {
+	case CacheKindGit:
+		inspectGitCache(&info)
+	case CacheKindStore:
+		info.Name = ObjectStoreDir + "/" + info.Name
+		inspectStore(&info)
+	case CacheKindArchive:
+		source := filepath.Join(dir, archiveSourceFile)
+		if data, err := os.ReadFile(source); err == nil {
+			info.URL, _, _ = strings.Cut(string(data), "\n")
+		}
+		if fi, err := os.Stat(source); err == nil {
+			info.LastRefresh = fi.ModTime()
+		}
+	}
+	return info
+}
+
+func cacheKind(dir string) string {
+	switch {
+	case strings.Contains(filepath.Base(dir), ".download-"):
+		return CacheKindDownload
+	case exists(filepath.Join(dir, ".git")):
+		return CacheKindGit
+	case exists(filepath.Join(dir, archiveSourceFile)):
+		return CacheKindArchive
+	case exists(filepath.Join(dir, "HEAD")) && exists(filepath.Join(dir, "objects")):
+		return CacheKindStore
+	}
+	return CacheKindUnknown
+}
+
+func exists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
+
+func inspectGitCache(info *CacheInfo) {
+	info.Store = linkedStore(info.Path)
+	gitDir := filepath.Join(info.Path, ".git")
+	if data, err := os.ReadFile(filepath.Join(gitDir, refreshMarker)); err == nil {
+		info.LastRefresh, _ = time.Parse(time.RFC3339, strings.TrimSpace(string(data)))
+	}
+	if info.LastRefresh.IsZero() {
+		// Clones made before the marker existed: the index is rewritten on
+		// every checkout.
+		if fi, err := os.Stat(filepath.Join(gitDir, "index")); err == nil {
+			info.LastRefresh = fi.ModTime()
+		}
+	}
+
+	repo, err := openRepo(info.Path)
+	if err != nil {
+		return
+	}
+	if remote, err := repo.Remote(git.DefaultRemoteName); err == nil && len(remote.Config().URLs) > 0 {
+		info.URL = remote.Config().URLs[0]
+	}
+	if head, err := repo.Head(); err == nil {
+		info.Commit = head.Hash().String()
+		if head.Name().IsBranch() {
+			info.Branch = head.Name().Short()
+		}
+	}
+	info.Shallow = isShallow(repo)
+}
+
+func inspectStore(info *CacheInfo) {
+	// Every fetch into the store adds a pack.
+	if fi, err := os.Stat(filepath.Join(info.Path, "objects", "pack")); err == nil {
+		info.LastRefresh = fi.ModTime()
+	}
+	repo, err := git.PlainOpen(info.Path)
+	if err != nil {
+		return
+	}
+	if remote, err := repo.Remote(git.DefaultRemoteName); err == nil && len(remote.Config().URLs) > 0 {
+		info.URL = remote.Config().URLs[0]
+	}
+	info.Shallow = isShallow(repo)
+}
+
+// dirSize sums the sizes of the regular files under dir.
+func dirSize(dir string) int64 {
+	var size int64
+	_ = filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.Type().IsRegular() {
+			if fi, err := d.Info(); err == nil {
+				size += fi.Size()
+			}
+		}
+		return nil
+	})
+	return size
+}
+
+// VerifyCache checks that the cache in dir is complete: a git clone must
+// have a readable HEAD commit whose every file is in the object store and
+// checked out (outside a sparse checkout's skipped paths); an archive
+// cache must have its extracted tree and source record; an object store
+// must hold the commits its refs point at. The returned
+// errors list every problem found; none means the cache is healthy.
+func VerifyCache(dir string) []error {
+	switch cacheKind(dir) {
+	case CacheKindGit:
+		return verifyGitCache(dir)
+	case CacheKindArchive:
+		var errs []error
+		if fi, err := os.Stat(filepath.Join(dir, archiveTreeDir)); err != nil || !fi.IsDir() {
+			errs = append(errs, errors.New("extracted archive is missing"))
+		}
+		data, err := os.ReadFile(filepath.Join(dir, archiveSourceFile))
+		if err != nil || !strings.Contains(string(data), "\n") {
+			errs = append(errs, errors.New("archive source record is unreadable"))
+		}
+		return errs
+	case CacheKindStore:
+		return verifyStore(dir)
+	case CacheKindDownload:
+		return []error{errors.New("leftover from an interrupted download or re-clone")}
+	}
+	return []error{errors.New("not a git clone or archive (partial or interrupted clone)")}
+}
+
+func verifyGitCache(dir string) []error {
+	if store := linkedStore(dir); store != "" && !exists(store) {
+		return []error{fmt.Errorf("shared object store %s is missing", store)}
+	}
+	repo, err := openRepo(dir)
+	if err != nil {
+		return []error{fmt.Errorf("open repository: %w", err)}
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return []error{fmt.Errorf("resolve HEAD: %w", err)}
+	}
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return []error{fmt.Errorf("read commit %s: %w", head.Hash(), err)}
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return []error{fmt.Errorf("read tree of %s: %w", head.Hash(), err)}
+	}
+
+	var errs []error
+	walker := object.NewTreeWalker(tree, true, nil)
+	defer walker.Close()
+	for {
+		name, entry, err := walker.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("walk tree of %s: %w", head.Hash(), err))
+			break
+		}
+		if entry.Mode == filemode.Dir || entry.Mode == filemode.Submodule {
+			continue
+		}
+		if _, err := repo.Storer.EncodedObject(plumbing.BlobObject, entry.Hash); err != nil {
+			errs = append(errs, fmt.Errorf("object for %s is missing", name))
+		}
+	}
+
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return append(errs, fmt.Errorf("read index: %w", err))
+	}
+	missing := 0
+	for _, e := range idx.Entries {
+		if e.SkipWorktree {
+			continue
+		}
+		if !exists(filepath.Join(dir, filepath.FromSlash(e.Name))) {
+			missing++
+		}
+	}
+	if missing > 0 {
+		errs = append(errs, fmt.Errorf("%d checked-out file(s) missing from the worktree", missing))
+	}
+	return errs
+}
+
+func verifyStore(dir string) []error {
+	repo, err := git.PlainOpen(dir)
+	if err != nil {
+		return []error{fmt.Errorf("open repository: %w", err)}
+	}
+	refs, err := repo.References()
+	if err != nil {
+		return []error{fmt.Errorf("read refs: %w", err)}
+	}
+	var errs []error
+	_ = refs.ForEach(func(ref *plumbing.Reference) error {
+		if ref.Type() != plumbing.HashReference {
+			return nil
+		}
+		if _, err := repo.Storer.EncodedObject(plumbing.AnyObject, ref.Hash()); err != nil {
+			errs = append(errs, fmt.Errorf("object for %s is missing", ref.Name()))
+		}
+		return nil
+	})
+	return errs
+}
@@ -0,0 +1,121 @@
+package registry
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestListCaches_InspectsEachKind(t *testing.T) {
+	root := t.TempDir()
+	repoDir := setupTestGitRepo(t, "skills", map[string]string{"tdd": "---\nname: tdd\n---\n"})
+	reg := &GitRegistry{RegistryName: "team", URL: repoDir, CachePath: filepath.Join(root, "team"), SkillsPath: "skills", Ref: RefLatest}
+	if _, err := reg.List(); err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	writeDirRegistryFile(t, filepath.Join(root, "release"), archiveSourceFile, "https://example.com/r.tgz\n\n")
+	writeDirRegistryFile(t, filepath.Join(root, "release"), "tree/skills/x/SKILL.md", "x")
+	writeDirRegistryFile(t, filepath.Join(root, "release.download-123"), "archive", "partial")
+	writeDirRegistryFile(t, filepath.Join(root, "half"), "README.md", "no .git")
+
+	caches, err := ListCaches(root)
+	if err != nil {
+		t.Fatalf("ListCaches: %v", err)
+	}
+	kinds := map[string]string{}
+	for _, c := range caches {
+		kinds[c.Name] = c.Kind
+	}
+	want := map[string]string{"team": CacheKindGit, "release": CacheKindArchive, "release.download-123": CacheKindDownload, "half": CacheKindUnknown}
+	for name, kind := range want {
+		if kinds[name] != kind {
+			t.Fatalf("expected %s to be %s, got %v", name, kind, kinds)
+		}
+	}
+
+	git := InspectCache(reg.CachePath)
+	if git.URL != repoDir || git.Branch != "main" || len(git.Commit) != 40 || !git.Shallow {
+		t.Fatalf("unexpected git cache info %+v", git)
+	}
+	if git.LastRefresh.IsZero() || git.Size == 0 {
+		t.Fatalf("expected size and last refresh, got %+v", git)
+	}
+	if archive := InspectCache(filepath.Join(root, "release")); archive.URL != "https://example.com/r.tgz" {
+		t.Fatalf("unexpected archive cache info %+v", archive)
+	}
+
+	shared := &GitRegistry{
+		RegistryName: "shared",
+		URL:          repoDir,
+		CachePath:    filepath.Join(root, CacheKey("shared", repoDir, "")),
+		ObjectsPath:  filepath.Join(root, ObjectStoreDir, RepoKey(repoDir)),
+		SkillsPath:   "skills",
+	}
+	if _, err := shared.List(); err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	caches, err = ListCaches(root)
+	if err != nil {
+		t.Fatalf("ListCaches: %v", err)
+	}
+	var store *CacheInfo
+	for i, c := range caches {
+		if c.Name == ObjectStoreDir+"/"+RepoKey(repoDir) {
+			store = &caches[i]
+		}
+	}
+	if store == nil || store.Kind != CacheKindStore || store.URL != repoDir || !store.Shallow || store.Size == 0 {
+		t.Fatalf("expected the object store to be listed, got %+v", caches)
+	}
+	if problems := VerifyCache(store.Path); len(problems) != 0 {
+		t.Fatalf("expected a healthy object store, got %v", problems)
+	}
+	if checkout := InspectCache(shared.CachePath); checkout.Branch != "main" || len(checkout.Commit) != 40 || checkout.Store != store.Path {
+		t.Fatalf("unexpected info for a checkout using the store %+v", checkout)
+	}
+
+	if missing, err := ListCaches(filepath.Join(root, "nope")); err != nil || missing != nil {
+		t.Fatalf("expected no caches for a missing root, got %v, %v", missing, err)
+	}
+}
+
+func TestVerifyCache(t *testing.T) {
+	repoDir := setupTestGitRepo(t, "skills", map[string]string{"tdd": "---\nname: tdd\n---\n"})
+	reg := &GitRegistry{RegistryName: "team", URL: repoDir, CachePath: filepath.Join(t.TempDir(), "team"), SkillsPath: "skills", Ref: RefLatest}
+	if _, err := reg.List(); err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if problems := VerifyCache(reg.CachePath); len(problems) != 0 {
+		t.Fatalf("expected a healthy clone, got %v", problems)
+	}
+
+	if err := os.Remove(filepath.Join(reg.CachePath, "skills", "tdd", "SKILL.md")); err != nil {
+		t.Fatal(err)
+	}
+	problems := VerifyCache(reg.CachePath)
+	if len(problems) != 1 || !strings.Contains(problems[0].Error(), "1 checked-out file(s) missing") {
+		t.Fatalf("expected a missing worktree file, got %v", problems)
+	}
+
+	packs, _ := filepath.Glob(filepath.Join(reg.CachePath, ".git", "objects", "pack", "*"))
+	for _, p := range packs {
+		if err := os.Remove(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if problems := VerifyCache(reg.CachePath); len(problems) == 0 || !strings.Contains(problems[0].Error(), "read commit") {
+		t.Fatalf("expected missing objects to be reported, got %v", problems)
+	}
+
+	partial := t.TempDir()
+	if problems := VerifyCache(partial); len(problems) != 1 || !strings.Contains(problems[0].Error(), "partial or interrupted") {
+		t.Fatalf("expected a partial clone, got %v", problems)
+	}
+
+	archive := t.TempDir()
+	writeDirRegistryFile(t, archive, archiveSourceFile, "https://example.com/r.tgz\n\n")
+	if problems := VerifyCache(archive); len(problems) != 1 || !strings.Contains(problems[0].Error(), "extracted archive is missing") {
+		t.Fatalf("expected a missing extraction, got %v", problems)
+	}
+}
@@ -0,0 +1,113 @@
+package registry
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/chaz8081/positive-vibes/pkg/schema"
+)
+
+// DirRegistry serves skills straight from a directory on disk, such as a
+// network mount or a sibling checkout. Nothing is cloned or cached: every
+// call reads the directory as it is now.
+type DirRegistry struct {
+	RegistryName     string
+	Path             string // absolute path of the registry root
+	SkillsPath       string // subdirectory where skills live; defaults to "."
+	InstructionsPath string // base path for instructions; defaults to "."
+	AgentsPath       string // base path for agents; defaults to "."
+}
+
+func (r *DirRegistry) Name() string { return r.RegistryName }
+
+func (r *DirRegistry) tree() dirTree {
+	return dirTree{
+		registryName:     r.RegistryName,
+		root:             r.Path,
+		skillsPath:       r.SkillsPath,
+		instructionsPath: r.InstructionsPath,
+		agentsPath:       r.AgentsPath,
+	}
+}
+
+// checkRoot reports a missing or unreadable registry directory.
+func (r *DirRegistry) checkRoot() error {
+	info, err := os.Stat(r.Path)
+	if err != nil {
+		return fmt.Errorf("registry %q: %w", r.RegistryName, err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("registry %q: %s is not a directory", r.RegistryName, r.Path)
+	}
+	return nil
+}
+
+// Fetch retrieves a skill by name.
+// It returns the parsed Skill and the path to the skill's source directory on disk.
+func (r *DirRegistry) Fetch(name string) (*schema.Skill, string, error) {
+	if err := r.checkRoot(); err != nil {
+		return nil, "", err
+	}
+	return r.tree().fetch(name)
+}
+
+// List returns all available skill names (directories containing a SKILL.md).
+func (r *DirRegistry) List() ([]string, error) {
+	if err := r.checkRoot(); err != nil {
+		return nil, err
+	}
+	return r.tree().list()
+}
+
+// FetchFile retrieves raw file bytes from a skill directory.
+func (r *DirRegistry) FetchFile(skillName, relPath string) ([]byte, error) {
+	if err := r.checkRoot(); err != nil {
+		return nil, err
+	}
+	return r.tree().fetchFile(skillName, relPath)
+}
+
+// ListFiles returns the names of files directly within a subdirectory of a
+// skill directory, or an empty slice if the directory does not exist.
+func (r *DirRegistry) ListFiles(skillName, relDir string) ([]string, error) {
+	if err := r.checkRoot(); err != nil {
+		return nil, err
+	}
+	return r.tree().listFiles(skillName, relDir)
+}
+
+// FetchResourceFile retrieves raw file bytes from a resource base directory.
+// kind must be one of: "skills", "instructions", "agents".
+func (r *DirRegistry) FetchResourceFile(kind, relPath string) ([]byte, error) {
+	if err := r.checkRoot(); err != nil {
+		return nil, err
+	}
+	return r.tree().fetchResourceFile(kind, relPath)
+}
+
+// FetchRootFile retrieves raw file bytes by path relative to the registry
+// directory. Paths may not escape it.
+func (r *DirRegistry) FetchRootFile(relPath string) ([]byte, error) {
+	if err := r.checkRoot(); err != nil {
+		return nil, err
+	}
+	return r.tree().fetchRootFile(relPath)
+}
+
+// ListResourceFiles recursively lists files under the configured base path for
+// the requested resource kind. Returned paths are relative to that base path.
+func (r *DirRegistry) ListResourceFiles(kind string) ([]string, error) {
+	if err := r.checkRoot(); err != nil {
+		return nil, err
+	}
+	return r.tree().listResourceFiles(kind)
+}
+
+// Index returns the registry's index file (see IndexFilenames), or nil when
+// it has none.
+func (r *DirRegistry) Index() (*Index, error) {
+	if err := r.checkRoot(); err != nil {
+		return nil, err
+	}
+	return r.tree().index()
+}
@@ -0,0 +1,70 @@
+package registry
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeDirRegistryFile(t *testing.T, root, rel, content string) {
+	t.Helper()
+	path := filepath.Join(root, rel)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write %s: %v", rel, err)
+	}
+}
+
+func TestDirRegistry_ServesConfiguredPaths(t *testing.T) {
+	root := t.TempDir()
+	writeDirRegistryFile(t, root, "skills/tdd/SKILL.md", archiveSkill)
+	writeDirRegistryFile(t, root, "docs/instructions/style.md", "Use tabs.")
+	writeDirRegistryFile(t, root, "docs/agents/helper.md", "helper agent")
+
+	r := &DirRegistry{RegistryName: "shared", Path: root, SkillsPath: "skills", InstructionsPath: "docs/instructions", AgentsPath: "docs/agents"}
+
+	sk, dir, err := r.Fetch("tdd")
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if sk.Name != "tdd" || dir != filepath.Join(root, "skills", "tdd") {
+		t.Fatalf("unexpected skill %q at %s", sk.Name, dir)
+	}
+	inst, err := r.FetchResourceFile("instructions", "style.md")
+	if err != nil || string(inst) != "Use tabs." {
+		t.Fatalf("FetchResourceFile: %q, %v", inst, err)
+	}
+	agents, err := r.ListResourceFiles("agents")
+	if err != nil || !reflect.DeepEqual(agents, []string{"helper.md"}) {
+		t.Fatalf("ListResourceFiles: %v, %v", agents, err)
+	}
+}
+
+func TestDirRegistry_ReadsLive(t *testing.T) {
+	root := t.TempDir()
+	writeDirRegistryFile(t, root, "tdd/SKILL.md", archiveSkill)
+	r := &DirRegistry{RegistryName: "shared", Path: root}
+
+	names, err := r.List()
+	if err != nil || !reflect.DeepEqual(names, []string{"tdd"}) {
+		t.Fatalf("List: %v, %v", names, err)
+	}
+
+	writeDirRegistryFile(t, root, "review/SKILL.md", strings.Replace(archiveSkill, "name: tdd", "name: review", 1))
+	names, err = r.List()
+	if err != nil || !reflect.DeepEqual(names, []string{"review", "tdd"}) {
+		t.Fatalf("expected new skill without refresh, got %v, %v", names, err)
+	}
+}
+
+func TestDirRegistry_MissingDirectory(t *testing.T) {
+	r := &DirRegistry{RegistryName: "shared", Path: filepath.Join(t.TempDir(), "unmounted")}
+	_, err := r.List()
+	if err == nil || !strings.Contains(err.Error(), `registry "shared"`) {
+		t.Fatalf("expected missing directory error, got %v", err)
+	}
+}
@@ -1,37 +1,44 @@
 package registry
 
 import (
-	"embed"
 	"fmt"
 	"io/fs"
-	"os"
-	"path/filepath"
+	"path"
 	"sort"
 
+	"github.com/chaz8081/positive-vibes/agents"
+	"github.com/chaz8081/positive-vibes/instructions"
 	"github.com/chaz8081/positive-vibes/pkg/schema"
 	skills "github.com/chaz8081/positive-vibes/skills"
 )
 
-// EmbeddedRegistry serves skills embedded into the binary.
+// EmbeddedRegistry serves skills, instructions and agents embedded into the
+// binary. Everything is read straight from the embedded filesystems; nothing
+// is written to disk.
 type EmbeddedRegistry struct {
-	RegistryName string
-	FS           embed.FS
+	RegistryName   string
+	FS             fs.FS // skill directories, one per skill
+	InstructionsFS fs.FS
+	AgentsFS       fs.FS
 }
 
-// NewEmbeddedRegistry constructs an EmbeddedRegistry using the embedded skills FS.
+// NewEmbeddedRegistry constructs an EmbeddedRegistry using the bundled FSes.
 func NewEmbeddedRegistry() *EmbeddedRegistry {
 	return &EmbeddedRegistry{
-		RegistryName: "embedded",
-		FS:           skills.SkillsFS,
+		RegistryName:   "embedded",
+		FS:             skills.SkillsFS,
+		InstructionsFS: instructions.InstructionsFS,
+		AgentsFS:       agents.AgentsFS,
 	}
 }
 
 func (e *EmbeddedRegistry) Name() string { return e.RegistryName }
 
-// Fetch reads the SKILL.md from the embedded FS, parses it and writes it to a temp dir.
+// Fetch parses the embedded SKILL.md for name. Embedded skills have no
+// directory on disk, so the returned source dir is always empty; use SkillFS
+// to read the full skill directory.
 func (e *EmbeddedRegistry) Fetch(name string) (*schema.Skill, string, error) {
-	rel := filepath.Join(name, "SKILL.md")
-	b, err := e.FS.ReadFile(rel)
+	b, err := fs.ReadFile(e.FS, path.Join(name, "SKILL.md"))
 	if err != nil {
 		return nil, "", fmt.Errorf("skill %s not found: %w", name, err)
 	}
@@ -40,24 +47,15 @@ func (e *EmbeddedRegistry) Fetch(name string) (*schema.Skill, string, error) {
 	if err != nil {
 		return nil, "", err
 	}
+	return sk, "", nil
+}
 
-	// write to temp dir
-	tmp, err := os.MkdirTemp("", "pv-skill-")
-	if err != nil {
-		return nil, "", err
-	}
-
-	// create dir for skill
-	skillDir := filepath.Join(tmp, name)
-	if err := os.MkdirAll(skillDir, 0o755); err != nil {
-		return nil, "", err
-	}
-
-	if err := os.WriteFile(filepath.Join(skillDir, "SKILL.md"), b, 0o644); err != nil {
-		return nil, "", err
+// SkillFS returns the embedded directory for the named skill.
+func (e *EmbeddedRegistry) SkillFS(name string) (fs.FS, error) {
+	if _, err := fs.Stat(e.FS, path.Join(name, "SKILL.md")); err != nil {
+		return nil, fmt.Errorf("skill %s not found: %w", name, err)
 	}
-
-	return sk, skillDir, nil
+	return fs.Sub(e.FS, name)
 }
 
 // List returns all skill names embedded.
@@ -71,8 +69,7 @@ func (e *EmbeddedRegistry) List() ([]string, error) {
 	for _, ent := range entries {
 		if ent.IsDir() {
 			// ensure SKILL.md exists
-			p := filepath.Join(ent.Name(), "SKILL.md")
-			if _, err := e.FS.ReadFile(p); err == nil {
+			if _, err := fs.Stat(e.FS, path.Join(ent.Name(), "SKILL.md")); err == nil {
 				names = append(names, ent.Name())
 			}
 		}
@@ -80,3 +77,78 @@ func (e *EmbeddedRegistry) List() ([]string, error) {
 	sort.Strings(names)
 	return names, nil
 }
+
+// FetchFile retrieves raw file bytes from an embedded skill directory.
+func (e *EmbeddedRegistry) FetchFile(skillName, relPath string) ([]byte, error) {
+	data, err := fs.ReadFile(e.FS, path.Join(skillName, relPath))
+	if err != nil {
+		return nil, fmt.Errorf("file not found: %s/%s (registry %s)", skillName, relPath, e.RegistryName)
+	}
+	return data, nil
+}
+
+// ListFiles returns the names of files directly within a subdirectory of an
+// embedded skill. Returns an empty slice if the directory does not exist.
+func (e *EmbeddedRegistry) ListFiles(skillName, relDir string) ([]string, error) {
+	entries, err := fs.ReadDir(e.FS, path.Join(skillName, relDir))
+	if err != nil {
+		return nil, nil
+	}
+	var names []string
+	for _, ent := range entries {
+		if !ent.IsDir() {
+			names = append(names, ent.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (e *EmbeddedRegistry) kindFS(kind string) fs.FS {
+	switch kind {
+	case "skills":
+		return e.FS
+	case "instructions":
+		return e.InstructionsFS
+	case "agents":
+		return e.AgentsFS
+	}
+	return nil
+}
+
+// FetchResourceFile retrieves raw file bytes for a bundled resource.
+// kind must be one of: "skills", "instructions", "agents".
+func (e *EmbeddedRegistry) FetchResourceFile(kind, relPath string) ([]byte, error) {
+	fsys := e.kindFS(kind)
+	if fsys == nil {
+		return nil, fmt.Errorf("file not found: %s (registry %s, kind %s)", relPath, e.RegistryName, kind)
+	}
+	data, err := fs.ReadFile(fsys, path.Clean(relPath))
+	if err != nil {
+		return nil, fmt.Errorf("file not found: %s (registry %s, kind %s)", relPath, e.RegistryName, kind)
+	}
+	return data, nil
+}
+
+// ListResourceFiles recursively lists bundled files for the requested kind.
+func (e *EmbeddedRegistry) ListResourceFiles(kind string) ([]string, error) {
+	fsys := e.kindFS(kind)
+	if fsys == nil {
+		return nil, nil
+	}
+	var names []string
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			names = append(names, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+	return names, nil
+}
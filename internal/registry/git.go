@@ -5,14 +5,11 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
-	"sort"
 	"strings"
 
 	"github.com/chaz8081/positive-vibes/pkg/schema"
 	git "github.com/go-git/go-git/v5"
-	"github.com/go-git/go-git/v5/plumbing"
-	"github.com/go-git/go-git/v5/plumbing/transport"
-	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/go-git/go-git/v5/config"
 )
 
 // GitRegistry fetches skills from a remote (or local) git repository.
@@ -21,11 +18,14 @@ import (
 type GitRegistry struct {
 	RegistryName     string
 	URL              string
-	CachePath        string // directory to clone into; e.g., ~/.positive-vibes/cache/<name>/
+	CachePath        string // directory to clone into; e.g., ~/.positive-vibes/cache/<name>-<key>/ (see CacheKey)
+	ObjectsPath      string // shared object store for every ref of URL (see RepoKey); empty keeps objects in the clone
+	LegacyCachePath  string // pre-CacheKey location of this registry's cache, adopted if it matches
 	SkillsPath       string // subdirectory inside the repo where skills live; defaults to "."
 	InstructionsPath string // base path for instructions in registry; defaults to "."
 	AgentsPath       string // base path for agents in registry; defaults to "."
 	Ref              string // "latest", branch name, tag name, or commit SHA
+	Auth             *GitAuth
 }
 
 const RefLatest = "latest"
@@ -42,18 +42,8 @@ func (r *GitRegistry) isPinned() bool {
 
 func (r *GitRegistry) Name() string { return r.RegistryName }
 
-// authMethod returns the appropriate transport.AuthMethod for the URL.
-// For SSH URLs (git@... or ssh://...), it attempts to use the system SSH agent.
-// For HTTPS or local paths, no auth is needed.
-func (r *GitRegistry) authMethod() transport.AuthMethod {
-	if isSSHURL(r.URL) {
-		auth, err := gitssh.NewSSHAgentAuth("git")
-		if err == nil {
-			return auth
-		}
-	}
-	return nil
-}
+// CacheDir returns the directory holding the clone.
+func (r *GitRegistry) CacheDir() string { return r.CachePath }
 
 // isSSHURL returns true if the URL looks like an SSH git URL.
 func isSSHURL(url string) bool {
@@ -61,105 +51,84 @@ func isSSHURL(url string) bool {
 }
 
 // ensureCache clones the repository into CachePath if it does not already exist.
+// Branch and tag refs (and "latest") are fetched at depth 1, and full commit
+// SHAs are fetched on their own when the server allows it, so only one
+// commit's objects are downloaded. When ObjectsPath is set, objects go into
+// that shared store, and the checkout borrows them from it. When every
+// configured path is a subdirectory, the worktree is a sparse checkout of
+// those paths; see sparseDirs. History is fetched later, on demand, by
+// Deepen. A cache left at LegacyCachePath is adopted first if it matches.
+//
 // If the clone fails but a cached copy already exists, it silently returns nil
 // so callers can continue with stale data.
 func (r *GitRegistry) ensureCache() error {
+	r.adoptLegacyCache()
 	if _, err := os.Stat(filepath.Join(r.CachePath, ".git")); err == nil {
-		// Cache already populated.
-		return nil
-	}
-
-	cloneOpts := &git.CloneOptions{
-		URL:  r.URL,
-		Auth: r.authMethod(),
-	}
-
-	var repo *git.Repository
-	var err error
-
-	// For non-SHA pinned refs, attempt branch clone first (single-branch), then tag.
-	if r.isPinned() && !isSHA(r.Ref) {
-		cloneOpts.ReferenceName = plumbing.NewBranchReferenceName(r.Ref)
-		cloneOpts.SingleBranch = true
+		// Cache already populated; re-clone only if the sparse paths changed
+		// or its object store was deleted.
+		store := linkedStore(r.CachePath)
+		if !r.sparseStale() && (store == "" || exists(store)) {
+			return nil
+		}
+		return r.reclone()
 	}
 
-	repo, err = git.PlainClone(r.CachePath, false, cloneOpts)
+	auth, err := r.authMethod()
 	if err != nil {
-		if r.isPinned() && !isSHA(r.Ref) {
-			// try tag
-			_ = os.RemoveAll(r.CachePath)
-			cloneOpts.ReferenceName = plumbing.NewTagReferenceName(r.Ref)
-			cloneOpts.SingleBranch = true
-			repo, err = git.PlainClone(r.CachePath, false, cloneOpts)
-		}
-		if err != nil {
-			// If we somehow have a partial cache, allow fallback.
-			if _, statErr := os.Stat(r.CachePath); statErr == nil {
-				return nil
-			}
-			if r.isPinned() && !isSHA(r.Ref) {
-				return fmt.Errorf("registry %q: ref %q not found as branch or tag in %s", r.RegistryName, r.Ref, r.URL)
-			}
-			return fmt.Errorf("git clone %s: %w", r.URL, err)
-		}
+		return err
 	}
 
-	// If pinned to a commit SHA, checkout that hash.
-	if r.isPinned() && isSHA(r.Ref) {
-		// Ensure repo is cloned (full clone)
-		if repo == nil {
-			repo, err = git.PlainClone(r.CachePath, false, &git.CloneOptions{
-				URL:  r.URL,
-				Auth: r.authMethod(),
-			})
-			if err != nil {
-				if _, statErr := os.Stat(r.CachePath); statErr == nil {
-					return nil
-				}
-				return fmt.Errorf("git clone %s: %w", r.URL, err)
-			}
-		}
-
-		wt, err := repo.Worktree()
-		if err != nil {
-			return fmt.Errorf("worktree: %w", err)
-		}
-		err = wt.Checkout(&git.CheckoutOptions{
-			Hash: plumbing.NewHash(r.Ref),
-		})
-		if err != nil {
-			return fmt.Errorf("registry %q: commit %s not found: %w", r.RegistryName, r.Ref, err)
+	_, statErr := os.Stat(r.CachePath)
+	partial := statErr == nil
+	if err := r.clone(auth); err != nil {
+		if partial {
+			// If we somehow have a partial cache, allow fallback, without
+			// the repository the failed clone started in it.
+			_ = os.RemoveAll(filepath.Join(r.CachePath, ".git"))
+			return nil
 		}
+		_ = os.RemoveAll(r.CachePath)
+		return err
 	}
-
 	return nil
 }
 
-// skillsDir returns the absolute path to the directory containing skills.
-func (r *GitRegistry) skillsDir() string {
-	sp := r.SkillsPath
-	if sp == "" || sp == "." {
-		return r.CachePath
+// reclone replaces the cache with a fresh clone made next to it, so that the
+// existing copy stays in place when the clone fails, e.g. offline.
+func (r *GitRegistry) reclone() error {
+	auth, err := r.authMethod()
+	if err != nil {
+		return err
 	}
-	return filepath.Join(r.CachePath, sp)
-}
+	tmp, err := os.MkdirTemp(filepath.Dir(r.CachePath), filepath.Base(r.CachePath)+".download-*")
+	if err != nil {
+		return fmt.Errorf("create cache dir: %w", err)
+	}
+	defer os.RemoveAll(tmp)
 
-func (r *GitRegistry) kindDir(kind string) string {
-	var p string
-	switch kind {
-	case "skills":
-		p = r.SkillsPath
-	case "instructions":
-		p = r.InstructionsPath
-	case "agents":
-		p = r.AgentsPath
-	default:
-		p = "."
+	staged := *r
+	staged.CachePath = filepath.Join(tmp, "cache")
+	if err := staged.clone(auth); err != nil {
+		return nil // keep using the existing copy
+	}
+	if err := os.RemoveAll(r.CachePath); err != nil {
+		return fmt.Errorf("reset cache %s: %w", r.CachePath, err)
+	}
+	if err := os.Rename(staged.CachePath, r.CachePath); err != nil {
+		return fmt.Errorf("reset cache %s: %w", r.CachePath, err)
 	}
-	if p == "" || p == "." {
-		return r.CachePath
+	return nil
+}
+
+// tree reads the cloned worktree.
+func (r *GitRegistry) tree() dirTree {
+	return dirTree{
+		registryName:     r.RegistryName,
+		root:             r.CachePath,
+		skillsPath:       r.SkillsPath,
+		instructionsPath: r.InstructionsPath,
+		agentsPath:       r.AgentsPath,
 	}
-	return filepath.Join(r.CachePath, p)
 }
 
 // Fetch retrieves a skill by name.
@@ -168,21 +137,7 @@ func (r *GitRegistry) Fetch(name string) (*schema.Skill, string, error) {
 	if err := r.ensureCache(); err != nil {
 		return nil, "", err
 	}
-
-	srcDir := filepath.Join(r.skillsDir(), name)
-	skillFile := filepath.Join(srcDir, "SKILL.md")
-
-	data, err := os.ReadFile(skillFile)
-	if err != nil {
-		return nil, "", fmt.Errorf("skill not found: %s (registry %s)", name, r.RegistryName)
-	}
-
-	sk, err := schema.ParseSkillFile(data)
-	if err != nil {
-		return nil, "", fmt.Errorf("parse skill %s: %w", name, err)
-	}
-
-	return sk, srcDir, nil
+	return r.tree().fetch(name)
 }
 
 // List returns all available skill names (directories containing a SKILL.md).
@@ -190,24 +145,7 @@ func (r *GitRegistry) List() ([]string, error) {
 	if err := r.ensureCache(); err != nil {
 		return nil, err
 	}
-
-	base := r.skillsDir()
-	entries, err := os.ReadDir(base)
-	if err != nil {
-		return nil, fmt.Errorf("read skills dir: %w", err)
-	}
-
-	var names []string
-	for _, ent := range entries {
-		if !ent.IsDir() {
-			continue
-		}
-		if _, err := os.Stat(filepath.Join(base, ent.Name(), "SKILL.md")); err == nil {
-			names = append(names, ent.Name())
-		}
-	}
-	sort.Strings(names)
-	return names, nil
+	return r.tree().list()
 }
 
 // FetchFile retrieves raw file bytes from a skill directory in the registry.
@@ -217,13 +155,7 @@ func (r *GitRegistry) FetchFile(skillName, relPath string) ([]byte, error) {
 	if err := r.ensureCache(); err != nil {
 		return nil, err
 	}
-
-	path := filepath.Join(r.skillsDir(), skillName, relPath)
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return nil, fmt.Errorf("file not found: %s/%s (registry %s)", skillName, relPath, r.RegistryName)
-	}
-	return data, nil
+	return r.tree().fetchFile(skillName, relPath)
 }
 
 // ListFiles returns the names of files directly within a subdirectory of a
@@ -233,24 +165,7 @@ func (r *GitRegistry) ListFiles(skillName, relDir string) ([]string, error) {
 	if err := r.ensureCache(); err != nil {
 		return nil, err
 	}
-
-	dir := filepath.Join(r.skillsDir(), skillName, relDir)
-	entries, err := os.ReadDir(dir)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, nil
-		}
-		return nil, fmt.Errorf("read dir %s/%s: %w", skillName, relDir, err)
-	}
-
-	var names []string
-	for _, ent := range entries {
-		if !ent.IsDir() {
-			names = append(names, ent.Name())
-		}
-	}
-	sort.Strings(names)
-	return names, nil
+	return r.tree().listFiles(skillName, relDir)
 }
 
 // FetchResourceFile retrieves raw file bytes from a resource base directory.
@@ -259,51 +174,36 @@ func (r *GitRegistry) FetchResourceFile(kind, relPath string) ([]byte, error) {
 	if err := r.ensureCache(); err != nil {
 		return nil, err
 	}
-	path := filepath.Join(r.kindDir(kind), relPath)
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return nil, fmt.Errorf("file not found: %s (registry %s, kind %s)", relPath, r.RegistryName, kind)
-	}
-	return data, nil
+	return r.tree().fetchResourceFile(kind, relPath)
 }
 
-// ListResourceFiles recursively lists files under the configured base path for
-// the requested resource kind. Returned paths are relative to that base path.
-func (r *GitRegistry) ListResourceFiles(kind string) ([]string, error) {
+// FetchRootFile retrieves raw file bytes by path relative to the repository
+// root, e.g. a shared manifest. Paths may not escape the repository. Files
+// outside a sparse checkout are read from the checked-out commit.
+func (r *GitRegistry) FetchRootFile(relPath string) ([]byte, error) {
 	if err := r.ensureCache(); err != nil {
 		return nil, err
 	}
-	base := r.kindDir(kind)
-	if _, err := os.Stat(base); err != nil {
-		if os.IsNotExist(err) {
-			return nil, nil
+	data, err := r.tree().fetchRootFile(relPath)
+	if err != nil && len(r.sparseDirs()) > 0 {
+		if fromHead, headErr := r.headFile(relPath); headErr == nil {
+			return fromHead, nil
 		}
-		return nil, err
 	}
+	return data, err
+}
 
-	var names []string
-	err := filepath.WalkDir(base, func(path string, d os.DirEntry, err error) error {
-		if err != nil {
-			return err
-		}
-		if d.IsDir() {
-			return nil
-		}
-		rel, err := filepath.Rel(base, path)
-		if err != nil {
-			return err
-		}
-		names = append(names, filepath.ToSlash(rel))
-		return nil
-	})
-	if err != nil {
+// ListResourceFiles recursively lists files under the configured base path for
+// the requested resource kind. Returned paths are relative to that base path.
+func (r *GitRegistry) ListResourceFiles(kind string) ([]string, error) {
+	if err := r.ensureCache(); err != nil {
 		return nil, err
 	}
-	sort.Strings(names)
-	return names, nil
+	return r.tree().listResourceFiles(kind)
 }
 
-// Refresh pulls the latest changes from the remote into the cached worktree.
+// Refresh fetches the latest commit of the cached branch and resets the
+// worktree to it.
 // If the cache does not exist yet, it clones instead.
 // For pinned refs (anything other than "latest" or empty), refresh is a no-op
 // since the cached checkout already has the correct content.
@@ -319,22 +219,59 @@ func (r *GitRegistry) Refresh() error {
 		return r.ensureCache()
 	}
 
-	repo, err := git.PlainOpen(r.CachePath)
+	repo, err := openRepo(r.CachePath)
 	if err != nil {
 		return fmt.Errorf("open cached repo: %w", err)
 	}
+	store, err := objectStore(r.CachePath, repo)
+	if err != nil {
+		return err
+	}
 
 	wt, err := repo.Worktree()
 	if err != nil {
 		return fmt.Errorf("worktree: %w", err)
 	}
 
-	err = wt.Pull(&git.PullOptions{
-		Auth: r.authMethod(),
-	})
-	if err != nil && err != git.NoErrAlreadyUpToDate {
-		return fmt.Errorf("git pull: %w", err)
+	auth, err := r.authMethod()
+	if err != nil {
+		return err
+	}
+
+	// Fetch only the new tip and move the worktree to it. Unlike a pull,
+	// this keeps the clone shallow and the checkout sparse. Another clone
+	// sharing the object store may have fetched the tip already.
+	spec, err := r.headRefSpec(repo)
+	if err != nil {
+		return err
+	}
+	if err := r.fetch(store, []config.RefSpec{spec}, r.fetchDepth(store), auth); err != nil {
+		return fmt.Errorf("git fetch: %w", err)
 	}
 
+	tip, err := store.Reference(spec.Dst(""), true)
+	if err != nil {
+		return fmt.Errorf("registry %q: resolve %s: %w", r.RegistryName, spec.Dst(""), err)
+	}
+	if head, err := repo.Head(); err == nil && head.Hash() == tip.Hash() {
+		markRefreshed(r.CachePath)
+		return nil
+	}
+	if err := copyShallow(store, repo); err != nil {
+		return err
+	}
+	if err := wt.ResetSparsely(&git.ResetOptions{Commit: tip.Hash(), Mode: git.HardReset}, r.sparseDirs()); err != nil {
+		return fmt.Errorf("git reset: %w", err)
+	}
+	markRefreshed(r.CachePath)
 	return nil
 }
+
+// Index returns the registry's index file (see IndexFilenames), or nil when
+// it has none.
+func (r *GitRegistry) Index() (*Index, error) {
+	if err := r.ensureCache(); err != nil {
+		return nil, err
+	}
+	return r.tree().index()
+}
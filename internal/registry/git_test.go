@@ -841,6 +841,31 @@ func TestGitRegistry_FetchResourceFile_WithCustomPaths(t *testing.T) {
 	assert.Equal(t, "# Reviewer", string(agent))
 }
 
+func TestGitRegistry_FetchRootFile(t *testing.T) {
+	repoDir := setupTestGitRepoWithFiles(t, ".", map[string]string{
+		"manifests/base.yaml":    "skills:\n  - name: shared\n",
+		"repo-skills/a/SKILL.md": "---\nname: a\n---\n# A\n",
+	})
+
+	reg := &GitRegistry{
+		RegistryName: "company",
+		URL:          repoDir,
+		CachePath:    filepath.Join(t.TempDir(), "company"),
+		SkillsPath:   "repo-skills",
+	}
+
+	data, err := reg.FetchRootFile("manifests/base.yaml")
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "shared")
+
+	_, err = reg.FetchRootFile("../outside.yaml")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "inside the repository")
+
+	_, err = reg.FetchRootFile("manifests/missing.yaml")
+	require.Error(t, err)
+}
+
 func TestGitRegistry_Fetch_PinnedRef_FallsBackToCache(t *testing.T) {
 	repoDir := setupTestGitRepo(t, ".", map[string]string{
 		"cached-skill": "---\nname: cached-skill\n---\n# Cached\n",
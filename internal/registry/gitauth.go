@@ -0,0 +1,328 @@
+package registry
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+)
+
+// GitAuth holds the credential settings of a git registry. It never holds a
+// secret itself: tokens are read from the named environment variable and
+// keys from files, at the moment a clone or pull needs them.
+type GitAuth struct {
+	TokenEnv   string // environment variable holding an HTTPS token
+	Username   string // username sent with the token; defaults to defaultTokenUsername
+	SSHKey     string // private key file for SSH URLs; the SSH agent is used when empty
+	KnownHosts string // known_hosts file for verifying SSH host keys
+}
+
+// defaultTokenUsername is accepted by GitHub, GitHub Enterprise and Gitea
+// for token authentication; other hosts ignore the username.
+const defaultTokenUsername = "x-access-token"
+
+// RedactURL hides the credentials in rawURL's userinfo so that it can be
+// printed: a password becomes "xxxxx", as url.URL.Redacted does, and so does
+// a username alone on an HTTP(S) URL, where it is usually a token. SSH
+// usernames are kept.
+func RedactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.User == nil {
+		return rawURL
+	}
+	if _, hasPassword := u.User.Password(); hasPassword {
+		return u.Redacted()
+	}
+	if !isHTTPURL(rawURL) {
+		return rawURL
+	}
+	u.User = url.User("xxxxx")
+	return u.String()
+}
+
+// credentialFill asks git's configured credential helpers for the
+// credentials of rawURL and returns ok=false when none are stored. It is a
+// variable so tests can stand in for the helper.
+var credentialFill = gitCredentialFill
+
+// authMethod returns the transport.AuthMethod for the registry URL.
+//
+// SSH URLs (git@... or ssh://...) use Auth.SSHKey when set and the SSH agent
+// otherwise, verifying hosts against Auth.KnownHosts when set.
+//
+// HTTP(S) URLs try, in order: the token in Auth.TokenEnv, a matching
+// ~/.netrc entry (or $NETRC), and `git credential fill`. When nothing
+// matches the request is sent without credentials. Local paths need no auth.
+//
+// Errors name the variable or file involved but never include a secret.
+func (r *GitRegistry) authMethod() (transport.AuthMethod, error) {
+	switch {
+	case isSSHURL(r.URL):
+		return r.sshAuth()
+	case isHTTPURL(r.URL):
+		return r.httpAuth()
+	}
+	return nil, nil
+}
+
+func (r *GitRegistry) sshAuth() (transport.AuthMethod, error) {
+	var cfg GitAuth
+	if r.Auth != nil {
+		cfg = *r.Auth
+	}
+
+	user := sshUser(r.URL)
+	if cfg.SSHKey != "" {
+		keyPath := expandHome(cfg.SSHKey)
+		keys, err := gitssh.NewPublicKeysFromFile(user, keyPath, "")
+		if err != nil {
+			return nil, fmt.Errorf("registry %q: load ssh key %s: %w", r.RegistryName, keyPath, err)
+		}
+		if cfg.KnownHosts != "" {
+			if err := setKnownHosts(&keys.HostKeyCallbackHelper, cfg.KnownHosts); err != nil {
+				return nil, fmt.Errorf("registry %q: %w", r.RegistryName, err)
+			}
+		}
+		return keys, nil
+	}
+
+	agent, err := gitssh.NewSSHAgentAuth(user)
+	if err != nil {
+		// No agent: let the clone fail with the server's error.
+		return nil, nil
+	}
+	if cfg.KnownHosts != "" {
+		if err := setKnownHosts(&agent.HostKeyCallbackHelper, cfg.KnownHosts); err != nil {
+			return nil, fmt.Errorf("registry %q: %w", r.RegistryName, err)
+		}
+	}
+	return agent, nil
+}
+
+func setKnownHosts(helper *gitssh.HostKeyCallbackHelper, path string) error {
+	path = expandHome(path)
+	cb, err := gitssh.NewKnownHostsCallback(path)
+	if err != nil {
+		return fmt.Errorf("load known_hosts %s: %w", path, err)
+	}
+	helper.HostKeyCallback = cb
+	return nil
+}
+
+func (r *GitRegistry) httpAuth() (transport.AuthMethod, error) {
+	u, err := url.Parse(r.URL)
+	if err != nil {
+		return nil, nil
+	}
+	if _, hasPassword := u.User.Password(); hasPassword {
+		// Credentials embedded in the URL are used by the transport as-is.
+		return nil, nil
+	}
+
+	if r.Auth != nil && r.Auth.TokenEnv != "" {
+		token := os.Getenv(r.Auth.TokenEnv)
+		if token == "" {
+			return nil, fmt.Errorf("registry %q: environment variable %s is not set", r.RegistryName, r.Auth.TokenEnv)
+		}
+		username := r.Auth.Username
+		if username == "" {
+			username = defaultTokenUsername
+		}
+		return &githttp.BasicAuth{Username: username, Password: token}, nil
+	}
+
+	if login, password, ok := netrcLookup(netrcPath(), u.Hostname()); ok {
+		return &githttp.BasicAuth{Username: login, Password: password}, nil
+	}
+
+	if username, password, ok := credentialFill(r.URL); ok {
+		return &githttp.BasicAuth{Username: username, Password: password}, nil
+	}
+	return nil, nil
+}
+
+// isHTTPURL returns true if the URL uses the http or https scheme.
+func isHTTPURL(rawURL string) bool {
+	return strings.HasPrefix(rawURL, "https://") || strings.HasPrefix(rawURL, "http://")
+}
+
+// sshUser returns the user in an SSH URL, defaulting to "git".
+func sshUser(rawURL string) string {
+	if strings.HasPrefix(rawURL, "ssh://") {
+		if u, err := url.Parse(rawURL); err == nil && u.User != nil && u.User.Username() != "" {
+			return u.User.Username()
+		}
+		return "git"
+	}
+	if user, _, ok := strings.Cut(rawURL, "@"); ok && user != "" {
+		return user
+	}
+	return "git"
+}
+
+// expandHome replaces a leading "~" with the user's home directory.
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~"))
+}
+
+func netrcPath() string {
+	if p := os.Getenv("NETRC"); p != "" {
+		return p
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".netrc")
+}
+
+// netrcLookup returns the login and password for host from the netrc file
+// at path, falling back to its "default" entry.
+func netrcLookup(path, host string) (login, password string, ok bool) {
+	if path == "" {
+		return "", "", false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", "", false
+	}
+	return parseNetrc(data, host)
+}
+
+func parseNetrc(data []byte, host string) (login, password string, ok bool) {
+	type entry struct{ login, password string }
+	var matched, fallback *entry
+	var cur *entry
+
+	fields := netrcFields(data)
+	for i := 0; i < len(fields); i++ {
+		switch fields[i] {
+		case "machine":
+			cur = nil
+			if i+1 < len(fields) {
+				i++
+				if fields[i] == host && matched == nil {
+					matched = &entry{}
+					cur = matched
+				}
+			}
+		case "default":
+			cur = nil
+			if fallback == nil {
+				fallback = &entry{}
+				cur = fallback
+			}
+		case "login", "password", "account":
+			if i+1 >= len(fields) {
+				break
+			}
+			i++
+			if cur == nil {
+				continue
+			}
+			if fields[i-1] == "login" {
+				cur.login = fields[i]
+			} else if fields[i-1] == "password" {
+				cur.password = fields[i]
+			}
+		}
+	}
+	for _, e := range []*entry{matched, fallback} {
+		if e != nil && e.password != "" {
+			return e.login, e.password, true
+		}
+	}
+	return "", "", false
+}
+
+// netrcFields splits a netrc file into tokens, dropping macro definitions
+// (a "macdef" line and everything up to the next blank line).
+func netrcFields(data []byte) []string {
+	var fields []string
+	inMacro := false
+	for _, line := range strings.Split(string(data), "\n") {
+		words := strings.Fields(line)
+		if inMacro {
+			inMacro = len(words) > 0
+			continue
+		}
+		for i, w := range words {
+			if w == "macdef" {
+				words, inMacro = words[:i], true
+				break
+			}
+		}
+		fields = append(fields, words...)
+	}
+	return fields
+}
+
+// gitCredentialFill runs `git credential fill` for rawURL with prompting
+// disabled, so a missing credential never blocks CI waiting for input.
+func gitCredentialFill(rawURL string) (username, password string, ok bool) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", "", false
+	}
+	if _, err := exec.LookPath("git"); err != nil {
+		return "", "", false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var input bytes.Buffer
+	fmt.Fprintf(&input, "protocol=%s\nhost=%s\n", u.Scheme, u.Host)
+	if p := strings.TrimPrefix(u.Path, "/"); p != "" {
+		fmt.Fprintf(&input, "path=%s\n", p)
+	}
+	if u.User != nil && u.User.Username() != "" {
+		fmt.Fprintf(&input, "username=%s\n", u.User.Username())
+	}
+	input.WriteString("\n")
+
+	cmd := exec.CommandContext(ctx, "git", "credential", "fill")
+	cmd.Stdin = &input
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GCM_INTERACTIVE=never", "GIT_ASKPASS=", "SSH_ASKPASS=")
+	out, err := cmd.Output()
+	if err != nil {
+		return "", "", false
+	}
+	return parseCredentialOutput(out)
+}
+
+// parseCredentialOutput reads the key=value lines written by
+// `git credential fill`.
+func parseCredentialOutput(out []byte) (username, password string, ok bool) {
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for sc.Scan() {
+		key, value, found := strings.Cut(sc.Text(), "=")
+		if !found {
+			continue
+		}
+		switch key {
+		case "username":
+			username = value
+		case "password":
+			password = value
+		}
+	}
+	return username, password, password != ""
+}
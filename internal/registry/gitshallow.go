@@ -0,0 +1,279 @@
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// sparseMarker records, inside the clone's .git directory, the paths a
+// sparse checkout was made with, so a change to the registry's paths can be
+// detected.
+const sparseMarker = "positive-vibes-sparse"
+
+// pinnedRefPrefix is where commits fetched by SHA are stored in the object
+// store, one ref per commit.
+const pinnedRefPrefix = "refs/positive-vibes/pinned/"
+
+// CommitInfo describes one commit of a registry's history.
+type CommitInfo struct {
+	Hash    string
+	Subject string
+	Author  string
+	When    time.Time
+}
+
+// sparseDirs returns the path prefixes to check out, or nil for a full
+// checkout. Any path at the repository root ("." or empty) needs the whole
+// tree. Index files at the root are always included.
+func (r *GitRegistry) sparseDirs() []string {
+	var dirs []string
+	seen := make(map[string]bool)
+	for _, p := range []string{r.SkillsPath, r.InstructionsPath, r.AgentsPath} {
+		clean := path.Clean(filepath.ToSlash(p))
+		if clean == "." || clean == "/" || strings.HasPrefix(clean, "../") {
+			return nil
+		}
+		clean = strings.TrimPrefix(clean, "/") + "/"
+		if !seen[clean] {
+			seen[clean] = true
+			dirs = append(dirs, clean)
+		}
+	}
+	return append(dirs, IndexFilenames...)
+}
+
+// sparseStale reports whether the cached checkout is sparse with different
+// paths than the registry is now configured with. A full checkout is never
+// stale, since it holds every path.
+func (r *GitRegistry) sparseStale() bool {
+	data, err := os.ReadFile(filepath.Join(r.CachePath, ".git", sparseMarker))
+	if err != nil {
+		return false
+	}
+	return string(data) != strings.Join(r.sparseDirs(), "\n")
+}
+
+// checkout populates the worktree of a clone made with NoCheckout, limited
+// to sparseDirs. opts selects the branch or commit.
+func (r *GitRegistry) checkout(repo *git.Repository, opts *git.CheckoutOptions) error {
+	wt, err := repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("worktree: %w", err)
+	}
+	dirs := r.sparseDirs()
+	opts.SparseCheckoutDirectories = dirs
+	opts.Force = true
+	if err := wt.Checkout(opts); err != nil {
+		if !opts.Hash.IsZero() {
+			return fmt.Errorf("registry %q: commit %s not found: %w", r.RegistryName, r.Ref, err)
+		}
+		return fmt.Errorf("registry %q: checkout: %w", r.RegistryName, err)
+	}
+	markRefreshed(r.CachePath)
+	if len(dirs) == 0 {
+		return nil
+	}
+	return os.WriteFile(filepath.Join(r.CachePath, ".git", sparseMarker), []byte(strings.Join(dirs, "\n")), 0o644)
+}
+
+// pinnedRef is the ref holding the commit Ref pins.
+func (r *GitRegistry) pinnedRef() plumbing.ReferenceName {
+	return plumbing.ReferenceName(pinnedRefPrefix + r.Ref)
+}
+
+// isShallow reports whether the clone is missing history.
+func isShallow(repo *git.Repository) bool {
+	shallow, err := repo.Storer.Shallow()
+	return err == nil && len(shallow) > 0
+}
+
+// fetchDepth keeps shallow clones shallow when refreshing them, and full
+// clones (including caches made before clones were shallow) complete.
+func (r *GitRegistry) fetchDepth(repo *git.Repository) int {
+	if isShallow(repo) {
+		return 1
+	}
+	return 0
+}
+
+// headRefSpec returns the refspec that fetches what the cache has checked
+// out: its branch, its tag, or the pinned commit.
+func (r *GitRegistry) headRefSpec(repo *git.Repository) (config.RefSpec, error) {
+	head, err := repo.Head()
+	if err != nil {
+		return "", fmt.Errorf("registry %q: resolve HEAD: %w", r.RegistryName, err)
+	}
+	switch {
+	case head.Name().IsBranch():
+		remoteRef := plumbing.NewRemoteReferenceName(git.DefaultRemoteName, head.Name().Short())
+		return config.RefSpec(fmt.Sprintf("+%s:%s", head.Name(), remoteRef)), nil
+	case r.isPinned() && isSHA(r.Ref):
+		return config.RefSpec(r.Ref + ":" + string(r.pinnedRef())), nil
+	case r.isPinned():
+		tag := plumbing.NewTagReferenceName(r.Ref)
+		return config.RefSpec(fmt.Sprintf("+%s:%s", tag, tag)), nil
+	}
+	return "", fmt.Errorf("registry %q: cached checkout is not on a branch", r.RegistryName)
+}
+
+// Deepen fetches more history into a shallow cache, so that it holds depth
+// commits from the checked-out one, or the full history when depth <= 0.
+// History goes into the shared object store, so every clone of the
+// repository gains it. It is a no-op for full clones.
+func (r *GitRegistry) Deepen(depth int) error {
+	if err := r.ensureCache(); err != nil {
+		return err
+	}
+	repo, err := openRepo(r.CachePath)
+	if err != nil {
+		return fmt.Errorf("open cached repo: %w", err)
+	}
+	store, err := objectStore(r.CachePath, repo)
+	if err != nil {
+		return err
+	}
+	if !isShallow(store) {
+		return copyShallow(store, repo)
+	}
+	if depth <= 0 {
+		// What `git fetch --unshallow` asks for.
+		depth = math.MaxInt32
+	}
+	spec, err := r.headRefSpec(repo)
+	if err != nil {
+		return err
+	}
+	auth, err := r.authMethod()
+	if err != nil {
+		return err
+	}
+	if err := r.fetch(store, []config.RefSpec{spec}, depth, auth); err != nil {
+		return fmt.Errorf("registry %q: deepen: %w", r.RegistryName, err)
+	}
+	if err := pruneShallow(store); err != nil {
+		return err
+	}
+	return copyShallow(store, repo)
+}
+
+// pruneShallow drops commits whose parents are now present from the
+// clone's shallow list. go-git adds new shallow commits after a deeper
+// fetch but never removes the ones the server reports as unshallowed.
+func pruneShallow(repo *git.Repository) error {
+	shallow, err := repo.Storer.Shallow()
+	if err != nil {
+		return err
+	}
+	var keep []plumbing.Hash
+	for _, h := range shallow {
+		c, err := repo.CommitObject(h)
+		if err != nil {
+			keep = append(keep, h)
+			continue
+		}
+		for _, p := range c.ParentHashes {
+			if _, err := repo.Storer.EncodedObject(plumbing.CommitObject, p); err != nil {
+				keep = append(keep, h)
+				break
+			}
+		}
+	}
+	if len(keep) == len(shallow) {
+		return nil
+	}
+	return repo.Storer.SetShallow(keep)
+}
+
+// History returns up to limit commits (all when limit <= 0) leading to the
+// checked-out commit, newest first, following first parents. A shallow
+// cache is deepened first if it holds fewer commits than asked for.
+func (r *GitRegistry) History(limit int) ([]CommitInfo, error) {
+	if err := r.ensureCache(); err != nil {
+		return nil, err
+	}
+	repo, err := openRepo(r.CachePath)
+	if err != nil {
+		return nil, fmt.Errorf("open cached repo: %w", err)
+	}
+
+	commits, complete, err := r.walkHistory(repo, limit)
+	if err != nil || complete || !isShallow(repo) {
+		return commits, err
+	}
+	if err := r.Deepen(limit); err != nil {
+		return nil, err
+	}
+	// Reopen to see the packs the deepening fetch added.
+	if repo, err = openRepo(r.CachePath); err != nil {
+		return nil, fmt.Errorf("open cached repo: %w", err)
+	}
+	commits, _, err = r.walkHistory(repo, limit)
+	return commits, err
+}
+
+// walkHistory lists up to limit first-parent commits from HEAD. complete is
+// false when the walk stopped early at the edge of a shallow clone.
+func (r *GitRegistry) walkHistory(repo *git.Repository, limit int) (commits []CommitInfo, complete bool, err error) {
+	head, err := repo.Head()
+	if err != nil {
+		return nil, false, fmt.Errorf("registry %q: resolve HEAD: %w", r.RegistryName, err)
+	}
+	c, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return nil, false, fmt.Errorf("registry %q: read commit %s: %w", r.RegistryName, head.Hash(), err)
+	}
+	for {
+		subject, _, _ := strings.Cut(c.Message, "\n")
+		commits = append(commits, CommitInfo{Hash: c.Hash.String(), Subject: subject, Author: c.Author.Name, When: c.Author.When})
+		if limit > 0 && len(commits) >= limit {
+			return commits, true, nil
+		}
+		if len(c.ParentHashes) == 0 {
+			return commits, true, nil
+		}
+		parent, err := repo.CommitObject(c.ParentHashes[0])
+		if errors.Is(err, plumbing.ErrObjectNotFound) {
+			return commits, false, nil
+		}
+		if err != nil {
+			return nil, false, fmt.Errorf("registry %q: read commit %s: %w", r.RegistryName, c.ParentHashes[0], err)
+		}
+		c = parent
+	}
+}
+
+// headFile reads relPath from the checked-out commit rather than the
+// worktree, for root files left out of a sparse checkout.
+func (r *GitRegistry) headFile(relPath string) ([]byte, error) {
+	repo, err := openRepo(r.CachePath)
+	if err != nil {
+		return nil, err
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return nil, err
+	}
+	c, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return nil, err
+	}
+	f, err := c.File(path.Clean(filepath.ToSlash(relPath)))
+	if err != nil {
+		return nil, err
+	}
+	contents, err := f.Contents()
+	if err != nil {
+		return nil, err
+	}
+	return []byte(contents), nil
+}
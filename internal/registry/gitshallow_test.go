@@ -0,0 +1,239 @@
+package registry
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	git "github.com/go-git/go-git/v5"
+)
+
+// setupLargeTestRepo creates a repo with three commits, skills and agents
+// subtrees, an unrelated docs tree and files at the root.
+func setupLargeTestRepo(t *testing.T) (string, func(args ...string) string) {
+	t.Helper()
+	repoDir := setupTestGitRepoWithFiles(t, ".", map[string]string{
+		"skills/tdd/SKILL.md":      "---\nname: tdd\ndescription: Test first\n---\n# TDD\n",
+		"agents/reviewer.agent.md": "# Reviewer\n",
+		"docs/huge.md":             strings.Repeat("lorem ipsum\n", 1000),
+		"shared/vibes.yaml":        "targets: [opencode]\n",
+		"README.md":                "# Registry\n",
+	})
+	run := makeGitRunner(t, repoDir)
+	for _, msg := range []string{"second", "third"} {
+		if err := os.WriteFile(filepath.Join(repoDir, "docs", msg+".md"), []byte(msg), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		run("add", ".")
+		run("commit", "-m", msg)
+	}
+	return repoDir, run
+}
+
+func sparseTestRegistry(t *testing.T, url string) *GitRegistry {
+	t.Helper()
+	return &GitRegistry{
+		RegistryName:     "large",
+		URL:              url,
+		CachePath:        filepath.Join(t.TempDir(), "large"),
+		SkillsPath:       "skills",
+		InstructionsPath: "instructions",
+		AgentsPath:       "agents",
+		Ref:              RefLatest,
+	}
+}
+
+func openCache(t *testing.T, reg *GitRegistry) *git.Repository {
+	t.Helper()
+	repo, err := openRepo(reg.CachePath)
+	if err != nil {
+		t.Fatalf("open cache: %v", err)
+	}
+	return repo
+}
+
+func TestGitRegistry_ShallowSparseClone(t *testing.T) {
+	repoDir, _ := setupLargeTestRepo(t)
+	reg := sparseTestRegistry(t, repoDir)
+
+	if _, _, err := reg.Fetch("tdd"); err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if !isShallow(openCache(t, reg)) {
+		t.Fatal("expected a shallow clone")
+	}
+	for _, rel := range []string{"skills/tdd/SKILL.md", "agents/reviewer.agent.md"} {
+		if _, err := os.Stat(filepath.Join(reg.CachePath, rel)); err != nil {
+			t.Fatalf("expected %s in the sparse checkout: %v", rel, err)
+		}
+	}
+	for _, rel := range []string{"docs/huge.md", "README.md"} {
+		if _, err := os.Stat(filepath.Join(reg.CachePath, rel)); !os.IsNotExist(err) {
+			t.Fatalf("expected %s to be left out of the sparse checkout, got %v", rel, err)
+		}
+	}
+
+	data, err := reg.FetchRootFile("shared/vibes.yaml")
+	if err != nil {
+		t.Fatalf("FetchRootFile outside the sparse paths: %v", err)
+	}
+	if string(data) != "targets: [opencode]\n" {
+		t.Fatalf("unexpected content %q", data)
+	}
+	if _, err := reg.FetchRootFile("../outside"); err == nil || !strings.Contains(err.Error(), "must stay inside") {
+		t.Fatalf("expected a traversal error, got %v", err)
+	}
+}
+
+func TestGitRegistry_RootPathMeansFullCheckout(t *testing.T) {
+	repoDir, _ := setupLargeTestRepo(t)
+	reg := sparseTestRegistry(t, repoDir)
+	reg.InstructionsPath = "."
+
+	if _, err := reg.List(); err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(reg.CachePath, "docs", "huge.md")); err != nil {
+		t.Fatalf("expected a full checkout: %v", err)
+	}
+}
+
+func TestGitRegistry_SparsePathsChangeRecloned(t *testing.T) {
+	repoDir, _ := setupLargeTestRepo(t)
+	reg := sparseTestRegistry(t, repoDir)
+	if _, err := reg.List(); err != nil {
+		t.Fatalf("List: %v", err)
+	}
+
+	reg.InstructionsPath = "docs"
+	files, err := reg.ListResourceFiles("instructions")
+	if err != nil {
+		t.Fatalf("ListResourceFiles: %v", err)
+	}
+	if len(files) != 3 {
+		t.Fatalf("expected the newly configured docs path to be checked out, got %v", files)
+	}
+}
+
+func TestGitRegistry_SparsePathsChangeOfflineKeepsCache(t *testing.T) {
+	repoDir, _ := setupLargeTestRepo(t)
+	reg := sparseTestRegistry(t, repoDir)
+	if _, err := reg.List(); err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if err := os.Rename(repoDir, repoDir+".offline"); err != nil {
+		t.Fatal(err)
+	}
+
+	reg.InstructionsPath = "docs"
+	if _, _, err := reg.Fetch("tdd"); err != nil {
+		t.Fatalf("expected the existing checkout to keep serving while offline: %v", err)
+	}
+	if !reg.sparseStale() {
+		t.Fatal("expected the checkout to stay as it was until a re-clone succeeds")
+	}
+	leftovers, _ := filepath.Glob(reg.CachePath + ".download-*")
+	if len(leftovers) != 0 {
+		t.Fatalf("expected the failed re-clone to be cleaned up, found %v", leftovers)
+	}
+}
+
+func TestGitRegistry_RefreshKeepsShallowSparse(t *testing.T) {
+	repoDir, run := setupLargeTestRepo(t)
+	reg := sparseTestRegistry(t, repoDir)
+	if _, err := reg.List(); err != nil {
+		t.Fatalf("List: %v", err)
+	}
+
+	if err := os.MkdirAll(filepath.Join(repoDir, "skills", "deploy"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repoDir, "skills", "deploy", "SKILL.md"), []byte("---\nname: deploy\n---\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	run("add", ".")
+	run("commit", "-m", "add deploy")
+
+	if err := reg.Refresh(); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	names, err := reg.List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(names) != 2 {
+		t.Fatalf("expected the refreshed skill, got %v", names)
+	}
+	if _, err := os.Stat(filepath.Join(reg.CachePath, "docs")); !os.IsNotExist(err) {
+		t.Fatalf("expected refresh to keep the checkout sparse, got %v", err)
+	}
+	if !isShallow(openCache(t, reg)) {
+		t.Fatal("expected refresh to keep the clone shallow")
+	}
+}
+
+func TestGitRegistry_HistoryDeepensOnDemand(t *testing.T) {
+	repoDir, _ := setupLargeTestRepo(t)
+	reg := sparseTestRegistry(t, repoDir)
+
+	commits, err := reg.History(1)
+	if err != nil {
+		t.Fatalf("History(1): %v", err)
+	}
+	if len(commits) != 1 || commits[0].Subject != "third" {
+		t.Fatalf("unexpected history %+v", commits)
+	}
+	if !isShallow(openCache(t, reg)) {
+		t.Fatal("History(1) should not need to deepen")
+	}
+
+	commits, err = reg.History(0)
+	if err != nil {
+		t.Fatalf("History(0): %v", err)
+	}
+	var subjects []string
+	for _, c := range commits {
+		subjects = append(subjects, c.Subject)
+	}
+	if strings.Join(subjects, ",") != "third,second,initial commit" {
+		t.Fatalf("expected the full history after deepening, got %v", subjects)
+	}
+	if isShallow(openCache(t, reg)) {
+		t.Fatal("expected the clone to be complete after deepening fully")
+	}
+}
+
+func TestGitRegistry_FetchesPinnedSHAOnly(t *testing.T) {
+	isolateGitCredentials(t)
+	repoDir, run := setupLargeTestRepo(t)
+	sha := run("rev-parse", "HEAD~1")
+
+	reg := sparseTestRegistry(t, serveGitOverHTTP(t, repoDir, "u", "p"))
+	reg.Ref = sha
+	reg.Auth = &GitAuth{TokenEnv: "PV_TEST_GIT_TOKEN", Username: "u"}
+	t.Setenv("PV_TEST_GIT_TOKEN", "p")
+
+	if _, _, err := reg.Fetch("tdd"); err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	repo := openCache(t, reg)
+	if !isShallow(repo) {
+		t.Fatal("expected only the pinned commit to be fetched")
+	}
+	if _, err := repo.Reference(reg.pinnedRef(), false); err != nil {
+		t.Fatalf("expected the commit to be fetched by SHA: %v", err)
+	}
+	head, err := repo.Head()
+	if err != nil || head.Hash().String() != sha {
+		t.Fatalf("expected HEAD at %s, got %v (%v)", sha, head, err)
+	}
+
+	commits, err := reg.History(0)
+	if err != nil {
+		t.Fatalf("History: %v", err)
+	}
+	if len(commits) != 2 {
+		t.Fatalf("expected two commits up to the pinned one, got %+v", commits)
+	}
+}
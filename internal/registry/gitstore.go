@@ -0,0 +1,363 @@
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	git "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/cache"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+	"github.com/go-git/go-git/v5/storage/memory"
+
+	"github.com/go-git/go-billy/v5/osfs"
+)
+
+// alternatesFile lists, inside a checkout's .git directory, the object
+// directories it borrows objects from: that of its shared object store.
+const alternatesFile = "objects/info/alternates"
+
+// cloneTarget is what a new checkout fetches and checks out.
+type cloneTarget struct {
+	spec   config.RefSpec         // fetches the branch or tag; empty for a pinned commit
+	branch plumbing.ReferenceName // local branch to check out; empty for a detached HEAD
+}
+
+// openRepo opens the clone in dir, following its alternates file to a
+// shared object store at an absolute path, which git.PlainOpen can't.
+func openRepo(dir string) (*git.Repository, error) {
+	gitDir := filepath.Join(dir, ".git")
+	if _, err := os.Stat(gitDir); err != nil {
+		return nil, git.ErrRepositoryNotExists
+	}
+	st := filesystem.NewStorageWithOptions(osfs.New(gitDir), cache.NewObjectLRUDefault(), filesystem.Options{
+		AlternatesFS: osfs.New(string(filepath.Separator)),
+	})
+	return git.Open(st, osfs.New(dir))
+}
+
+// linkedStore returns the object store the clone in dir borrows objects
+// from, or "" when it holds all of its objects itself.
+func linkedStore(dir string) string {
+	data, err := os.ReadFile(filepath.Join(dir, ".git", filepath.FromSlash(alternatesFile)))
+	if err != nil {
+		return ""
+	}
+	line, _, _ := strings.Cut(string(data), "\n")
+	if line = strings.TrimSpace(line); line == "" {
+		return ""
+	}
+	return filepath.Dir(line)
+}
+
+// objectStore returns the repository that fetches for the clone repo in
+// dir go into: its linked store, or the clone itself.
+func objectStore(dir string, repo *git.Repository) (*git.Repository, error) {
+	store := linkedStore(dir)
+	if store == "" {
+		return repo, nil
+	}
+	s, err := git.PlainOpen(store)
+	if err != nil {
+		return nil, fmt.Errorf("open object store %s: %w", store, err)
+	}
+	return s, nil
+}
+
+// openStore opens the object store at ObjectsPath, creating it if needed.
+// created reports whether it was just created.
+func (r *GitRegistry) openStore() (store *git.Repository, created bool, err error) {
+	store, err = git.PlainOpen(r.ObjectsPath)
+	if err == nil {
+		return store, false, nil
+	}
+	if !errors.Is(err, git.ErrRepositoryNotExists) {
+		return nil, false, fmt.Errorf("open object store %s: %w", r.ObjectsPath, err)
+	}
+	store, err = git.PlainInit(r.ObjectsPath, true)
+	if err != nil {
+		return nil, false, fmt.Errorf("create object store %s: %w", r.ObjectsPath, err)
+	}
+	if _, err := store.CreateRemote(&config.RemoteConfig{Name: git.DefaultRemoteName, URLs: []string{r.URL}}); err != nil {
+		return nil, true, fmt.Errorf("create object store %s: %w", r.ObjectsPath, err)
+	}
+	return store, true, nil
+}
+
+// clone creates the checkout in CachePath. Objects are fetched into the
+// shared store at ObjectsPath, when set, which the checkout borrows them
+// from; refs of the same repository cloned before are not downloaded again.
+func (r *GitRegistry) clone(auth transport.AuthMethod) (err error) {
+	repo, err := git.PlainInit(r.CachePath, false)
+	if err != nil {
+		return err
+	}
+	if _, err := repo.CreateRemote(&config.RemoteConfig{Name: git.DefaultRemoteName, URLs: []string{r.URL}}); err != nil {
+		return err
+	}
+
+	store := repo
+	if r.ObjectsPath != "" {
+		var created bool
+		store, created, err = r.openStore()
+		if created {
+			defer func() {
+				if err != nil {
+					_ = os.RemoveAll(r.ObjectsPath)
+				}
+			}()
+		}
+		if err != nil {
+			return err
+		}
+		objects, err := filepath.Abs(filepath.Join(r.ObjectsPath, "objects"))
+		if err != nil {
+			return err
+		}
+		alternates := filepath.Join(r.CachePath, ".git", filepath.FromSlash(alternatesFile))
+		if err := os.MkdirAll(filepath.Dir(alternates), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(alternates, []byte(objects+"\n"), 0o644); err != nil {
+			return err
+		}
+		if repo, err = openRepo(r.CachePath); err != nil {
+			return err
+		}
+	}
+
+	target, err := r.resolveTarget(auth)
+	if err != nil {
+		return err
+	}
+	hash, err := r.fetchTarget(store, target, auth)
+	if err != nil {
+		return err
+	}
+	if err := copyShallow(store, repo); err != nil {
+		return err
+	}
+	if target.branch == "" {
+		return r.checkout(repo, &git.CheckoutOptions{Hash: hash})
+	}
+	if err := repo.Storer.SetReference(plumbing.NewHashReference(target.branch, hash)); err != nil {
+		return err
+	}
+	return r.checkout(repo, &git.CheckoutOptions{Branch: target.branch})
+}
+
+// resolveTarget works out what Ref names on the remote: the default branch
+// for "latest", else a branch, else a tag. Commit SHAs need no lookup.
+func (r *GitRegistry) resolveTarget(auth transport.AuthMethod) (cloneTarget, error) {
+	if r.isPinned() && isSHA(r.Ref) {
+		return cloneTarget{}, nil
+	}
+	remote := git.NewRemote(memory.NewStorage(), &config.RemoteConfig{Name: git.DefaultRemoteName, URLs: []string{r.URL}})
+	refs, err := remote.List(&git.ListOptions{Auth: auth})
+	if err != nil {
+		return cloneTarget{}, fmt.Errorf("git clone %s: %w", RedactURL(r.URL), err)
+	}
+	byName := make(map[plumbing.ReferenceName]*plumbing.Reference, len(refs))
+	for _, ref := range refs {
+		byName[ref.Name()] = ref
+	}
+
+	branchTarget := func(branch plumbing.ReferenceName) cloneTarget {
+		remoteRef := plumbing.NewRemoteReferenceName(git.DefaultRemoteName, branch.Short())
+		return cloneTarget{spec: config.RefSpec(fmt.Sprintf("+%s:%s", branch, remoteRef)), branch: branch}
+	}
+
+	if r.isPinned() {
+		if _, ok := byName[plumbing.NewBranchReferenceName(r.Ref)]; ok {
+			return branchTarget(plumbing.NewBranchReferenceName(r.Ref)), nil
+		}
+		tag := plumbing.NewTagReferenceName(r.Ref)
+		if _, ok := byName[tag]; ok {
+			return cloneTarget{spec: config.RefSpec(fmt.Sprintf("+%s:%s", tag, tag))}, nil
+		}
+		return cloneTarget{}, fmt.Errorf("registry %q: ref %q not found as branch or tag in %s", r.RegistryName, r.Ref, RedactURL(r.URL))
+	}
+
+	head, ok := byName[plumbing.HEAD]
+	if ok && head.Type() == plumbing.SymbolicReference && head.Target().IsBranch() {
+		return branchTarget(head.Target()), nil
+	}
+	if ok {
+		// No symref capability: pick a branch at HEAD's commit.
+		for _, ref := range refs {
+			if ref.Name().IsBranch() && ref.Hash() == head.Hash() {
+				return branchTarget(ref.Name()), nil
+			}
+		}
+	}
+	return cloneTarget{}, fmt.Errorf("git clone %s: remote has no default branch", RedactURL(r.URL))
+}
+
+// storeDepth returns the depth to fetch a new ref at: 1, unless the store
+// already holds full history, which it keeps.
+func storeDepth(store *git.Repository) int {
+	if isShallow(store) {
+		return 1
+	}
+	refs, err := store.References()
+	if err != nil {
+		return 1
+	}
+	full := false
+	_ = refs.ForEach(func(ref *plumbing.Reference) error {
+		if ref.Type() == plumbing.HashReference {
+			full = true
+		}
+		return nil
+	})
+	if full {
+		return 0
+	}
+	return 1
+}
+
+// fetch runs a forced fetch of specs from the registry URL into store.
+func (r *GitRegistry) fetch(store *git.Repository, specs []config.RefSpec, depth int, auth transport.AuthMethod) error {
+	err := store.Fetch(&git.FetchOptions{
+		RemoteName: git.DefaultRemoteName,
+		RemoteURL:  r.URL,
+		RefSpecs:   specs,
+		Depth:      depth,
+		Auth:       auth,
+		Force:      true,
+	})
+	if err == git.NoErrAlreadyUpToDate {
+		return nil
+	}
+	return err
+}
+
+// fetchTarget fetches target into store and returns the commit to check
+// out. A pinned commit already in the store is not fetched again. A full
+// SHA is fetched on its own when the server allows it; otherwise (and for
+// abbreviated SHAs) every branch and tag is fetched with full history.
+func (r *GitRegistry) fetchTarget(store *git.Repository, target cloneTarget, auth transport.AuthMethod) (plumbing.Hash, error) {
+	if target.spec != "" {
+		if err := r.fetch(store, []config.RefSpec{target.spec}, storeDepth(store), auth); err != nil {
+			return plumbing.ZeroHash, fmt.Errorf("git clone %s: %w", RedactURL(r.URL), err)
+		}
+		ref, err := store.Reference(target.spec.Dst(""), true)
+		if err != nil {
+			return plumbing.ZeroHash, fmt.Errorf("registry %q: resolve %s: %w", r.RegistryName, target.spec.Dst(""), err)
+		}
+		return peelCommit(store, ref.Hash())
+	}
+
+	if h, err := store.ResolveRevision(plumbing.Revision(r.Ref)); err == nil {
+		if _, err := store.CommitObject(*h); err == nil {
+			return *h, nil
+		}
+	}
+	if len(r.Ref) == 40 {
+		spec := config.RefSpec(r.Ref + ":" + string(r.pinnedRef()))
+		if err := r.fetch(store, []config.RefSpec{spec}, 1, auth); err == nil {
+			return plumbing.NewHash(r.Ref), nil
+		}
+		// The server refused a fetch by SHA; fall back to full history.
+	}
+	depth := 0
+	if isShallow(store) {
+		depth = math.MaxInt32
+	}
+	specs := []config.RefSpec{
+		config.RefSpec(fmt.Sprintf("+refs/heads/*:refs/remotes/%s/*", git.DefaultRemoteName)),
+		"+refs/tags/*:refs/tags/*",
+	}
+	if err := r.fetch(store, specs, depth, auth); err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("git clone %s: %w", RedactURL(r.URL), err)
+	}
+	if err := pruneShallow(store); err != nil {
+		return plumbing.ZeroHash, err
+	}
+	h, err := store.ResolveRevision(plumbing.Revision(r.Ref))
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("registry %q: commit %s not found: %w", r.RegistryName, r.Ref, err)
+	}
+	return *h, nil
+}
+
+// peelCommit returns the commit h names, following annotated tags.
+func peelCommit(repo *git.Repository, h plumbing.Hash) (plumbing.Hash, error) {
+	tag, err := repo.TagObject(h)
+	if errors.Is(err, plumbing.ErrObjectNotFound) {
+		return h, nil
+	}
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	c, err := tag.Commit()
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	return c.Hash, nil
+}
+
+// copyShallow gives the checkout repo the shallow list of its object store,
+// so it knows where the fetched history ends.
+func copyShallow(store, repo *git.Repository) error {
+	if store == repo {
+		return nil
+	}
+	shallow, err := store.Storer.Shallow()
+	if err != nil {
+		return err
+	}
+	return repo.Storer.SetShallow(shallow)
+}
+
+// adoptLegacyCache moves a cache made before caches were keyed by URL and
+// ref from LegacyCachePath to CachePath, when it was cloned from the same
+// repository and has Ref checked out. Anything else is left for
+// `positive-vibes cache prune`.
+func (r *GitRegistry) adoptLegacyCache() {
+	legacy := r.LegacyCachePath
+	if legacy == "" || legacy == r.CachePath || exists(r.CachePath) {
+		return
+	}
+	repo, err := openRepo(legacy)
+	if err != nil {
+		return
+	}
+	remote, err := repo.Remote(git.DefaultRemoteName)
+	if err != nil || len(remote.Config().URLs) == 0 || NormalizeURL(remote.Config().URLs[0]) != NormalizeURL(r.URL) {
+		return
+	}
+	head, err := repo.Head()
+	if err != nil || !r.legacyMatches(repo, head) {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(r.CachePath), 0o755); err != nil {
+		return
+	}
+	_ = os.Rename(legacy, r.CachePath)
+}
+
+// legacyMatches reports whether a legacy checkout at head is what Ref asks
+// for: any branch for "latest", else the pinned commit, branch or tag.
+func (r *GitRegistry) legacyMatches(repo *git.Repository, head *plumbing.Reference) bool {
+	switch {
+	case !r.isPinned():
+		return head.Name().IsBranch()
+	case isSHA(r.Ref):
+		return strings.HasPrefix(head.Hash().String(), r.Ref)
+	case head.Name() == plumbing.NewBranchReferenceName(r.Ref):
+		return true
+	}
+	tag, err := repo.Tag(r.Ref)
+	if err != nil {
+		return false
+	}
+	c, err := peelCommit(repo, tag.Hash())
+	return err == nil && c == head.Hash()
+}
@@ -0,0 +1,215 @@
+package registry
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/chaz8081/positive-vibes/pkg/schema"
+	yaml "gopkg.in/yaml.v3"
+)
+
+// IndexFilenames are the registry index files looked for at a registry's
+// root, in order of preference.
+var IndexFilenames = []string{"index.yaml", "index.json"}
+
+// IndexVersion is the index format version BuildIndex writes.
+const IndexVersion = 1
+
+// ResourceKinds lists the kinds a registry serves, matching the keys of a
+// registry's paths.
+var ResourceKinds = []string{"skills", "instructions", "agents"}
+
+// Index lists a registry's resources so they can be listed and searched
+// without walking the registry and parsing every file.
+type Index struct {
+	Version   int          `yaml:"version" json:"version"`
+	Resources []IndexEntry `yaml:"resources" json:"resources"`
+}
+
+// IndexEntry describes one resource in a registry index.
+type IndexEntry struct {
+	// Name is the name to install the resource by.
+	Name string `yaml:"name" json:"name"`
+	// Kind is one of ResourceKinds.
+	Kind        string   `yaml:"kind" json:"kind"`
+	Description string   `yaml:"description,omitempty" json:"description,omitempty"`
+	Tags        []string `yaml:"tags,omitempty" json:"tags,omitempty"`
+	Version     string   `yaml:"version,omitempty" json:"version,omitempty"`
+	// Path is relative to the registry root, slash-separated: the skill
+	// directory, or the instruction or agent file.
+	Path string `yaml:"path" json:"path"`
+}
+
+// IndexSource is implemented by registries that can serve an index. Index
+// returns nil when the registry has none.
+type IndexSource interface {
+	SkillSource
+	Index() (*Index, error)
+}
+
+// errNotIndex marks a file without a version, which is not treated as an
+// index so that an unrelated index.yaml at a registry's root is ignored.
+var errNotIndex = errors.New("not a registry index: version is missing")
+
+// ParseIndex reads an index in YAML or JSON.
+func ParseIndex(data []byte) (*Index, error) {
+	var ix Index
+	if err := yaml.Unmarshal(data, &ix); err != nil {
+		return nil, err
+	}
+	if ix.Version == 0 {
+		return nil, errNotIndex
+	}
+	if ix.Version > IndexVersion {
+		return nil, fmt.Errorf("index version %d is newer than this positive-vibes supports (%d); upgrade positive-vibes", ix.Version, IndexVersion)
+	}
+	for i, e := range ix.Resources {
+		switch {
+		case e.Name == "":
+			return nil, fmt.Errorf("resources[%d]: name is required", i)
+		case !containsKind(e.Kind):
+			return nil, fmt.Errorf("resources[%d] (%s): invalid kind %q (use one of: %s)", i, e.Name, e.Kind, strings.Join(ResourceKinds, ", "))
+		case e.Path == "" || path.IsAbs(e.Path) || !fs.ValidPath(path.Clean(e.Path)):
+			return nil, fmt.Errorf("resources[%d] (%s): path must be relative to the registry root", i, e.Name)
+		}
+	}
+	return &ix, nil
+}
+
+func containsKind(kind string) bool {
+	for _, k := range ResourceKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// BuildIndex scans the registry at root: every directory holding a SKILL.md
+// is a skill, and every *.instructions.md and *.agent.md file is an
+// instruction or agent, including those inside skill and hidden directories,
+// so that the index lists whatever a scan of a registry's paths would.
+// Descriptions, tags and versions come from each file's frontmatter. The
+// .git directory is skipped.
+func BuildIndex(root string) (*Index, error) {
+	ix := &Index{Version: IndexVersion, Resources: []IndexEntry{}}
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			if data, err := os.ReadFile(filepath.Join(p, "SKILL.md")); err == nil && rel != "." {
+				ix.Resources = append(ix.Resources, indexEntry("skills", d.Name(), rel, data))
+			}
+			return nil
+		}
+		for kind, suffix := range map[string]string{"instructions": ".instructions.md", "agents": ".agent.md"} {
+			if name, ok := strings.CutSuffix(d.Name(), suffix); ok && name != "" {
+				data, err := os.ReadFile(p)
+				if err != nil {
+					return err
+				}
+				ix.Resources = append(ix.Resources, indexEntry(kind, name, rel, data))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(ix.Resources, func(i, j int) bool {
+		a, b := ix.Resources[i], ix.Resources[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.Path < b.Path
+	})
+	return ix, nil
+}
+
+func indexEntry(kind, name, rel string, data []byte) IndexEntry {
+	e := IndexEntry{Name: name, Kind: kind, Path: rel}
+	if meta, err := schema.ParseSkillFile(data); err == nil {
+		e.Description = strings.TrimSpace(meta.Description)
+		e.Tags = meta.Tags
+		e.Version = meta.Version
+	}
+	return e
+}
+
+// WriteIndex writes ix to root as index.yaml, or index.json when asJSON is
+// set, and returns the path written.
+func WriteIndex(root string, ix *Index, asJSON bool) (string, error) {
+	name := IndexFilenames[0]
+	data, err := yaml.Marshal(ix)
+	if asJSON {
+		name = IndexFilenames[1]
+		data, err = json.MarshalIndent(ix, "", "  ")
+		data = append(data, '\n')
+	}
+	if err != nil {
+		return "", fmt.Errorf("marshal index: %w", err)
+	}
+	p := filepath.Join(root, name)
+	if err := os.WriteFile(p, data, 0o644); err != nil {
+		return "", fmt.Errorf("write index: %w", err)
+	}
+	return p, nil
+}
+
+// index reads the tree's index file, returning nil when there is none.
+func (t dirTree) index() (*Index, error) {
+	for _, name := range IndexFilenames {
+		data, err := os.ReadFile(filepath.Join(t.root, name))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		ix, err := ParseIndex(data)
+		if errors.Is(err, errNotIndex) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("registry %s: %s: %w", t.registryName, name, err)
+		}
+		return ix, nil
+	}
+	return nil, nil
+}
+
+// indexedPaths returns the paths of ix's entries of the given kind,
+// relative to the tree's base path for that kind. Entries outside the base
+// path are left out.
+func (t dirTree) indexedPaths(ix *Index, kind string) []string {
+	base := t.kindDir(kind)
+	var out []string
+	for _, e := range ix.Resources {
+		if e.Kind != kind {
+			continue
+		}
+		rel, err := filepath.Rel(base, filepath.Join(t.root, filepath.FromSlash(e.Path)))
+		if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		out = append(out, filepath.ToSlash(rel))
+	}
+	sort.Strings(out)
+	return out
+}
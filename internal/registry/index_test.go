@@ -0,0 +1,149 @@
+package registry
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBuildIndex(t *testing.T) {
+	root := t.TempDir()
+	writeDirRegistryFile(t, root, "skills/tdd/SKILL.md", "---\nname: tdd\ndescription: Test-driven development\nversion: 1.2.0\ntags: [testing]\n---\nWrite the test first.\n")
+	writeDirRegistryFile(t, root, "skills/tdd/agents/helper.agent.md", "# Helper\n")
+	writeDirRegistryFile(t, root, ".github/instructions/review.instructions.md", "# Review\n")
+	writeDirRegistryFile(t, root, "instructions/go.instructions.md", "---\ndescription: Go style\n---\nUse gofmt.\n")
+	writeDirRegistryFile(t, root, "agents/debug.agent.md", "# Debugger\n")
+	writeDirRegistryFile(t, root, ".git/hooks/x.agent.md", "hidden")
+	writeDirRegistryFile(t, root, "README.md", "not a resource")
+
+	ix, err := BuildIndex(root)
+	if err != nil {
+		t.Fatalf("BuildIndex: %v", err)
+	}
+	want := []IndexEntry{
+		{Name: "debug", Kind: "agents", Path: "agents/debug.agent.md"},
+		{Name: "helper", Kind: "agents", Path: "skills/tdd/agents/helper.agent.md"},
+		{Name: "review", Kind: "instructions", Path: ".github/instructions/review.instructions.md"},
+		{Name: "go", Kind: "instructions", Description: "Go style", Path: "instructions/go.instructions.md"},
+		{Name: "tdd", Kind: "skills", Description: "Test-driven development", Tags: []string{"testing"}, Version: "1.2.0", Path: "skills/tdd"},
+	}
+	if ix.Version != IndexVersion || !reflect.DeepEqual(ix.Resources, want) {
+		t.Fatalf("unexpected index:\n%+v", ix)
+	}
+
+	path, err := WriteIndex(root, ix, false)
+	if err != nil {
+		t.Fatalf("WriteIndex: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read index: %v", err)
+	}
+	parsed, err := ParseIndex(data)
+	if err != nil || !reflect.DeepEqual(parsed, ix) {
+		t.Fatalf("round trip: %+v, %v", parsed, err)
+	}
+}
+
+func TestParseIndex_Errors(t *testing.T) {
+	tests := map[string]string{
+		"version: 9\nresources: []\n":                                             "newer than",
+		"version: 1\nresources:\n  - kind: skills\n    path: a\n":                 "name is required",
+		"version: 1\nresources:\n  - name: a\n    kind: x\n    path: a\n":         "invalid kind",
+		"version: 1\nresources:\n  - name: a\n    kind: skills\n    path: ../a\n": "relative to the registry root",
+	}
+	for input, want := range tests {
+		_, err := ParseIndex([]byte(input))
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("ParseIndex(%q): expected %q, got %v", input, want, err)
+		}
+	}
+}
+
+func TestDirRegistry_PrefersIndex(t *testing.T) {
+	root := t.TempDir()
+	writeDirRegistryFile(t, root, "skills/tdd/SKILL.md", archiveSkill)
+	writeDirRegistryFile(t, root, "skills/unlisted/SKILL.md", archiveSkill)
+	writeDirRegistryFile(t, root, "docs/style.instructions.md", "Use tabs.")
+	writeDirRegistryFile(t, root, "index.json", `{"version": 1, "resources": [
+		{"name": "tdd", "kind": "skills", "description": "Test-driven development", "path": "skills/tdd"},
+		{"name": "style", "kind": "instructions", "path": "docs/style.instructions.md"},
+		{"name": "elsewhere", "kind": "instructions", "path": "other/elsewhere.instructions.md"}
+	]}`)
+
+	r := &DirRegistry{RegistryName: "shared", Path: root, SkillsPath: "skills", InstructionsPath: "docs"}
+	names, err := r.List()
+	if err != nil || !reflect.DeepEqual(names, []string{"tdd"}) {
+		t.Fatalf("List: %v, %v", names, err)
+	}
+	files, err := r.ListResourceFiles("instructions")
+	if err != nil || !reflect.DeepEqual(files, []string{"style.instructions.md"}) {
+		t.Fatalf("ListResourceFiles: %v, %v", files, err)
+	}
+	ix, err := r.Index()
+	if err != nil || ix == nil || ix.Resources[0].Description != "Test-driven development" {
+		t.Fatalf("Index: %+v, %v", ix, err)
+	}
+}
+
+func TestDirRegistry_BuiltIndexListsWhatAScanDoes(t *testing.T) {
+	root := t.TempDir()
+	writeDirRegistryFile(t, root, "tdd/SKILL.md", archiveSkill)
+	writeDirRegistryFile(t, root, "tdd/tdd.instructions.md", "Red, green, refactor.")
+	writeDirRegistryFile(t, root, ".github/instructions/go.instructions.md", "Use gofmt.")
+	writeDirRegistryFile(t, root, ".github/agents/review.agent.md", "# Reviewer\n")
+
+	r := &DirRegistry{RegistryName: "shared", Path: root, InstructionsPath: ".", AgentsPath: ".github/agents"}
+	scan := make(map[string][]string)
+	for _, kind := range []string{"instructions", "agents"} {
+		files, err := r.ListResourceFiles(kind)
+		if err != nil {
+			t.Fatalf("ListResourceFiles(%s): %v", kind, err)
+		}
+		for _, f := range files {
+			if strings.HasSuffix(f, "."+strings.TrimSuffix(kind, "s")+".md") {
+				scan[kind] = append(scan[kind], f)
+			}
+		}
+	}
+
+	ix, err := BuildIndex(root)
+	if err != nil {
+		t.Fatalf("BuildIndex: %v", err)
+	}
+	if _, err := WriteIndex(root, ix, false); err != nil {
+		t.Fatalf("WriteIndex: %v", err)
+	}
+	for kind, want := range scan {
+		got, err := r.ListResourceFiles(kind)
+		if err != nil || !reflect.DeepEqual(got, want) {
+			t.Fatalf("indexed %s: got %v (%v), want %v", kind, got, err, want)
+		}
+	}
+}
+
+func TestDirRegistry_IgnoresUnrelatedIndexFile(t *testing.T) {
+	root := t.TempDir()
+	writeDirRegistryFile(t, root, "tdd/SKILL.md", archiveSkill)
+	writeDirRegistryFile(t, root, "index.yaml", "apiVersion: v1\nentries: {}\n")
+
+	r := &DirRegistry{RegistryName: "shared", Path: root}
+	names, err := r.List()
+	if err != nil || !reflect.DeepEqual(names, []string{"tdd"}) {
+		t.Fatalf("List: %v, %v", names, err)
+	}
+	if ix, err := r.Index(); ix != nil || err != nil {
+		t.Fatalf("expected no index, got %+v, %v", ix, err)
+	}
+}
+
+func TestDirRegistry_InvalidIndex(t *testing.T) {
+	root := t.TempDir()
+	writeDirRegistryFile(t, root, "index.yaml", "version: 1\nresources:\n  - name: a\n    kind: widgets\n    path: a\n")
+
+	r := &DirRegistry{RegistryName: "shared", Path: root}
+	if _, err := r.List(); err == nil || !strings.Contains(err.Error(), "index.yaml") {
+		t.Fatalf("expected index error, got %v", err)
+	}
+}
@@ -1,6 +1,10 @@
 package registry
 
-import "github.com/chaz8081/positive-vibes/pkg/schema"
+import (
+	"io/fs"
+
+	"github.com/chaz8081/positive-vibes/pkg/schema"
+)
 
 // SkillSource abstracts where skills come from.
 type SkillSource interface {
@@ -35,3 +39,30 @@ type ResourceSource interface {
 	FetchResourceFile(kind, relPath string) ([]byte, error)
 	ListResourceFiles(kind string) ([]string, error)
 }
+
+// FSSource is implemented by registries that serve skill directories from an
+// fs.FS instead of a directory on disk (e.g., skills embedded in the binary).
+// Their Fetch returns an empty source dir; SkillFS exposes the files instead.
+type FSSource interface {
+	SkillSource
+	SkillFS(name string) (fs.FS, error)
+}
+
+// RootFileSource is implemented by registries that can read files anywhere
+// in their content, such as shared manifests referenced by extends.
+type RootFileSource interface {
+	SkillSource
+	// FetchRootFile retrieves raw file bytes by path relative to the
+	// registry root. Paths may not escape the root.
+	FetchRootFile(relPath string) ([]byte, error)
+}
+
+// Refresher is implemented by registries that keep a local copy of remote
+// content.
+type Refresher interface {
+	SkillSource
+	// CacheDir returns the directory holding the local copy.
+	CacheDir() string
+	// Refresh updates the local copy when the registry tracks "latest".
+	Refresh() error
+}
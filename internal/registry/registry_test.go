@@ -1,9 +1,11 @@
 package registry
 
 import (
+	"io/fs"
 	"path/filepath"
 	"strings"
 	"testing"
+	"testing/fstest"
 )
 
 func TestEmbeddedRegistry_Fetch_ConventionalCommits(t *testing.T) {
@@ -24,8 +26,8 @@ func TestEmbeddedRegistry_Fetch_ConventionalCommits(t *testing.T) {
 	if sk.Instructions == "" {
 		t.Fatalf("expected instructions to be set")
 	}
-	if path == "" {
-		t.Fatalf("expected a temp path")
+	if path != "" {
+		t.Fatalf("expected no on-disk path for embedded skill, got %s", path)
 	}
 }
 
@@ -70,6 +72,81 @@ func TestEmbeddedRegistry_List(t *testing.T) {
 	}
 }
 
+func TestEmbeddedRegistry_SkillFS_IncludesSupportFiles(t *testing.T) {
+	r := &EmbeddedRegistry{
+		RegistryName: "embedded",
+		FS: fstest.MapFS{
+			"demo/SKILL.md":          {Data: []byte("---\nname: demo\n---\n# Demo\n")},
+			"demo/scripts/run.sh":    {Data: []byte("echo hi\n")},
+			"demo/references/api.md": {Data: []byte("# API\n")},
+			"not-a-skill/README.md":  {Data: []byte("nope")},
+		},
+	}
+
+	names, err := r.List()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(names) != 1 || names[0] != "demo" {
+		t.Fatalf("expected only demo skill, got %v", names)
+	}
+
+	fsys, err := r.SkillFS("demo")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, err := fs.ReadFile(fsys, "scripts/run.sh")
+	if err != nil {
+		t.Fatalf("expected support file in skill FS: %v", err)
+	}
+	if string(data) != "echo hi\n" {
+		t.Fatalf("unexpected script content %q", data)
+	}
+
+	files, err := r.ListFiles("demo", "references")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(files) != 1 || files[0] != "api.md" {
+		t.Fatalf("expected [api.md], got %v", files)
+	}
+
+	if _, err := r.SkillFS("not-a-skill"); err == nil {
+		t.Fatalf("expected error for directory without SKILL.md")
+	}
+}
+
+func TestEmbeddedRegistry_BundledInstructionsAndAgents(t *testing.T) {
+	var r ResourceSource = NewEmbeddedRegistry()
+
+	insts, err := r.ListResourceFiles("instructions")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !containsSlice(insts, "code-quality.instructions.md") {
+		t.Fatalf("expected bundled code-quality instruction, got %v", insts)
+	}
+	agents, err := r.ListResourceFiles("agents")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !containsSlice(agents, "code-reviewer.agent.md") {
+		t.Fatalf("expected bundled code-reviewer agent, got %v", agents)
+	}
+
+	data, err := r.FetchResourceFile("agents", "code-reviewer.agent.md")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !contains(string(data), "Code Reviewer") {
+		t.Fatalf("unexpected agent content: %s", data)
+	}
+
+	if _, err := r.FetchResourceFile("agents", "missing.agent.md"); err == nil {
+		t.Fatalf("expected error for missing bundled agent")
+	}
+}
+
 func TestGitRegistry_Fetch_InvalidURL(t *testing.T) {
 	cacheDir := t.TempDir()
 	g := &GitRegistry{
@@ -0,0 +1,189 @@
+package registry
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/chaz8081/positive-vibes/pkg/schema"
+)
+
+// dirTree serves skills and resources from a registry's files on disk. The
+// registries that keep a local copy of their content (git clones, extracted
+// archives) read through it once the copy is in place.
+type dirTree struct {
+	registryName     string
+	root             string
+	skillsPath       string
+	instructionsPath string
+	agentsPath       string
+}
+
+// skillsDir returns the absolute path to the directory containing skills.
+func (t dirTree) skillsDir() string {
+	return t.kindDir("skills")
+}
+
+func (t dirTree) kindDir(kind string) string {
+	var p string
+	switch kind {
+	case "skills":
+		p = t.skillsPath
+	case "instructions":
+		p = t.instructionsPath
+	case "agents":
+		p = t.agentsPath
+	default:
+		p = "."
+	}
+	if p == "" || p == "." {
+		return t.root
+	}
+	return filepath.Join(t.root, p)
+}
+
+func (t dirTree) fetch(name string) (*schema.Skill, string, error) {
+	srcDir := filepath.Join(t.skillsDir(), name)
+	skillFile := filepath.Join(srcDir, "SKILL.md")
+
+	data, err := os.ReadFile(skillFile)
+	if err != nil {
+		return nil, "", fmt.Errorf("skill not found: %s (registry %s)", name, t.registryName)
+	}
+
+	sk, err := schema.ParseSkillFile(data)
+	if err != nil {
+		return nil, "", fmt.Errorf("parse skill %s: %w", name, err)
+	}
+
+	return sk, srcDir, nil
+}
+
+// list returns the skill directories directly under the skills path, from
+// the index when the tree has one.
+func (t dirTree) list() ([]string, error) {
+	ix, err := t.index()
+	if err != nil {
+		return nil, err
+	}
+	if ix != nil {
+		var names []string
+		for _, rel := range t.indexedPaths(ix, "skills") {
+			if !strings.Contains(rel, "/") {
+				names = append(names, rel)
+			}
+		}
+		return names, nil
+	}
+
+	base := t.skillsDir()
+	entries, err := os.ReadDir(base)
+	if err != nil {
+		return nil, fmt.Errorf("read skills dir: %w", err)
+	}
+
+	var names []string
+	for _, ent := range entries {
+		if !ent.IsDir() {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(base, ent.Name(), "SKILL.md")); err == nil {
+			names = append(names, ent.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (t dirTree) fetchFile(skillName, relPath string) ([]byte, error) {
+	path := filepath.Join(t.skillsDir(), skillName, relPath)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("file not found: %s/%s (registry %s)", skillName, relPath, t.registryName)
+	}
+	return data, nil
+}
+
+func (t dirTree) listFiles(skillName, relDir string) ([]string, error) {
+	dir := filepath.Join(t.skillsDir(), skillName, relDir)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read dir %s/%s: %w", skillName, relDir, err)
+	}
+
+	var names []string
+	for _, ent := range entries {
+		if !ent.IsDir() {
+			names = append(names, ent.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (t dirTree) fetchResourceFile(kind, relPath string) ([]byte, error) {
+	path := filepath.Join(t.kindDir(kind), relPath)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("file not found: %s (registry %s, kind %s)", relPath, t.registryName, kind)
+	}
+	return data, nil
+}
+
+func (t dirTree) fetchRootFile(relPath string) ([]byte, error) {
+	clean := filepath.Clean(filepath.FromSlash(relPath))
+	if filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return nil, fmt.Errorf("invalid path %q (registry %s): must stay inside the repository", relPath, t.registryName)
+	}
+	data, err := os.ReadFile(filepath.Join(t.root, clean))
+	if err != nil {
+		return nil, fmt.Errorf("file not found: %s (registry %s)", relPath, t.registryName)
+	}
+	return data, nil
+}
+
+// listResourceFiles lists the files under the base path for kind, or the
+// indexed resources of that kind when the tree has an index.
+func (t dirTree) listResourceFiles(kind string) ([]string, error) {
+	ix, err := t.index()
+	if err != nil {
+		return nil, err
+	}
+	if ix != nil {
+		return t.indexedPaths(ix, kind), nil
+	}
+
+	base := t.kindDir(kind)
+	if _, err := os.Stat(base); err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var names []string
+	err = filepath.WalkDir(base, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(base, path)
+		if err != nil {
+			return err
+		}
+		names = append(names, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+	return names, nil
+}
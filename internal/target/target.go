@@ -2,17 +2,23 @@ package target
 
 import (
 	"fmt"
-	"path/filepath"
-
+	"io/fs"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/chaz8081/positive-vibes/pkg/schema"
 )
 
-// InstallOpts controls how skills are installed.
+// InstallOpts controls how resources are installed.
 type InstallOpts struct {
-	Force bool // overwrite existing skills
+	Force bool // overwrite existing resources
 	Link  bool // create symlinks instead of copies
+
+	// SourceFS, when set, is read instead of sourceDir for a skill's support
+	// files. Skills served this way have nothing on disk to link to, so they
+	// are always copied.
+	SourceFS fs.FS
 }
 
 // Target knows how to install a skill for a specific AI tool.
@@ -60,12 +66,27 @@ func skillPath(projectRoot, skillDir, skillName string) string {
 	return filepath.Join(projectRoot, skillDir, skillName)
 }
 
+// SkillPath returns the directory t installs the named skill into.
+func SkillPath(t Target, projectRoot, name string) string {
+	return skillPath(projectRoot, t.SkillDir(), name)
+}
+
+// InstructionPath returns the file t installs the named instruction as.
+func InstructionPath(t Target, projectRoot, name string) string {
+	return filepath.Join(projectRoot, t.InstructionDir(), name+".md")
+}
+
+// AgentPath returns the file t installs the named agent as.
+func AgentPath(t Target, projectRoot, name string) string {
+	return filepath.Join(projectRoot, t.AgentDir(), name+".md")
+}
+
 // installGeneric contains shared installation logic for targets.
 func installGeneric(skill *schema.Skill, sourceDir, projectRoot, skillDir string, opts InstallOpts) error {
 	dest := skillPath(projectRoot, skillDir, skill.Name)
 
-	// check exists
-	if _, err := os.Stat(dest); err == nil {
+	// check exists (Lstat so a dangling link still counts as installed)
+	if _, err := os.Lstat(dest); err == nil {
 		if !opts.Force {
 			return fmt.Errorf("skill '%s' already exists for %s (use --force to overwrite)", skill.Name, skillDir)
 		}
@@ -80,12 +101,8 @@ func installGeneric(skill *schema.Skill, sourceDir, projectRoot, skillDir string
 		return err
 	}
 
-	if opts.Link {
-		// create symlink
-		if err := os.Symlink(sourceDir, dest); err != nil {
-			return err
-		}
-		return nil
+	if opts.Link && sourceDir != "" && opts.SourceFS == nil {
+		return linkSource(sourceDir, dest, projectRoot)
 	}
 
 	// copy mode: create dest and write SKILL.md
@@ -101,52 +118,60 @@ func installGeneric(skill *schema.Skill, sourceDir, projectRoot, skillDir string
 		return err
 	}
 
-	// copy additional files from sourceDir (simple recursive copy)
-	// if sourceDir doesn't exist or is same as dest, skip
-	if sourceDir != "" {
-		// walk sourceDir and copy files except SKILL.md
-		if err := filepath.WalkDir(sourceDir, func(path string, d os.DirEntry, err error) error {
-			if err != nil {
-				return err
-			}
-			rel, err := filepath.Rel(sourceDir, path)
-			if err != nil {
-				return err
-			}
-			if rel == "" || rel == "SKILL.md" {
-				return nil
-			}
-			targetPath := filepath.Join(dest, rel)
-			if d.IsDir() {
-				return os.MkdirAll(targetPath, 0o755)
-			}
-			// file: copy
-			data, err := os.ReadFile(path)
-			if err != nil {
-				return err
+	// copy additional files from the skill source
+	src := opts.SourceFS
+	if src == nil && sourceDir != "" {
+		src = os.DirFS(sourceDir)
+	}
+	if src != nil {
+		if err := copySkillFiles(src, dest); err != nil {
+			if sourceDir != "" {
+				return fmt.Errorf("copy skill files from %s: %w", sourceDir, err)
 			}
-			return os.WriteFile(targetPath, data, 0o644)
-		}); err != nil {
-			return err
+			return fmt.Errorf("copy skill files: %w", err)
 		}
 	}
 
 	return nil
 }
 
+// copySkillFiles copies every file from src into dest except SKILL.md, which
+// installGeneric renders separately.
+func copySkillFiles(src fs.FS, dest string) error {
+	return fs.WalkDir(src, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == "." || path == "SKILL.md" {
+			return nil
+		}
+		targetPath := filepath.Join(dest, filepath.FromSlash(path))
+		if d.IsDir() {
+			return os.MkdirAll(targetPath, 0o755)
+		}
+		data, err := fs.ReadFile(src, path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(targetPath, data, 0o644)
+	})
+}
+
 // installInstructionGeneric writes an instruction file as <name>.md into the
 // target's instruction directory. Either content or sourcePath must be provided.
 func installInstructionGeneric(name, content, sourcePath, projectRoot, instDir string, opts InstallOpts) error {
 	dest := filepath.Join(projectRoot, instDir, name+".md")
 
-	if _, err := os.Stat(dest); err == nil {
-		if !opts.Force {
+	if err := prepareFileDest(dest, opts); err != nil {
+		if os.IsExist(err) {
 			return fmt.Errorf("instruction '%s' already exists for %s (use --force to overwrite)", name, instDir)
 		}
+		return err
 	}
 
-	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
-		return err
+	// Inline content has no file to point at, so it is always written.
+	if opts.Link && content == "" && sourcePath != "" {
+		return linkSource(sourcePath, dest, projectRoot)
 	}
 
 	var data []byte
@@ -170,14 +195,15 @@ func installInstructionGeneric(name, content, sourcePath, projectRoot, instDir s
 func installAgentGeneric(name, sourcePath, projectRoot, agentDir string, opts InstallOpts) error {
 	dest := filepath.Join(projectRoot, agentDir, name+".md")
 
-	if _, err := os.Stat(dest); err == nil {
-		if !opts.Force {
+	if err := prepareFileDest(dest, opts); err != nil {
+		if os.IsExist(err) {
 			return fmt.Errorf("agent '%s' already exists for %s (use --force to overwrite)", name, agentDir)
 		}
+		return err
 	}
 
-	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
-		return err
+	if opts.Link && sourcePath != "" {
+		return linkSource(sourcePath, dest, projectRoot)
 	}
 
 	data, err := os.ReadFile(sourcePath)
@@ -187,3 +213,65 @@ func installAgentGeneric(name, sourcePath, projectRoot, agentDir string, opts In
 
 	return os.WriteFile(dest, data, 0o644)
 }
+
+// prepareFileDest makes dest ready to receive a single-file resource. An
+// existing file or link is removed when opts.Force is set (removing rather than
+// overwriting keeps a copy from writing through an old symlink into its
+// source); otherwise an os.ErrExist error is returned.
+func prepareFileDest(dest string, opts InstallOpts) error {
+	if _, err := os.Lstat(dest); err == nil {
+		if !opts.Force {
+			return os.ErrExist
+		}
+		if err := os.Remove(dest); err != nil {
+			return err
+		}
+	}
+	return os.MkdirAll(filepath.Dir(dest), 0o755)
+}
+
+// linkSource creates a symlink at dest pointing to source. Sources inside
+// projectRoot are linked relatively so the link keeps working when the
+// repository is cloned somewhere else; anything outside is linked absolutely.
+func linkSource(source, dest, projectRoot string) error {
+	absSource, err := filepath.Abs(source)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(absSource); err != nil {
+		return fmt.Errorf("link source: %w", err)
+	}
+
+	linkTarget := absSource
+	if PathWithin(projectRoot, absSource) {
+		absDest, err := filepath.Abs(dest)
+		if err != nil {
+			return err
+		}
+		if rel, err := filepath.Rel(filepath.Dir(absDest), absSource); err == nil {
+			linkTarget = rel
+		}
+	}
+	return os.Symlink(linkTarget, dest)
+}
+
+// PathWithin reports whether path is dir itself or located beneath it.
+// Both paths are made absolute before comparison.
+func PathWithin(dir, path string) bool {
+	if dir == "" || path == "" {
+		return false
+	}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return false
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(absDir, absPath)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
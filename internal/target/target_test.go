@@ -326,3 +326,107 @@ func TestInstallAgent_Force(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, "new agent", string(b))
 }
+
+func TestTarget_Install_LinkInsideProjectIsRelative(t *testing.T) {
+	proj := t.TempDir()
+	src := filepath.Join(proj, "skills", "test-skill")
+	require.NoError(t, os.MkdirAll(src, 0o755))
+	s := writeTempSkill(t, src)
+
+	tgt := CopilotTarget{}
+	require.NoError(t, tgt.Install(s, src, proj, InstallOpts{Link: true}))
+
+	installed := filepath.Join(proj, ".github", "skills", s.Name)
+	link, err := os.Readlink(installed)
+	require.NoError(t, err)
+	assert.False(t, filepath.IsAbs(link))
+	assert.Equal(t, filepath.Join("..", "..", "skills", "test-skill"), link)
+
+	_, err = os.Stat(filepath.Join(installed, "SKILL.md"))
+	require.NoError(t, err)
+}
+
+func TestTarget_Install_LinkOutsideProjectIsAbsolute(t *testing.T) {
+	src := t.TempDir()
+	s := writeTempSkill(t, src)
+	proj := t.TempDir()
+
+	tgt := CursorTarget{}
+	require.NoError(t, tgt.Install(s, src, proj, InstallOpts{Link: true}))
+
+	link, err := os.Readlink(filepath.Join(proj, ".cursor", "skills", s.Name))
+	require.NoError(t, err)
+	assert.True(t, filepath.IsAbs(link))
+}
+
+func TestInstallInstruction_Link(t *testing.T) {
+	proj := t.TempDir()
+	srcFile := filepath.Join(proj, "instructions", "guide.md")
+	require.NoError(t, os.MkdirAll(filepath.Dir(srcFile), 0o755))
+	require.NoError(t, os.WriteFile(srcFile, []byte("linked guide"), 0o644))
+
+	tgt := OpenCodeTarget{}
+	require.NoError(t, tgt.InstallInstruction("guide", "", srcFile, proj, InstallOpts{Link: true}))
+
+	dest := filepath.Join(proj, ".opencode", "instructions", "guide.md")
+	link, err := os.Readlink(dest)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join("..", "..", "instructions", "guide.md"), link)
+	b, err := os.ReadFile(dest)
+	require.NoError(t, err)
+	assert.Equal(t, "linked guide", string(b))
+}
+
+func TestInstallInstruction_LinkWithContentWritesFile(t *testing.T) {
+	proj := t.TempDir()
+
+	tgt := CopilotTarget{}
+	require.NoError(t, tgt.InstallInstruction("style", "inline", "", proj, InstallOpts{Link: true}))
+
+	fi, err := os.Lstat(filepath.Join(proj, ".github", "instructions", "style.md"))
+	require.NoError(t, err)
+	assert.True(t, fi.Mode().IsRegular())
+}
+
+func TestInstallAgent_Link(t *testing.T) {
+	proj := t.TempDir()
+	srcFile := filepath.Join(proj, "agents", "reviewer.md")
+	require.NoError(t, os.MkdirAll(filepath.Dir(srcFile), 0o755))
+	require.NoError(t, os.WriteFile(srcFile, []byte("# Reviewer"), 0o644))
+
+	tgt := CursorTarget{}
+	require.NoError(t, tgt.InstallAgent("reviewer", srcFile, proj, InstallOpts{Link: true}))
+
+	link, err := os.Readlink(filepath.Join(proj, ".cursor", "agents", "reviewer.md"))
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join("..", "..", "agents", "reviewer.md"), link)
+}
+
+func TestInstallAgent_ForceCopyOverLinkKeepsSource(t *testing.T) {
+	proj := t.TempDir()
+	srcFile := filepath.Join(proj, "agents", "reviewer.md")
+	otherFile := filepath.Join(proj, "agents", "other.md")
+	require.NoError(t, os.MkdirAll(filepath.Dir(srcFile), 0o755))
+	require.NoError(t, os.WriteFile(srcFile, []byte("original"), 0o644))
+	require.NoError(t, os.WriteFile(otherFile, []byte("replacement"), 0o644))
+
+	tgt := OpenCodeTarget{}
+	require.NoError(t, tgt.InstallAgent("reviewer", srcFile, proj, InstallOpts{Link: true}))
+	require.NoError(t, tgt.InstallAgent("reviewer", otherFile, proj, InstallOpts{Force: true}))
+
+	b, err := os.ReadFile(srcFile)
+	require.NoError(t, err)
+	assert.Equal(t, "original", string(b), "copy must not write through the old symlink")
+
+	fi, err := os.Lstat(filepath.Join(proj, ".opencode", "agents", "reviewer.md"))
+	require.NoError(t, err)
+	assert.True(t, fi.Mode().IsRegular())
+}
+
+func TestPathWithin(t *testing.T) {
+	assert.True(t, PathWithin("/a/b", "/a/b"))
+	assert.True(t, PathWithin("/a/b", "/a/b/c/d"))
+	assert.False(t, PathWithin("/a/b", "/a/bc"))
+	assert.False(t, PathWithin("/a/b", "/a"))
+	assert.False(t, PathWithin("", "/a"))
+}
@@ -2,5 +2,8 @@ package skills
 
 import "embed"
 
-//go:embed */SKILL.md
+// SkillsFS holds every bundled skill directory, including any scripts or
+// reference files that sit alongside SKILL.md.
+//
+//go:embed */*
 var SkillsFS embed.FS